@@ -0,0 +1,641 @@
+package zaguansdk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ChatStreamAccumulator assembles streamed chat completion chunks into a
+// complete assistant turn.
+//
+// Feed every event received from ChatStream.Recv to Add. Once the stream is
+// finished, AssistantMessage returns the assistant message (content and fully
+// assembled tool calls) ready to append to the next request's Messages.
+// Only the first choice (index 0) is accumulated.
+//
+// Example:
+//
+//	acc := zaguansdk.NewChatStreamAccumulator()
+//	for {
+//		event, err := stream.Recv()
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		acc.Add(event)
+//	}
+//	req.Messages = append(req.Messages, acc.AssistantMessage())
+type ChatStreamAccumulator struct {
+	// OnToken, if set, is called by Add with each piece of new content.
+	// Only complete UTF-8 characters are passed; a character split across
+	// deltas is held back until the rest of it arrives, so the text can be
+	// printed directly. Any held-back bytes are flushed when the stream
+	// reports a finish reason.
+	OnToken func(text string)
+
+	role         string
+	content      strings.Builder
+	tokens       UTF8DeltaBuffer
+	toolCalls    []ToolCall
+	toolIndex    map[int]int
+	finishReason string
+	usage        *Usage
+	citations    []string
+}
+
+// NewChatStreamAccumulator creates an empty ChatStreamAccumulator.
+func NewChatStreamAccumulator() *ChatStreamAccumulator {
+	return &ChatStreamAccumulator{
+		toolIndex: make(map[int]int),
+	}
+}
+
+// Add merges a stream event into the accumulated state.
+func (a *ChatStreamAccumulator) Add(event *ChatStreamEvent) {
+	if event == nil {
+		return
+	}
+
+	if event.Usage != nil {
+		a.usage = event.Usage
+	}
+	if len(event.Citations) > 0 {
+		a.citations = event.Citations
+	}
+
+	for _, choice := range event.Choices {
+		if choice.Index != 0 {
+			continue
+		}
+
+		if choice.Delta.Role != "" {
+			a.role = choice.Delta.Role
+		}
+		a.content.WriteString(choice.Delta.Content)
+		if a.OnToken != nil {
+			if text := a.tokens.Write(choice.Delta.Content); text != "" {
+				a.OnToken(text)
+			}
+		}
+
+		for _, tc := range choice.Delta.ToolCalls {
+			a.addToolCall(tc)
+		}
+
+		if choice.FinishReason != nil {
+			a.finishReason = *choice.FinishReason
+			if a.OnToken != nil {
+				if text := a.tokens.Flush(); text != "" {
+					a.OnToken(text)
+				}
+			}
+		}
+	}
+}
+
+// addToolCall merges a tool call fragment into the accumulated tool calls.
+func (a *ChatStreamAccumulator) addToolCall(delta ToolCall) {
+	pos := -1
+	if delta.Index != nil {
+		if p, ok := a.toolIndex[*delta.Index]; ok {
+			pos = p
+		}
+	} else if delta.ID == "" && len(a.toolCalls) > 0 {
+		// Providers that omit the index continue the last tool call
+		pos = len(a.toolCalls) - 1
+	}
+
+	if pos < 0 {
+		a.toolCalls = append(a.toolCalls, ToolCall{})
+		pos = len(a.toolCalls) - 1
+		if delta.Index != nil {
+			a.toolIndex[*delta.Index] = pos
+		}
+	}
+
+	tc := &a.toolCalls[pos]
+	if delta.ID != "" {
+		tc.ID = delta.ID
+	}
+	if delta.Type != "" {
+		tc.Type = delta.Type
+	}
+	if delta.Function.Name != "" {
+		tc.Function.Name = delta.Function.Name
+	}
+	tc.Function.Arguments += delta.Function.Arguments
+}
+
+// Validate checks the accumulated tool calls for stream corruption.
+//
+// Call it once the stream has ended. It returns a *StreamToolCallError if
+// tool-call indices are not contiguous from 0, or if any call's arguments are
+// not valid JSON (for example, because the stream ended mid-argument).
+// Calls with empty arguments are accepted.
+func (a *ChatStreamAccumulator) Validate() error {
+	// Map each call position back to its stream index
+	indexOf := make([]int, len(a.toolCalls))
+	for i := range indexOf {
+		indexOf[i] = i
+	}
+	maxIndex := -1
+	for index, pos := range a.toolIndex {
+		indexOf[pos] = index
+		if index > maxIndex {
+			maxIndex = index
+		}
+	}
+
+	var toolErr StreamToolCallError
+	for index := 0; index < maxIndex; index++ {
+		if _, ok := a.toolIndex[index]; !ok {
+			toolErr.MissingIndices = append(toolErr.MissingIndices, index)
+		}
+	}
+	for pos, tc := range a.toolCalls {
+		if tc.Function.Arguments != "" && !json.Valid([]byte(tc.Function.Arguments)) {
+			toolErr.InvalidIndices = append(toolErr.InvalidIndices, indexOf[pos])
+		}
+	}
+	sort.Ints(toolErr.InvalidIndices)
+
+	if len(toolErr.MissingIndices) > 0 || len(toolErr.InvalidIndices) > 0 {
+		return &toolErr
+	}
+	return nil
+}
+
+// Content returns the text content accumulated so far.
+func (a *ChatStreamAccumulator) Content() string {
+	return a.content.String()
+}
+
+// ToolCalls returns the tool calls assembled so far, in the order they were
+// first seen.
+func (a *ChatStreamAccumulator) ToolCalls() []ToolCall {
+	if len(a.toolCalls) == 0 {
+		return nil
+	}
+	calls := make([]ToolCall, len(a.toolCalls))
+	for i, tc := range a.toolCalls {
+		tc.Index = nil
+		if tc.Type == "" {
+			tc.Type = "function"
+		}
+		calls[i] = tc
+	}
+	return calls
+}
+
+// FinishReason returns the finish reason reported by the stream, if any.
+func (a *ChatStreamAccumulator) FinishReason() string {
+	return a.finishReason
+}
+
+// Usage returns the token usage reported by the stream, if any.
+func (a *ChatStreamAccumulator) Usage() *Usage {
+	return a.usage
+}
+
+// Citations returns the source URLs reported by the stream, if any.
+func (a *ChatStreamAccumulator) Citations() []string {
+	return a.citations
+}
+
+// AssistantMessage returns the complete assistant turn assembled from the
+// stream, suitable for appending to the next request's Messages.
+//
+// Content is omitted when the model only produced tool calls.
+func (a *ChatStreamAccumulator) AssistantMessage() Message {
+	role := a.role
+	if role == "" {
+		role = "assistant"
+	}
+
+	msg := Message{
+		Role:      role,
+		ToolCalls: a.ToolCalls(),
+	}
+	if content := a.content.String(); content != "" || len(msg.ToolCalls) == 0 {
+		msg.Content = content
+	}
+	return msg
+}
+
+// ChatAudioStreamAccumulator separates the text and audio of a streamed chat
+// completion that requested the "audio" modality.
+//
+// Text deltas are collected into a string. Audio arrives as base64 chunks,
+// which are decoded as they arrive and written to the io.Writer passed to
+// NewChatAudioStreamAccumulator, or buffered in memory if it is nil. The
+// spoken words are streamed separately as a transcript.
+// Only the first choice (index 0) is accumulated.
+//
+// Example:
+//
+//	f, err := os.Create("reply.pcm")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer f.Close()
+//
+//	acc := zaguansdk.NewChatAudioStreamAccumulator(f)
+//	for {
+//		event, err := stream.Recv()
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		if err := acc.Add(event); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//	if err := acc.Close(); err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(acc.Transcript())
+type ChatAudioStreamAccumulator struct {
+	text       strings.Builder
+	transcript strings.Builder
+	audioID    string
+	expiresAt  int64
+
+	w       io.Writer
+	buf     bytes.Buffer
+	pending string // base64 characters not yet decoded
+	err     error
+}
+
+// NewChatAudioStreamAccumulator creates a ChatAudioStreamAccumulator that
+// writes decoded audio to w. If w is nil, audio is buffered and returned by
+// Audio.
+func NewChatAudioStreamAccumulator(w io.Writer) *ChatAudioStreamAccumulator {
+	a := &ChatAudioStreamAccumulator{w: w}
+	if w == nil {
+		a.w = &a.buf
+	}
+	return a
+}
+
+// Add merges a stream event into the accumulated state, decoding and
+// writing any audio it carries. After an error, later calls do nothing and
+// return the same error.
+func (a *ChatAudioStreamAccumulator) Add(event *ChatStreamEvent) error {
+	if a.err != nil || event == nil {
+		return a.err
+	}
+
+	for _, choice := range event.Choices {
+		if choice.Index != 0 {
+			continue
+		}
+
+		a.text.WriteString(choice.Delta.Content)
+
+		audio := choice.Delta.Audio
+		if audio == nil {
+			continue
+		}
+		if audio.ID != "" {
+			a.audioID = audio.ID
+		}
+		if audio.ExpiresAt != 0 {
+			a.expiresAt = audio.ExpiresAt
+		}
+		a.transcript.WriteString(audio.Transcript)
+		if err := a.writeAudio(audio.Data); err != nil {
+			a.err = err
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAudio decodes the complete base64 quanta of pending+data and writes
+// them, holding back a trailing partial quantum for the next chunk.
+func (a *ChatAudioStreamAccumulator) writeAudio(data string) error {
+	if data == "" {
+		return nil
+	}
+	encoded := a.pending + data
+	cut := len(encoded) - len(encoded)%4
+	a.pending = encoded[cut:]
+	if cut == 0 {
+		return nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(encoded[:cut])
+	if err != nil {
+		return fmt.Errorf("failed to decode audio chunk: %w", err)
+	}
+	if _, err := a.w.Write(decoded); err != nil {
+		return fmt.Errorf("failed to write audio: %w", err)
+	}
+	return nil
+}
+
+// Close reports any error from Add and checks that the stream ended on a
+// complete audio chunk. Call it once the stream has returned io.EOF. It does
+// not close the writer.
+func (a *ChatAudioStreamAccumulator) Close() error {
+	if a.err != nil {
+		return a.err
+	}
+	if a.pending != "" {
+		a.err = errors.New("audio stream ended with an incomplete base64 chunk")
+	}
+	return a.err
+}
+
+// Text returns the text content accumulated so far.
+func (a *ChatAudioStreamAccumulator) Text() string {
+	return a.text.String()
+}
+
+// Transcript returns the transcript of the audio accumulated so far.
+func (a *ChatAudioStreamAccumulator) Transcript() string {
+	return a.transcript.String()
+}
+
+// Audio returns the decoded audio when no writer was given to
+// NewChatAudioStreamAccumulator, and nil otherwise.
+func (a *ChatAudioStreamAccumulator) Audio() []byte {
+	if a.w != &a.buf {
+		return nil
+	}
+	return a.buf.Bytes()
+}
+
+// AudioID returns the identifier of the audio response, which can be used to
+// refer to the audio in follow-up requests until AudioExpiresAt.
+func (a *ChatAudioStreamAccumulator) AudioID() string {
+	return a.audioID
+}
+
+// AudioExpiresAt returns the Unix timestamp after which the audio can no
+// longer be referenced, or zero if the stream did not report one.
+func (a *ChatAudioStreamAccumulator) AudioExpiresAt() int64 {
+	return a.expiresAt
+}
+
+// UTF8DeltaBuffer splits streamed text deltas on UTF-8 character boundaries.
+//
+// A multi-byte character can be split across two deltas. Printing each delta
+// as it arrives would then emit a partial character. Write returns only the
+// complete characters received so far and holds back a trailing partial
+// character until the next delta completes it.
+//
+// The zero value is ready to use.
+//
+// Example:
+//
+//	var buf zaguansdk.UTF8DeltaBuffer
+//	for _, delta := range deltas {
+//		fmt.Print(buf.Write(delta))
+//	}
+//	fmt.Print(buf.Flush())
+type UTF8DeltaBuffer struct {
+	pending []byte
+}
+
+// Write adds delta to the buffer and returns the text that ends on a
+// complete UTF-8 character.
+func (b *UTF8DeltaBuffer) Write(delta string) string {
+	if len(b.pending) == 0 && utf8.ValidString(delta) {
+		return delta
+	}
+
+	b.pending = append(b.pending, delta...)
+	cut := len(b.pending)
+
+	// Look for an incomplete character in the last utf8.UTFMax-1 bytes
+	for i := len(b.pending) - 1; i >= 0 && i >= len(b.pending)-(utf8.UTFMax-1); i-- {
+		if utf8.RuneStart(b.pending[i]) {
+			if !utf8.FullRune(b.pending[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+
+	text := string(b.pending[:cut])
+	b.pending = append(b.pending[:0], b.pending[cut:]...)
+	return text
+}
+
+// Flush returns any held-back bytes and empties the buffer. Call it when the
+// stream ends; bytes of a character that never completed are returned as-is.
+func (b *UTF8DeltaBuffer) Flush() string {
+	text := string(b.pending)
+	b.pending = b.pending[:0]
+	return text
+}
+
+// MessagesStreamAccumulator assembles streamed Anthropic Messages events into
+// the final text, thinking, stop reason, and token usage.
+//
+// Anthropic reports usage in message_delta events as running totals, not
+// increments, so the accumulator keeps the latest non-zero value of each
+// counter rather than summing them. This matters for cache metrics, which may
+// accrue over the course of the stream.
+//
+// Example:
+//
+//	acc := zaguansdk.NewMessagesStreamAccumulator()
+//	for {
+//		event, err := stream.Recv()
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		acc.Add(event)
+//	}
+//	fmt.Println(acc.Text(), acc.CacheReadInputTokens())
+//
+// Tool inputs arrive as input_json_delta fragments. The accumulator
+// reassembles them per content block, and ToolInputs returns each tool_use
+// block's input once its content_block_stop has arrived.
+type MessagesStreamAccumulator struct {
+	text       strings.Builder
+	thinking   strings.Builder
+	stopReason string
+	usage      *AnthropicUsage
+
+	toolJSON   map[int]*strings.Builder // partial input of open tool_use blocks
+	toolInputs map[int]json.RawMessage  // input of stopped tool_use blocks
+	invalid    []int                    // stopped tool_use blocks with invalid input
+}
+
+// NewMessagesStreamAccumulator creates an empty MessagesStreamAccumulator.
+func NewMessagesStreamAccumulator() *MessagesStreamAccumulator {
+	return &MessagesStreamAccumulator{}
+}
+
+// Add merges a stream event into the accumulated state.
+func (a *MessagesStreamAccumulator) Add(event *MessagesStreamEvent) {
+	if event == nil {
+		return
+	}
+
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			usage := event.Message.Usage
+			a.usage = &usage
+		}
+	case "content_block_start":
+		if event.ContentBlock != nil && event.ContentBlock.Type == "tool_use" {
+			if a.toolJSON == nil {
+				a.toolJSON = make(map[int]*strings.Builder)
+			}
+			a.toolJSON[event.Index] = &strings.Builder{}
+		}
+	case "content_block_delta":
+		if event.Delta != nil {
+			a.text.WriteString(event.Delta.Text)
+			a.thinking.WriteString(event.Delta.Thinking)
+			if buf, ok := a.toolJSON[event.Index]; ok {
+				buf.WriteString(event.Delta.PartialJSON)
+			}
+		}
+	case "content_block_stop":
+		buf, ok := a.toolJSON[event.Index]
+		if !ok {
+			return
+		}
+		delete(a.toolJSON, event.Index)
+
+		// A tool called without arguments streams no fragments
+		input := buf.String()
+		if strings.TrimSpace(input) == "" {
+			input = "{}"
+		}
+		if !json.Valid([]byte(input)) {
+			a.invalid = append(a.invalid, event.Index)
+			return
+		}
+		if a.toolInputs == nil {
+			a.toolInputs = make(map[int]json.RawMessage)
+		}
+		a.toolInputs[event.Index] = json.RawMessage(input)
+	case "message_delta":
+		if event.Delta != nil && event.Delta.StopReason != "" {
+			a.stopReason = event.Delta.StopReason
+		}
+		if event.Usage != nil {
+			if a.usage == nil {
+				a.usage = &AnthropicUsage{}
+			}
+			mergeAnthropicUsage(a.usage, event.Usage)
+		}
+	}
+}
+
+// Text returns the text content accumulated so far, excluding thinking and
+// tool input. It is the streaming equivalent of MessagesResponse.AnswerText.
+func (a *MessagesStreamAccumulator) Text() string {
+	return a.text.String()
+}
+
+// Thinking returns the extended thinking content accumulated so far.
+func (a *MessagesStreamAccumulator) Thinking() string {
+	return a.thinking.String()
+}
+
+// StopReason returns the stop reason reported by the stream, if any.
+func (a *MessagesStreamAccumulator) StopReason() string {
+	return a.stopReason
+}
+
+// Usage returns the latest token usage reported by the stream, if any.
+func (a *MessagesStreamAccumulator) Usage() *AnthropicUsage {
+	return a.usage
+}
+
+// ToolInputs returns the input of each tool_use block whose
+// content_block_stop has arrived, keyed by content block index. Blocks whose
+// reassembled input is not valid JSON are omitted; see Validate.
+//
+// Example:
+//
+//	for index, input := range acc.ToolInputs() {
+//		var args struct{ City string }
+//		if err := json.Unmarshal(input, &args); err != nil {
+//			log.Fatal(err)
+//		}
+//		fmt.Println(index, args.City)
+//	}
+func (a *MessagesStreamAccumulator) ToolInputs() map[int]json.RawMessage {
+	inputs := make(map[int]json.RawMessage, len(a.toolInputs))
+	for index, input := range a.toolInputs {
+		inputs[index] = input
+	}
+	return inputs
+}
+
+// Validate checks that every tool_use block's input was complete JSON.
+//
+// Call it once the stream has ended. It returns a *StreamToolCallError listing
+// the content block indices whose input is invalid or whose
+// content_block_stop never arrived.
+func (a *MessagesStreamAccumulator) Validate() error {
+	invalid := append([]int(nil), a.invalid...)
+	for index := range a.toolJSON {
+		invalid = append(invalid, index)
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	sort.Ints(invalid)
+	return &StreamToolCallError{InvalidIndices: invalid}
+}
+
+// CacheReadInputTokens returns the final number of input tokens read from
+// the prompt cache.
+func (a *MessagesStreamAccumulator) CacheReadInputTokens() int {
+	if a.usage == nil {
+		return 0
+	}
+	return a.usage.CacheReadInputTokens
+}
+
+// CacheCreationInputTokens returns the final number of input tokens written
+// to the prompt cache.
+func (a *MessagesStreamAccumulator) CacheCreationInputTokens() int {
+	if a.usage == nil {
+		return 0
+	}
+	return a.usage.CacheCreationInputTokens
+}
+
+// mergeAnthropicUsage applies a message_delta usage update to dst.
+//
+// message_delta counts are cumulative, so non-zero values replace the
+// snapshot instead of being added to it.
+func mergeAnthropicUsage(dst, update *AnthropicUsage) {
+	if update.InputTokens > 0 {
+		dst.InputTokens = update.InputTokens
+	}
+	if update.OutputTokens > 0 {
+		dst.OutputTokens = update.OutputTokens
+	}
+	if update.CacheCreationInputTokens > 0 {
+		dst.CacheCreationInputTokens = update.CacheCreationInputTokens
+	}
+	if update.CacheReadInputTokens > 0 {
+		dst.CacheReadInputTokens = update.CacheReadInputTokens
+	}
+}
@@ -0,0 +1,442 @@
+package zaguansdk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChatStreamAccumulator_Content(t *testing.T) {
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":", world"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`,
+	}
+
+	acc := NewChatStreamAccumulator()
+	for _, chunk := range chunks {
+		var event ChatStreamEvent
+		if err := json.Unmarshal([]byte(chunk), &event); err != nil {
+			t.Fatalf("unmarshal error = %v", err)
+		}
+		acc.Add(&event)
+	}
+
+	msg := acc.AssistantMessage()
+	if msg.Role != "assistant" {
+		t.Errorf("Role = %q, want assistant", msg.Role)
+	}
+	if msg.Content != "Hello, world" {
+		t.Errorf("Content = %v, want %q", msg.Content, "Hello, world")
+	}
+	if len(msg.ToolCalls) != 0 {
+		t.Errorf("ToolCalls = %v, want none", msg.ToolCalls)
+	}
+	if acc.FinishReason() != "stop" {
+		t.Errorf("FinishReason() = %q, want stop", acc.FinishReason())
+	}
+	if acc.Usage() == nil || acc.Usage().TotalTokens != 8 {
+		t.Errorf("Usage() = %+v, want total 8", acc.Usage())
+	}
+}
+
+func TestChatStreamAccumulator_ToolCalls(t *testing.T) {
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"loc"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"ation\":\"Paris\"}"}}]}}]}`,
+		`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+	}
+
+	acc := NewChatStreamAccumulator()
+	for _, chunk := range chunks {
+		var event ChatStreamEvent
+		if err := json.Unmarshal([]byte(chunk), &event); err != nil {
+			t.Fatalf("unmarshal error = %v", err)
+		}
+		acc.Add(&event)
+	}
+
+	msg := acc.AssistantMessage()
+	if msg.Content != nil {
+		t.Errorf("Content = %v, want nil for tool-only turn", msg.Content)
+	}
+	if len(msg.ToolCalls) != 2 {
+		t.Fatalf("got %d tool calls, want 2", len(msg.ToolCalls))
+	}
+
+	first := msg.ToolCalls[0]
+	if first.ID != "call_1" || first.Function.Name != "get_weather" {
+		t.Errorf("ToolCalls[0] = %+v", first)
+	}
+	if first.Function.Arguments != `{"location":"Paris"}` {
+		t.Errorf("ToolCalls[0].Arguments = %s", first.Function.Arguments)
+	}
+	if first.Index != nil {
+		t.Error("ToolCalls[0].Index should be cleared for the next request")
+	}
+	if msg.ToolCalls[1].ID != "call_2" || msg.ToolCalls[1].Function.Arguments != "{}" {
+		t.Errorf("ToolCalls[1] = %+v", msg.ToolCalls[1])
+	}
+	if err := acc.Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
+
+func TestChatStreamAccumulator_Validate(t *testing.T) {
+	tests := []struct {
+		name        string
+		chunks      []string
+		wantMissing []int
+		wantInvalid []int
+	}{
+		{
+			name: "skipped index",
+			chunks: []string{
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"a","arguments":"{}"}}]}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":2,"id":"call_3","function":{"name":"c","arguments":"{}"}}]}}]}`,
+			},
+			wantMissing: []int{1},
+		},
+		{
+			name: "unterminated arguments",
+			chunks: []string{
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"a","arguments":"{}"}}]}}]}`,
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_2","function":{"name":"b","arguments":"{\"city\":\"Par"}}]}}]}`,
+			},
+			wantInvalid: []int{1},
+		},
+		{
+			name: "empty arguments are accepted",
+			chunks: []string{
+				`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"a","arguments":""}}]}}]}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			acc := NewChatStreamAccumulator()
+			for _, chunk := range tt.chunks {
+				var event ChatStreamEvent
+				if err := json.Unmarshal([]byte(chunk), &event); err != nil {
+					t.Fatalf("unmarshal error = %v", err)
+				}
+				acc.Add(&event)
+			}
+
+			err := acc.Validate()
+			if tt.wantMissing == nil && tt.wantInvalid == nil {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+
+			toolErr, ok := err.(*StreamToolCallError)
+			if !ok {
+				t.Fatalf("Validate() error = %v, want *StreamToolCallError", err)
+			}
+			if !equalInts(toolErr.MissingIndices, tt.wantMissing) {
+				t.Errorf("MissingIndices = %v, want %v", toolErr.MissingIndices, tt.wantMissing)
+			}
+			if !equalInts(toolErr.InvalidIndices, tt.wantInvalid) {
+				t.Errorf("InvalidIndices = %v, want %v", toolErr.InvalidIndices, tt.wantInvalid)
+			}
+		})
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestChatAudioStreamAccumulator(t *testing.T) {
+	audio := []byte("RIFF\x00\x01\x02\x03audio-bytes")
+	encoded := base64.StdEncoding.EncodeToString(audio)
+
+	// Split the base64 across chunks at a non-quantum boundary
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"role":"assistant","audio":{"id":"audio_1","transcript":"Hel","data":"` + encoded[:5] + `"}}}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"note","audio":{"transcript":"lo","data":"` + encoded[5:] + `"}}}]}`,
+		`{"choices":[{"index":0,"delta":{"audio":{"expires_at":1700000000}},"finish_reason":"stop"}]}`,
+	}
+	events := make([]*ChatStreamEvent, len(chunks))
+	for i, chunk := range chunks {
+		var event ChatStreamEvent
+		if err := json.Unmarshal([]byte(chunk), &event); err != nil {
+			t.Fatalf("unmarshal error = %v", err)
+		}
+		events[i] = &event
+	}
+
+	t.Run("buffered", func(t *testing.T) {
+		acc := NewChatAudioStreamAccumulator(nil)
+		for _, event := range events {
+			if err := acc.Add(event); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+		}
+		if err := acc.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		if !bytes.Equal(acc.Audio(), audio) {
+			t.Errorf("Audio() = %q, want %q", acc.Audio(), audio)
+		}
+		if acc.Text() != "note" {
+			t.Errorf("Text() = %q, want note", acc.Text())
+		}
+		if acc.Transcript() != "Hello" {
+			t.Errorf("Transcript() = %q, want Hello", acc.Transcript())
+		}
+		if acc.AudioID() != "audio_1" || acc.AudioExpiresAt() != 1700000000 {
+			t.Errorf("AudioID(), AudioExpiresAt() = %q, %d, want audio_1, 1700000000", acc.AudioID(), acc.AudioExpiresAt())
+		}
+	})
+
+	t.Run("writer", func(t *testing.T) {
+		var w bytes.Buffer
+		acc := NewChatAudioStreamAccumulator(&w)
+		for _, event := range events {
+			if err := acc.Add(event); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+		}
+		if err := acc.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		if !bytes.Equal(w.Bytes(), audio) {
+			t.Errorf("written audio = %q, want %q", w.Bytes(), audio)
+		}
+		if acc.Audio() != nil {
+			t.Errorf("Audio() = %q, want nil when writing to a writer", acc.Audio())
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		acc := NewChatAudioStreamAccumulator(nil)
+		if err := acc.Add(events[0]); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		if err := acc.Close(); err == nil {
+			t.Error("Close() should fail on an incomplete base64 chunk")
+		}
+	})
+
+	t.Run("invalid base64", func(t *testing.T) {
+		acc := NewChatAudioStreamAccumulator(nil)
+		event := &ChatStreamEvent{Choices: []ChatStreamChoice{{
+			Delta: ChatStreamDelta{Audio: &ChatStreamAudioDelta{Data: "!!!!"}},
+		}}}
+		if err := acc.Add(event); err == nil {
+			t.Error("Add() should fail on invalid base64")
+		}
+		if err := acc.Close(); err == nil {
+			t.Error("Close() should report the Add error")
+		}
+	})
+}
+
+func TestUTF8DeltaBuffer(t *testing.T) {
+	// "héllo 世界" with é and 世 split across deltas
+	text := "héllo 世界"
+	deltas := []string{text[:2], text[2:8], text[8:]}
+
+	var buf UTF8DeltaBuffer
+	var got []string
+	for _, delta := range deltas {
+		got = append(got, buf.Write(delta))
+	}
+
+	want := []string{"h", "éllo ", "世界"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Write(%q) = %q, want %q", deltas[i], got[i], want[i])
+		}
+	}
+	if rest := buf.Flush(); rest != "" {
+		t.Errorf("Flush() = %q, want empty", rest)
+	}
+
+	// Incomplete trailing bytes are returned by Flush
+	buf.Write("ok\xe4\xb8")
+	if rest := buf.Flush(); rest != "\xe4\xb8" {
+		t.Errorf("Flush() = %q, want the partial character", rest)
+	}
+}
+
+func TestChatStreamAccumulator_OnToken(t *testing.T) {
+	text := "日本語"
+	stop := "stop"
+	deltas := []string{text[:1], text[1:4], text[4:7], text[7:]}
+
+	var tokens []string
+	acc := NewChatStreamAccumulator()
+	acc.OnToken = func(s string) {
+		if !utf8.ValidString(s) {
+			t.Errorf("OnToken(%q) received invalid UTF-8", s)
+		}
+		tokens = append(tokens, s)
+	}
+	for i, delta := range deltas {
+		event := &ChatStreamEvent{Choices: []ChatStreamChoice{{Delta: ChatStreamDelta{Content: delta}}}}
+		if i == len(deltas)-1 {
+			event.Choices[0].FinishReason = &stop
+		}
+		acc.Add(event)
+	}
+
+	if joined := strings.Join(tokens, ""); joined != text {
+		t.Errorf("OnToken text = %q, want %q", joined, text)
+	}
+	if acc.Content() != text {
+		t.Errorf("Content() = %q, want %q", acc.Content(), text)
+	}
+}
+
+func TestMessagesStreamAccumulator(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"anthropic/claude-3-5-sonnet","usage":{"input_tokens":20,"output_tokens":1,"cache_read_input_tokens":100}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me think."}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hello"}}`,
+		`{"type":"message_delta","delta":{},"usage":{"output_tokens":5,"cache_read_input_tokens":150}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":" there"}}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":9,"cache_read_input_tokens":200,"cache_creation_input_tokens":40}}`,
+		`{"type":"message_stop"}`,
+	}
+
+	acc := NewMessagesStreamAccumulator()
+	for _, raw := range events {
+		var event MessagesStreamEvent
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			t.Fatalf("unmarshal error = %v", err)
+		}
+		acc.Add(&event)
+	}
+
+	if acc.Text() != "Hello there" {
+		t.Errorf("Text() = %q, want %q", acc.Text(), "Hello there")
+	}
+	if acc.Thinking() != "Let me think." {
+		t.Errorf("Thinking() = %q, want %q", acc.Thinking(), "Let me think.")
+	}
+	if acc.StopReason() != "end_turn" {
+		t.Errorf("StopReason() = %q, want end_turn", acc.StopReason())
+	}
+
+	// Cumulative counters take the latest value rather than the sum
+	if got := acc.CacheReadInputTokens(); got != 200 {
+		t.Errorf("CacheReadInputTokens() = %d, want 200", got)
+	}
+	if got := acc.CacheCreationInputTokens(); got != 40 {
+		t.Errorf("CacheCreationInputTokens() = %d, want 40", got)
+	}
+	usage := acc.Usage()
+	if usage == nil {
+		t.Fatal("Usage() = nil")
+	}
+	if usage.InputTokens != 20 || usage.OutputTokens != 9 {
+		t.Errorf("Usage() = %+v, want input 20 and output 9", usage)
+	}
+}
+
+func TestMessagesStreamAccumulator_ToolInputs(t *testing.T) {
+	events := []string{
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Checking the weather."}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\": \"Par"}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"is\", \"days\""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":": 3}"}}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_2","name":"get_time","input":{}}}`,
+		`{"type":"content_block_stop","index":2}`,
+	}
+
+	acc := NewMessagesStreamAccumulator()
+	for _, raw := range events {
+		var event MessagesStreamEvent
+		if err := json.Unmarshal([]byte(raw), &event); err != nil {
+			t.Fatalf("unmarshal error = %v", err)
+		}
+		acc.Add(&event)
+	}
+
+	// Block 1 has not stopped yet
+	inputs := acc.ToolInputs()
+	if _, ok := inputs[1]; ok {
+		t.Errorf("ToolInputs()[1] = %s before content_block_stop", inputs[1])
+	}
+	if got := string(inputs[2]); got != "{}" {
+		t.Errorf("ToolInputs()[2] = %s, want {} for a tool without arguments", got)
+	}
+	if err := acc.Validate(); err == nil {
+		t.Error("Validate() = nil, want an error while block 1 is open")
+	}
+
+	acc.Add(&MessagesStreamEvent{Type: "content_block_stop", Index: 1})
+
+	inputs = acc.ToolInputs()
+	if len(inputs) != 2 {
+		t.Fatalf("ToolInputs() has %d entries, want 2", len(inputs))
+	}
+	var args struct {
+		City string `json:"city"`
+		Days int    `json:"days"`
+	}
+	if err := json.Unmarshal(inputs[1], &args); err != nil {
+		t.Fatalf("unmarshal ToolInputs()[1] = %s: %v", inputs[1], err)
+	}
+	if args.City != "Paris" || args.Days != 3 {
+		t.Errorf("ToolInputs()[1] = %+v, want Paris for 3 days", args)
+	}
+	if acc.Text() != "Checking the weather." {
+		t.Errorf("Text() = %q, want only the text block", acc.Text())
+	}
+	if err := acc.Validate(); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+}
+
+func TestMessagesStreamAccumulator_InvalidToolInput(t *testing.T) {
+	acc := NewMessagesStreamAccumulator()
+	acc.Add(&MessagesStreamEvent{Type: "content_block_start", Index: 0, ContentBlock: &AnthropicContentBlock{Type: "tool_use"}})
+	acc.Add(&MessagesStreamEvent{Type: "content_block_delta", Index: 0, Delta: &MessagesStreamDelta{Type: "input_json_delta", PartialJSON: `{"city": "Par`}})
+	acc.Add(&MessagesStreamEvent{Type: "content_block_stop", Index: 0})
+
+	if inputs := acc.ToolInputs(); len(inputs) != 0 {
+		t.Errorf("ToolInputs() = %v, want no entries for invalid input", inputs)
+	}
+	var toolErr *StreamToolCallError
+	if err := acc.Validate(); !errors.As(err, &toolErr) || len(toolErr.InvalidIndices) != 1 || toolErr.InvalidIndices[0] != 0 {
+		t.Errorf("Validate() = %v, want invalid index 0", err)
+	}
+}
+
+func TestMessagesStreamAccumulator_Empty(t *testing.T) {
+	acc := NewMessagesStreamAccumulator()
+	acc.Add(nil)
+
+	if acc.Usage() != nil {
+		t.Errorf("Usage() = %+v, want nil", acc.Usage())
+	}
+	if acc.CacheReadInputTokens() != 0 || acc.CacheCreationInputTokens() != 0 {
+		t.Error("cache token counts should be zero without usage")
+	}
+}
@@ -17,6 +17,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
 )
@@ -48,6 +52,8 @@ type AudioTranscriptionRequest struct {
 
 	// ResponseFormat specifies the output format.
 	// Values: "json", "text", "srt", "verbose_json", "vtt"
+	// For "text", "srt" and "vtt" the raw body is returned in the
+	// response's Text field.
 	// Optional (default: "json").
 	ResponseFormat string
 
@@ -64,6 +70,8 @@ type AudioTranscriptionRequest struct {
 // AudioTranscriptionResponse represents the response from transcription.
 type AudioTranscriptionResponse struct {
 	// Text is the transcribed text.
+	// For the "text", "srt" and "vtt" response formats it holds the raw
+	// response body, e.g. the full SRT subtitle document.
 	Text string `json:"text"`
 
 	// Language is the detected language (if not specified).
@@ -147,6 +155,8 @@ type AudioTranslationRequest struct {
 
 	// ResponseFormat specifies the output format.
 	// Values: "json", "text", "srt", "verbose_json", "vtt"
+	// For "text", "srt" and "vtt" the raw body is returned in the
+	// response's Text field.
 	// Optional (default: "json").
 	ResponseFormat string
 
@@ -158,6 +168,8 @@ type AudioTranslationRequest struct {
 // AudioTranslationResponse represents the response from translation.
 type AudioTranslationResponse struct {
 	// Text is the translated text (in English).
+	// For the "text", "srt" and "vtt" response formats it holds the raw
+	// response body.
 	Text string `json:"text"`
 
 	// Duration is the audio duration in seconds.
@@ -216,47 +228,28 @@ func (c *Client) CreateTranscription(ctx context.Context, req AudioTranscription
 	c.log(ctx, LogLevelDebug, "creating audio transcription", "model", req.Model)
 
 	// Create multipart form
-	body, contentType, err := createAudioMultipartForm(req.File, req.FileName, map[string]string{
-		"model":           req.Model,
-		"language":        req.Language,
-		"prompt":          req.Prompt,
-		"response_format": req.ResponseFormat,
-		"temperature":     floatPtrToString(req.Temperature),
-	})
+	body, contentType, err := transcriptionForm(&req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/audio/transcriptions",
-		Body:   body,
+		Operation: "zaguan.CreateTranscription",
+		Method:    "POST",
+		Path:      "/v1/audio/transcriptions",
+		Body:      body,
 		Headers: http.Header{
 			"Content-Type": []string{contentType},
 		},
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			for k, v := range opts.Headers {
-				reqCfg.Headers[k] = v
-			}
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp AudioTranscriptionResponse
-	if err := c.internalHTTP.DoJSON(ctx, reqCfg, &resp); err != nil {
+	if err := c.doAudio(ctx, reqCfg, req.ResponseFormat, &resp, &resp.Text); err != nil {
 		c.log(ctx, LogLevelError, "create transcription request failed", "error", err)
 		return nil, err
 	}
@@ -290,46 +283,28 @@ func (c *Client) CreateTranslation(ctx context.Context, req AudioTranslationRequ
 	c.log(ctx, LogLevelDebug, "creating audio translation", "model", req.Model)
 
 	// Create multipart form
-	body, contentType, err := createAudioMultipartForm(req.File, req.FileName, map[string]string{
-		"model":           req.Model,
-		"prompt":          req.Prompt,
-		"response_format": req.ResponseFormat,
-		"temperature":     floatPtrToString(req.Temperature),
-	})
+	body, contentType, err := translationForm(&req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/audio/translations",
-		Body:   body,
+		Operation: "zaguan.CreateTranslation",
+		Method:    "POST",
+		Path:      "/v1/audio/translations",
+		Body:      body,
 		Headers: http.Header{
 			"Content-Type": []string{contentType},
 		},
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			for k, v := range opts.Headers {
-				reqCfg.Headers[k] = v
-			}
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp AudioTranslationResponse
-	if err := c.internalHTTP.DoJSON(ctx, reqCfg, &resp); err != nil {
+	if err := c.doAudio(ctx, reqCfg, req.ResponseFormat, &resp, &resp.Text); err != nil {
 		c.log(ctx, LogLevelError, "create translation request failed", "error", err)
 		return nil, err
 	}
@@ -339,10 +314,151 @@ func (c *Client) CreateTranslation(ctx context.Context, req AudioTranslationRequ
 	return &resp, nil
 }
 
+// isTextAudioFormat reports whether an audio response format returns a
+// plain-text body rather than JSON.
+func isTextAudioFormat(format string) bool {
+	switch format {
+	case "text", "srt", "vtt":
+		return true
+	}
+	return false
+}
+
+// transcriptionForm creates the multipart form for a transcription request.
+func transcriptionForm(req *AudioTranscriptionRequest) (io.Reader, string, error) {
+	return createAudioMultipartForm(req.File, req.FileName, map[string]string{
+		"model":           req.Model,
+		"language":        req.Language,
+		"prompt":          req.Prompt,
+		"response_format": req.ResponseFormat,
+		"temperature":     floatPtrToString(req.Temperature),
+	})
+}
+
+// translationForm creates the multipart form for a translation request.
+func translationForm(req *AudioTranslationRequest) (io.Reader, string, error) {
+	return createAudioMultipartForm(req.File, req.FileName, map[string]string{
+		"model":           req.Model,
+		"prompt":          req.Prompt,
+		"response_format": req.ResponseFormat,
+		"temperature":     floatPtrToString(req.Temperature),
+	})
+}
+
+// PreparedRequest is a fully built request that has not been sent, for
+// inspecting exactly what the SDK would send.
+type PreparedRequest struct {
+	// Method is the HTTP method.
+	Method string
+
+	// URL is the full request URL.
+	URL string
+
+	// ContentType is the Content-Type header, including the multipart
+	// boundary for multipart requests.
+	ContentType string
+
+	// Body is the complete request body.
+	Body []byte
+}
+
+// BodyReader returns a new reader over the request body. It can be called
+// any number of times.
+func (p *PreparedRequest) BodyReader() io.Reader {
+	return bytes.NewReader(p.Body)
+}
+
+// BuildTranscriptionRequest builds the multipart request CreateTranscription
+// would send for req, without sending it.
+//
+// The whole body, including the audio file, is read into memory. If
+// req.File is an io.Reader it is consumed.
+//
+// Example:
+//
+//	prepared, err := client.BuildTranscriptionRequest(req)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(prepared.ContentType)
+//	os.WriteFile("request.bin", prepared.Body, 0o644)
+func (c *Client) BuildTranscriptionRequest(req AudioTranscriptionRequest) (*PreparedRequest, error) {
+	if err := validateAudioTranscriptionRequest(&req); err != nil {
+		return nil, err
+	}
+	body, contentType, err := transcriptionForm(&req)
+	if err != nil {
+		return nil, err
+	}
+	return c.prepareRequest("/v1/audio/transcriptions", body, contentType)
+}
+
+// BuildTranslationRequest builds the multipart request CreateTranslation
+// would send for req, without sending it.
+//
+// The whole body, including the audio file, is read into memory. If
+// req.File is an io.Reader it is consumed.
+func (c *Client) BuildTranslationRequest(req AudioTranslationRequest) (*PreparedRequest, error) {
+	if err := validateAudioTranslationRequest(&req); err != nil {
+		return nil, err
+	}
+	body, contentType, err := translationForm(&req)
+	if err != nil {
+		return nil, err
+	}
+	return c.prepareRequest("/v1/audio/translations", body, contentType)
+}
+
+// prepareRequest reads body into a PreparedRequest for a POST to path.
+func (c *Client) prepareRequest(path string, body io.Reader, contentType string) (*PreparedRequest, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request body: %w", err)
+	}
+	return &PreparedRequest{
+		Method:      "POST",
+		URL:         c.baseURL + path,
+		ContentType: contentType,
+		Body:        data,
+	}, nil
+}
+
+// doAudio executes a transcription or translation request. Plain-text
+// response formats are read verbatim into text; all others are decoded as
+// JSON into result.
+func (c *Client) doAudio(ctx context.Context, reqCfg internal.RequestConfig, responseFormat string, result interface{}, text *string) error {
+	if !isTextAudioFormat(responseFormat) {
+		return c.internalHTTP.DoJSON(ctx, reqCfg, result)
+	}
+
+	resp, err := c.internalHTTP.Do(ctx, reqCfg)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Check for error status codes
+	if resp.StatusCode >= 400 {
+		return c.internalHTTP.ParseErrorResponse(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	*text = string(data)
+
+	return nil
+}
+
 // CreateSpeech generates audio from text using text-to-speech.
 //
 // Returns an io.ReadCloser containing the audio data. The caller is
-// responsible for closing the reader.
+// responsible for closing the reader. Use CreateSpeechStream to also learn
+// the audio content type.
+//
+// Input longer than MaxSpeechInputChars fails with a *ValidationError unless
+// Config.SplitLongSpeech is set.
 //
 // Example:
 //
@@ -361,6 +477,47 @@ func (c *Client) CreateTranslation(ctx context.Context, req AudioTranslationRequ
 //	defer out.Close()
 //	io.Copy(out, audio)
 func (c *Client) CreateSpeech(ctx context.Context, req AudioSpeechRequest, opts *RequestOptions) (io.ReadCloser, error) {
+	stream, err := c.createSpeech(ctx, req, opts, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// CreateSpeechStream generates audio from text and returns it as a stream
+// that can be consumed as the audio arrives.
+//
+// The stream's ContentType reports the audio format returned by the server,
+// e.g. "audio/mpeg" or "audio/opus". The caller must close the stream.
+//
+// Like ChatStream, it uses Config.StreamTimeout (not Config.Timeout) as the
+// default timeout, which bounds the total duration of the stream.
+//
+// Example:
+//
+//	stream, err := client.CreateSpeechStream(ctx, zaguansdk.AudioSpeechRequest{
+//		Model:          "openai/tts-1",
+//		Input:          "Hello, world!",
+//		Voice:          "alloy",
+//		ResponseFormat: "opus",
+//	}, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer stream.Close()
+//
+//	fmt.Println("format:", stream.ContentType())
+//	io.Copy(player, stream)
+func (c *Client) CreateSpeechStream(ctx context.Context, req AudioSpeechRequest, opts *RequestOptions) (*SpeechStream, error) {
+	return c.createSpeech(ctx, req, opts, c.streamTimeout)
+}
+
+// createSpeech sends a speech request, applying defaultTimeout unless opts sets a Timeout.
+func (c *Client) createSpeech(ctx context.Context, req AudioSpeechRequest, opts *RequestOptions, defaultTimeout time.Duration) (*SpeechStream, error) {
+	if c.splitLongSpeech && utf8.RuneCountInString(req.Input) > MaxSpeechInputChars {
+		return c.createSplitSpeech(ctx, req, opts, defaultTimeout)
+	}
+
 	// Validate request
 	if err := validateAudioSpeechRequest(&req); err != nil {
 		return nil, err
@@ -370,25 +527,14 @@ func (c *Client) CreateSpeech(ctx context.Context, req AudioSpeechRequest, opts
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/audio/speech",
-		Body:   req,
+		Operation: "zaguan.CreateSpeech",
+		Method:    "POST",
+		Path:      "/v1/audio/speech",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, defaultTimeout)
 
 	// Execute request
 	resp, err := c.internalHTTP.Do(ctx, reqCfg)
@@ -400,21 +546,235 @@ func (c *Client) CreateSpeech(ctx context.Context, req AudioSpeechRequest, opts
 	// Check for error status codes
 	if resp.StatusCode >= 400 {
 		defer resp.Body.Close()
-		return nil, internal.ParseErrorResponse(resp)
+		return nil, c.internalHTTP.ParseErrorResponse(resp)
 	}
 
-	c.log(ctx, LogLevelDebug, "create speech request succeeded")
+	c.log(ctx, LogLevelDebug, "create speech request succeeded",
+		"content_type", resp.Header.Get("Content-Type"))
+
+	return &SpeechStream{
+		body:        resp.Body,
+		contentType: resp.Header.Get("Content-Type"),
+	}, nil
+}
+
+// MaxSpeechInputChars is the maximum number of characters of speech input
+// accepted in a single request.
+const MaxSpeechInputChars = 4096
 
-	return resp.Body, nil
+// createSplitSpeech synthesizes over-limit input as several requests, one per
+// chunk from SplitSpeechInput, and concatenates the audio. Each chunk is only
+// requested once the previous one has been read.
+func (c *Client) createSplitSpeech(ctx context.Context, req AudioSpeechRequest, opts *RequestOptions, defaultTimeout time.Duration) (*SpeechStream, error) {
+	switch req.ResponseFormat {
+	case "", "mp3", "opus", "aac", "pcm":
+	default:
+		return nil, &ValidationError{
+			Field:   "response_format",
+			Message: fmt.Sprintf("%s audio cannot be concatenated; split input requires mp3, opus, aac, or pcm", req.ResponseFormat),
+		}
+	}
+
+	chunks := SplitSpeechInput(req.Input, MaxSpeechInputChars)
+	c.log(ctx, LogLevelDebug, "splitting speech input", "chunks", len(chunks))
+
+	chunkReq := req
+	chunkReq.Input = chunks[0]
+	first, err := c.createSpeech(ctx, chunkReq, opts, defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	body := &chainedSpeechBody{
+		current: first.body,
+		next: func(input string) (io.ReadCloser, error) {
+			chunkReq := req
+			chunkReq.Input = input
+			stream, err := c.createSpeech(ctx, chunkReq, opts, defaultTimeout)
+			if err != nil {
+				return nil, err
+			}
+			return stream.body, nil
+		},
+		remaining: chunks[1:],
+	}
+	return &SpeechStream{body: body, contentType: first.contentType}, nil
+}
+
+// chainedSpeechBody reads the audio of several speech requests in sequence,
+// opening the next one when the current one is exhausted.
+type chainedSpeechBody struct {
+	current   io.ReadCloser
+	next      func(input string) (io.ReadCloser, error)
+	remaining []string
+}
+
+// Read reads audio from the current chunk, moving on to the next chunk at EOF.
+func (b *chainedSpeechBody) Read(p []byte) (int, error) {
+	for {
+		if b.current == nil {
+			return 0, io.EOF
+		}
+
+		n, err := b.current.Read(p)
+		if err != io.EOF {
+			return n, err
+		}
+
+		b.current.Close()
+		b.current = nil
+		if len(b.remaining) > 0 {
+			input := b.remaining[0]
+			b.remaining = b.remaining[1:]
+			body, nextErr := b.next(input)
+			if nextErr != nil {
+				b.remaining = nil
+				return n, nextErr
+			}
+			b.current = body
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+// Close closes the current chunk and skips any remaining chunks.
+func (b *chainedSpeechBody) Close() error {
+	b.remaining = nil
+	if b.current == nil {
+		return nil
+	}
+	err := b.current.Close()
+	b.current = nil
+	return err
+}
+
+// SplitSpeechInput splits text into chunks of at most maxChars characters,
+// breaking on sentence boundaries where possible.
+//
+// Sentences are packed greedily into chunks. A sentence longer than maxChars
+// is broken at whitespace, or mid-word if it has none. Text that already
+// fits is returned as a single chunk.
+//
+// Example:
+//
+//	for _, chunk := range zaguansdk.SplitSpeechInput(article, zaguansdk.MaxSpeechInputChars) {
+//		// synthesize chunk
+//	}
+func SplitSpeechInput(text string, maxChars int) []string {
+	if maxChars <= 0 || utf8.RuneCountInString(text) <= maxChars {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+		currentLen = 0
+	}
+
+	for _, sentence := range splitSentences(text) {
+		// Trailing whitespace is trimmed from chunks, so it does not count
+		sentenceLen := utf8.RuneCountInString(sentence)
+		trimmedLen := utf8.RuneCountInString(strings.TrimRightFunc(sentence, unicode.IsSpace))
+		if currentLen+trimmedLen > maxChars {
+			flush()
+		}
+		for sentenceLen > maxChars {
+			head, tail := splitAtWhitespace(sentence, maxChars)
+			if head = strings.TrimSpace(head); head != "" {
+				chunks = append(chunks, head)
+			}
+			sentence = tail
+			sentenceLen = utf8.RuneCountInString(sentence)
+		}
+		current.WriteString(sentence)
+		currentLen += sentenceLen
+	}
+	flush()
+
+	return chunks
+}
+
+// splitSentences splits text after sentence-ending punctuation and newlines,
+// keeping the trailing whitespace with each sentence.
+func splitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	ended := false
+	for i, r := range text {
+		if ended && !unicode.IsSpace(r) {
+			sentences = append(sentences, text[start:i])
+			start = i
+			ended = false
+		}
+		switch r {
+		case '.', '!', '?', '\n':
+			ended = true
+		}
+	}
+	if start < len(text) {
+		sentences = append(sentences, text[start:])
+	}
+	return sentences
+}
+
+// splitAtWhitespace splits s into a head of at most maxChars characters and
+// the remaining tail, breaking after the last whitespace in the second half
+// of the head if there is one.
+func splitAtWhitespace(s string, maxChars int) (string, string) {
+	runes := []rune(s)
+	cut := maxChars
+	for i := maxChars; i > maxChars/2; i-- {
+		if unicode.IsSpace(runes[i-1]) {
+			cut = i
+			break
+		}
+	}
+	return string(runes[:cut]), string(runes[cut:])
+}
+
+// SpeechStream is a stream of synthesized audio.
+//
+// It implements io.ReadCloser, so it can be piped directly to a player or
+// file as the audio arrives.
+type SpeechStream struct {
+	body        io.ReadCloser
+	contentType string
+}
+
+// Read reads the next chunk of audio data.
+func (s *SpeechStream) Read(p []byte) (int, error) {
+	return s.body.Read(p)
+}
+
+// ContentType returns the Content-Type of the audio returned by the server,
+// e.g. "audio/mpeg" for mp3 or "audio/opus" for opus.
+func (s *SpeechStream) ContentType() string {
+	return s.contentType
+}
+
+// Close closes the stream and releases associated resources.
+func (s *SpeechStream) Close() error {
+	return s.body.Close()
 }
 
 // createAudioMultipartForm creates a multipart form for audio requests.
+//
+// The form is streamed through a pipe rather than buffered, so large audio
+// files are never held in memory. The returned reader must be consumed or
+// closed; file paths are opened here and closed once streaming finishes.
+// Errors while writing the form are returned from the reader's Read.
 func createAudioMultipartForm(file interface{}, fileName string, fields map[string]string) (io.Reader, string, error) {
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
-
-	// Add file
+	// Resolve the file source up front so open and validation errors are
+	// returned before the request starts
 	var fileReader io.Reader
+	var fileCloser io.Closer
 	var fileNameToUse string
 
 	switch v := file.(type) {
@@ -424,8 +784,8 @@ func createAudioMultipartForm(file interface{}, fileName string, fields map[stri
 		if err != nil {
 			return nil, "", fmt.Errorf("failed to open file: %w", err)
 		}
-		defer f.Close()
 		fileReader = f
+		fileCloser = f
 		fileNameToUse = filepath.Base(v)
 	case io.Reader:
 		// Reader
@@ -444,32 +804,49 @@ func createAudioMultipartForm(file interface{}, fileName string, fields map[stri
 		}
 	}
 
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		err := writeAudioMultipartForm(writer, fileReader, fileNameToUse, fields)
+		if fileCloser != nil {
+			fileCloser.Close()
+		}
+		// A nil error closes the pipe normally, signalling EOF to the reader
+		pw.CloseWithError(err)
+	}()
+
+	return pr, writer.FormDataContentType(), nil
+}
+
+// writeAudioMultipartForm writes the file and fields of an audio form.
+func writeAudioMultipartForm(writer *multipart.Writer, fileReader io.Reader, fileName string, fields map[string]string) error {
 	// Create form file
-	part, err := writer.CreateFormFile("file", fileNameToUse)
+	part, err := writer.CreateFormFile("file", fileName)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to create form file: %w", err)
+		return fmt.Errorf("failed to create form file: %w", err)
 	}
 
 	// Copy file data
 	if _, err := io.Copy(part, fileReader); err != nil {
-		return nil, "", fmt.Errorf("failed to copy file data: %w", err)
+		return fmt.Errorf("failed to copy file data: %w", err)
 	}
 
 	// Add other fields
 	for key, value := range fields {
 		if value != "" {
 			if err := writer.WriteField(key, value); err != nil {
-				return nil, "", fmt.Errorf("failed to write field %s: %w", key, err)
+				return fmt.Errorf("failed to write field %s: %w", key, err)
 			}
 		}
 	}
 
 	// Close writer
 	if err := writer.Close(); err != nil {
-		return nil, "", fmt.Errorf("failed to close multipart writer: %w", err)
+		return fmt.Errorf("failed to close multipart writer: %w", err)
 	}
 
-	return &buf, writer.FormDataContentType(), nil
+	return nil
 }
 
 // floatPtrToString converts a float pointer to string, or returns empty string if nil.
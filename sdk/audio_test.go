@@ -1,6 +1,18 @@
 package zaguansdk
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -184,6 +196,24 @@ func TestValidateAudioSpeechRequest(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "input over the character limit",
+			req: AudioSpeechRequest{
+				Model: "openai/tts-1",
+				Input: strings.Repeat("a", MaxSpeechInputChars+1),
+				Voice: "alloy",
+			},
+			wantErr: true,
+		},
+		{
+			name: "input at the character limit",
+			req: AudioSpeechRequest{
+				Model: "openai/tts-1",
+				Input: strings.Repeat("é", MaxSpeechInputChars),
+				Voice: "alloy",
+			},
+			wantErr: false,
+		},
 		{
 			name: "speed too low",
 			req: AudioSpeechRequest{
@@ -216,6 +246,381 @@ func TestValidateAudioSpeechRequest(t *testing.T) {
 	}
 }
 
+func TestClient_CreateSpeechStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/speech" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("X-Fail") != "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"unsupported voice","type":"invalid_request_error"}}`))
+			return
+		}
+
+		w.Header().Set("Content-Type", "audio/opus")
+		w.WriteHeader(http.StatusOK)
+		flusher := w.(http.Flusher)
+		for _, chunk := range []string{"chunk-1", "chunk-2", "chunk-3"} {
+			w.Write([]byte(chunk))
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	req := AudioSpeechRequest{
+		Model:          "openai/tts-1",
+		Input:          "Hello, world!",
+		Voice:          "alloy",
+		ResponseFormat: "opus",
+	}
+
+	stream, err := client.CreateSpeechStream(context.Background(), req, nil)
+	if err != nil {
+		t.Fatalf("CreateSpeechStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if stream.ContentType() != "audio/opus" {
+		t.Errorf("ContentType() = %q, want audio/opus", stream.ContentType())
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(data) != "chunk-1chunk-2chunk-3" {
+		t.Errorf("audio data = %q, want %q", data, "chunk-1chunk-2chunk-3")
+	}
+
+	// CreateSpeech returns the same audio as a plain reader
+	audio, err := client.CreateSpeech(context.Background(), req, nil)
+	if err != nil {
+		t.Fatalf("CreateSpeech() error = %v", err)
+	}
+	defer audio.Close()
+	if data, _ := io.ReadAll(audio); string(data) != "chunk-1chunk-2chunk-3" {
+		t.Errorf("CreateSpeech() data = %q", data)
+	}
+
+	// Errors are returned before any audio is read
+	audio, err = client.CreateSpeech(context.Background(), req, WithHeaders(http.Header{"X-Fail": []string{"1"}}))
+	if err == nil {
+		t.Fatal("CreateSpeech() should fail for an error response")
+	}
+	if audio != nil {
+		t.Error("CreateSpeech() should return a nil reader on error")
+	}
+}
+
+func TestSplitSpeechInput(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		maxChars int
+		want     []string
+	}{
+		{
+			name:     "fits",
+			text:     "Hello there. How are you?",
+			maxChars: 100,
+			want:     []string{"Hello there. How are you?"},
+		},
+		{
+			name:     "sentence boundaries",
+			text:     "One two. Three four! Five six?",
+			maxChars: 20,
+			want:     []string{"One two. Three four!", "Five six?"},
+		},
+		{
+			name:     "long sentence breaks at whitespace",
+			text:     "alpha beta gamma delta",
+			maxChars: 12,
+			want:     []string{"alpha beta", "gamma delta"},
+		},
+		{
+			name:     "no whitespace",
+			text:     "abcdefghij",
+			maxChars: 4,
+			want:     []string{"abcd", "efgh", "ij"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitSpeechInput(tt.text, tt.maxChars)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("SplitSpeechInput() = %q, want %q", got, tt.want)
+			}
+			for _, chunk := range got {
+				if n := len([]rune(chunk)); n > tt.maxChars {
+					t.Errorf("chunk %q has %d characters, want at most %d", chunk, n, tt.maxChars)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_CreateSpeech_SplitLongSpeech(t *testing.T) {
+	var inputs []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req AudioSpeechRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		inputs = append(inputs, req.Input)
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.Write([]byte(fmt.Sprintf("[audio-%d]", len(inputs))))
+	}))
+	defer server.Close()
+
+	sentence := strings.Repeat("word ", 199) + "end. "
+	req := AudioSpeechRequest{
+		Model: "openai/tts-1",
+		Input: strings.Repeat(sentence, 5),
+		Voice: "alloy",
+	}
+
+	// Without splitting, over-limit input fails locally
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"})
+	var valErr *ValidationError
+	if _, err := client.CreateSpeech(context.Background(), req, nil); !errors.As(err, &valErr) || valErr.Field != "input" {
+		t.Fatalf("CreateSpeech() error = %v, want input ValidationError", err)
+	}
+	if len(inputs) != 0 {
+		t.Fatalf("server received %d requests, want 0", len(inputs))
+	}
+
+	client = NewClient(Config{BaseURL: server.URL, APIKey: "test-key", SplitLongSpeech: true})
+	audio, err := client.CreateSpeech(context.Background(), req, nil)
+	if err != nil {
+		t.Fatalf("CreateSpeech() error = %v", err)
+	}
+	defer audio.Close()
+
+	// Only the first chunk is requested up front
+	if len(inputs) != 1 {
+		t.Errorf("server received %d requests before reading, want 1", len(inputs))
+	}
+
+	data, err := io.ReadAll(audio)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if string(data) != "[audio-1][audio-2]" {
+		t.Errorf("audio data = %q, want [audio-1][audio-2]", data)
+	}
+	if len(inputs) != 2 {
+		t.Fatalf("server received %d requests, want 2", len(inputs))
+	}
+	if strings.Join(inputs, " ") != strings.TrimSpace(req.Input) {
+		t.Error("chunks do not reassemble into the input")
+	}
+
+	// Formats that cannot be concatenated are rejected
+	req.ResponseFormat = "wav"
+	if _, err := client.CreateSpeech(context.Background(), req, nil); !errors.As(err, &valErr) || valErr.Field != "response_format" {
+		t.Errorf("CreateSpeech() error = %v, want response_format ValidationError", err)
+	}
+}
+
+func TestClient_CreateTranscription_StreamsMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm() error = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if got := r.FormValue("model"); got != "openai/whisper-1" {
+			t.Errorf("model field = %q, want openai/whisper-1", got)
+		}
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("FormFile() error = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"text":"` + header.Filename + `:` + string(data) + `"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	// File path source
+	path := filepath.Join(t.TempDir(), "clip.mp3")
+	if err := os.WriteFile(path, []byte("audio-bytes"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	resp, err := client.CreateTranscription(context.Background(), AudioTranscriptionRequest{
+		File:  path,
+		Model: "openai/whisper-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateTranscription() error = %v", err)
+	}
+	if resp.Text != "clip.mp3:audio-bytes" {
+		t.Errorf("Text = %q, want clip.mp3:audio-bytes", resp.Text)
+	}
+
+	// Reader source
+	resp, err = client.CreateTranscription(context.Background(), AudioTranscriptionRequest{
+		File:     strings.NewReader("reader-bytes"),
+		FileName: "speech.wav",
+		Model:    "openai/whisper-1",
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateTranscription() error = %v", err)
+	}
+	if resp.Text != "speech.wav:reader-bytes" {
+		t.Errorf("Text = %q, want speech.wav:reader-bytes", resp.Text)
+	}
+}
+
+func TestClient_CreateTranscription_ResponseFormats(t *testing.T) {
+	const srt = "1\n00:00:00,000 --> 00:00:01,500\nHello, world!\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("ParseMultipartForm() error = %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.FormValue("response_format") {
+		case "text":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte("Hello, world!\n"))
+		case "srt":
+			w.Header().Set("Content-Type", "application/x-subrip")
+			w.Write([]byte(srt))
+		case "vtt":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"vtt unavailable","type":"invalid_request_error"}}`))
+		case "verbose_json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"text":"Hello, world!","language":"english","duration":1.5,` +
+				`"segments":[{"id":0,"start":0,"end":1.5,"text":"Hello, world!"}]}`))
+		default:
+			t.Errorf("unexpected response_format %q", r.FormValue("response_format"))
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	transcribe := func(format string) (*AudioTranscriptionResponse, error) {
+		return client.CreateTranscription(context.Background(), AudioTranscriptionRequest{
+			File:           strings.NewReader("audio-bytes"),
+			FileName:       "clip.mp3",
+			Model:          "openai/whisper-1",
+			ResponseFormat: format,
+		}, nil)
+	}
+
+	resp, err := transcribe("text")
+	if err != nil {
+		t.Fatalf("CreateTranscription(text) error = %v", err)
+	}
+	if resp.Text != "Hello, world!\n" {
+		t.Errorf("text Text = %q", resp.Text)
+	}
+
+	resp, err = transcribe("srt")
+	if err != nil {
+		t.Fatalf("CreateTranscription(srt) error = %v", err)
+	}
+	if resp.Text != srt {
+		t.Errorf("srt Text = %q, want %q", resp.Text, srt)
+	}
+
+	resp, err = transcribe("verbose_json")
+	if err != nil {
+		t.Fatalf("CreateTranscription(verbose_json) error = %v", err)
+	}
+	if resp.Text != "Hello, world!" || resp.Language != "english" || resp.Duration != 1.5 {
+		t.Errorf("verbose_json response = %+v", resp)
+	}
+	if len(resp.Segments) != 1 || resp.Segments[0].End != 1.5 {
+		t.Errorf("verbose_json Segments = %+v", resp.Segments)
+	}
+
+	// Error responses are still parsed for plain-text formats
+	_, err = transcribe("vtt")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Message != "vtt unavailable" {
+		t.Errorf("CreateTranscription(vtt) error = %v, want the API error", err)
+	}
+}
+
+func TestIsTextAudioFormat(t *testing.T) {
+	for format, want := range map[string]bool{
+		"":             false,
+		"json":         false,
+		"verbose_json": false,
+		"text":         true,
+		"srt":          true,
+		"vtt":          true,
+	} {
+		if got := isTextAudioFormat(format); got != want {
+			t.Errorf("isTextAudioFormat(%q) = %v, want %v", format, got, want)
+		}
+	}
+}
+
+// failingReader returns some data and then an error.
+type failingReader struct {
+	sent bool
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if !r.sent {
+		r.sent = true
+		return copy(p, "partial"), nil
+	}
+	return 0, errors.New("disk read failed")
+}
+
+func TestCreateAudioMultipartForm_ReaderError(t *testing.T) {
+	body, contentType, err := createAudioMultipartForm(&failingReader{}, "clip.mp3", map[string]string{"model": "m"})
+	if err != nil {
+		t.Fatalf("createAudioMultipartForm() error = %v", err)
+	}
+	if !strings.HasPrefix(contentType, "multipart/form-data; boundary=") {
+		t.Errorf("content type = %q", contentType)
+	}
+
+	_, err = io.ReadAll(body)
+	if err == nil || !strings.Contains(err.Error(), "disk read failed") {
+		t.Errorf("reading form error = %v, want the file read error", err)
+	}
+}
+
+func TestCreateAudioMultipartForm_MissingFile(t *testing.T) {
+	_, _, err := createAudioMultipartForm(filepath.Join(t.TempDir(), "missing.mp3"), "", nil)
+	if err == nil {
+		t.Error("createAudioMultipartForm() should fail for a missing file")
+	}
+}
+
 func TestFloatPtrToString(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -258,3 +663,54 @@ func TestFloatPtrToString(t *testing.T) {
 func floatPtr(f float64) *float64 {
 	return &f
 }
+
+func TestClient_BuildTranscriptionRequest(t *testing.T) {
+	client := NewClient(Config{
+		BaseURL: "https://api.example.com",
+		APIKey:  "test-key",
+	})
+
+	prepared, err := client.BuildTranscriptionRequest(AudioTranscriptionRequest{
+		File:     strings.NewReader("audio-bytes"),
+		FileName: "clip.mp3",
+		Model:    "openai/whisper-1",
+		Language: "en",
+	})
+	if err != nil {
+		t.Fatalf("BuildTranscriptionRequest() error = %v", err)
+	}
+	if prepared.Method != "POST" || prepared.URL != "https://api.example.com/v1/audio/transcriptions" {
+		t.Errorf("request = %s %s, want POST to the transcriptions endpoint", prepared.Method, prepared.URL)
+	}
+
+	_, params, err := mime.ParseMediaType(prepared.ContentType)
+	if err != nil {
+		t.Fatalf("ParseMediaType() error = %v", err)
+	}
+
+	// The body can be read more than once
+	for i := 0; i < 2; i++ {
+		form, err := multipart.NewReader(prepared.BodyReader(), params["boundary"]).ReadForm(1 << 20)
+		if err != nil {
+			t.Fatalf("ReadForm() error = %v", err)
+		}
+		if got := form.Value["language"]; len(got) != 1 || got[0] != "en" {
+			t.Errorf("language field = %v, want [en]", got)
+		}
+		files := form.File["file"]
+		if len(files) != 1 || files[0].Filename != "clip.mp3" {
+			t.Fatalf("file parts = %v, want clip.mp3", files)
+		}
+		f, _ := files[0].Open()
+		data, _ := io.ReadAll(f)
+		f.Close()
+		if string(data) != "audio-bytes" {
+			t.Errorf("file data = %q, want audio-bytes", data)
+		}
+	}
+
+	// Validation errors are returned before building
+	if _, err := client.BuildTranslationRequest(AudioTranslationRequest{Model: "openai/whisper-1"}); err == nil {
+		t.Error("BuildTranslationRequest() should fail without a file")
+	}
+}
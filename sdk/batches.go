@@ -12,8 +12,15 @@
 package zaguansdk
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
 )
@@ -42,6 +49,98 @@ type BatchRequest struct {
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// BatchInputItem is a single request line of a batch input file.
+//
+// Input files are JSONL with one BatchInputItem per line. Every item's URL
+// must match the batch's Endpoint; use ValidateBatchInput to check this
+// before uploading the file.
+type BatchInputItem struct {
+	// CustomID identifies the request in the batch output. Must be unique
+	// within the file.
+	CustomID string `json:"custom_id"`
+
+	// Method is the HTTP method. Always "POST".
+	Method string `json:"method"`
+
+	// URL is the endpoint of the request, e.g. "/v1/chat/completions".
+	URL string `json:"url"`
+
+	// Body is the request body, e.g. a ChatRequest or EmbeddingsRequest.
+	Body interface{} `json:"body"`
+}
+
+// ValidateBatchInput checks that batch input items are consistent with the
+// batch endpoint, returning a *ValidationError for the first problem found.
+//
+// Every item must have a unique custom_id, use POST, and have a URL equal to
+// endpoint. Typed bodies must match the endpoint: ChatRequest for
+// /v1/chat/completions, EmbeddingsRequest for /v1/embeddings and
+// CompletionRequest for /v1/completions. A mismatch would otherwise only fail
+// once the batch is processed.
+//
+// Example:
+//
+//	if err := zaguansdk.ValidateBatchInput("/v1/embeddings", items); err != nil {
+//		log.Fatal(err)
+//	}
+func ValidateBatchInput(endpoint string, items []BatchInputItem) error {
+	if endpoint == "" {
+		return &ValidationError{Field: "endpoint", Message: "endpoint is required"}
+	}
+	if len(items) == 0 {
+		return &ValidationError{Field: "items", Message: "at least one item is required"}
+	}
+
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		field := fmt.Sprintf("items[%d]", i)
+		if item.CustomID == "" {
+			return &ValidationError{Field: field + ".custom_id", Message: "custom_id is required"}
+		}
+		if seen[item.CustomID] {
+			return &ValidationError{
+				Field:   field + ".custom_id",
+				Message: fmt.Sprintf("custom_id %q is not unique", item.CustomID),
+			}
+		}
+		seen[item.CustomID] = true
+
+		if item.Method != "POST" {
+			return &ValidationError{
+				Field:   field + ".method",
+				Message: fmt.Sprintf("method must be POST, got %q", item.Method),
+			}
+		}
+		if item.URL != endpoint {
+			return &ValidationError{
+				Field:   field + ".url",
+				Message: fmt.Sprintf("url %q does not match batch endpoint %q", item.URL, endpoint),
+			}
+		}
+		if want := batchBodyType(item.Body); want != "" && want != endpoint {
+			return &ValidationError{
+				Field:   field + ".body",
+				Message: fmt.Sprintf("body is a request for %s, not %s", want, endpoint),
+			}
+		}
+	}
+	return nil
+}
+
+// batchBodyType returns the endpoint a typed request body belongs to, or ""
+// for untyped bodies such as maps or raw JSON.
+func batchBodyType(body interface{}) string {
+	switch body.(type) {
+	case ChatRequest, *ChatRequest:
+		return "/v1/chat/completions"
+	case EmbeddingsRequest, *EmbeddingsRequest:
+		return "/v1/embeddings"
+	case CompletionRequest, *CompletionRequest:
+		return "/v1/completions"
+	}
+	return ""
+}
+
 // BatchResponse represents a batch job.
 type BatchResponse struct {
 	// ID is the unique identifier for the batch.
@@ -104,6 +203,9 @@ type BatchResponse struct {
 
 	// Metadata is custom metadata.
 	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// clock is the clock of the client that fetched the batch.
+	clock Clock
 }
 
 // BatchErrors contains error information for a batch.
@@ -186,25 +288,14 @@ func (c *Client) CreateBatch(ctx context.Context, req BatchRequest, opts *Reques
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/batches",
-		Body:   req,
+		Operation: "zaguan.CreateBatch",
+		Method:    "POST",
+		Path:      "/v1/batches",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp BatchResponse
@@ -215,6 +306,7 @@ func (c *Client) CreateBatch(ctx context.Context, req BatchRequest, opts *Reques
 
 	c.log(ctx, LogLevelDebug, "create batch request succeeded", "batch_id", resp.ID)
 
+	resp.clock = c.clock
 	return &resp, nil
 }
 
@@ -236,24 +328,13 @@ func (c *Client) GetBatch(ctx context.Context, batchID string, opts *RequestOpti
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "GET",
-		Path:   "/v1/batches/" + batchID,
+		Operation: "zaguan.GetBatch",
+		Method:    "GET",
+		Path:      "/v1/batches/" + batchID,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp BatchResponse
@@ -264,44 +345,57 @@ func (c *Client) GetBatch(ctx context.Context, batchID string, opts *RequestOpti
 
 	c.log(ctx, LogLevelDebug, "get batch request succeeded", "batch_id", resp.ID)
 
+	resp.clock = c.clock
 	return &resp, nil
 }
 
-// ListBatches lists all batches with optional filtering.
+// ListBatchesOptions contains options for listing batches.
+type ListBatchesOptions struct {
+	// Limit is the maximum number of batches to return (default: 20).
+	Limit int
+
+	// After is the pagination cursor: the ID of the last batch of the
+	// previous page (BatchListResponse.LastID).
+	After string
+}
+
+// ListBatches lists one page of batches. listOpts may be nil to fetch the
+// first page with the default page size. Use BatchesIterator to walk all
+// pages.
 //
 // Example:
 //
-//	batches, err := client.ListBatches(ctx, nil)
+//	batches, err := client.ListBatches(ctx, &zaguansdk.ListBatchesOptions{Limit: 10}, nil)
 //	if err != nil {
 //		log.Fatal(err)
 //	}
 //	for _, batch := range batches.Data {
 //		fmt.Printf("%s: %s\n", batch.ID, batch.Status)
 //	}
-func (c *Client) ListBatches(ctx context.Context, opts *RequestOptions) (*BatchListResponse, error) {
+func (c *Client) ListBatches(ctx context.Context, listOpts *ListBatchesOptions, opts *RequestOptions) (*BatchListResponse, error) {
 	c.log(ctx, LogLevelDebug, "listing batches")
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "GET",
-		Path:   "/v1/batches",
+		Operation:   "zaguan.ListBatches",
+		Method:      "GET",
+		Path:        "/v1/batches",
+		QueryParams: make(map[string]string),
 	}
 
-	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
+	// Add query parameters from list options
+	if listOpts != nil {
+		if listOpts.Limit > 0 {
+			reqCfg.QueryParams["limit"] = fmt.Sprintf("%d", listOpts.Limit)
 		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
+		if listOpts.After != "" {
+			reqCfg.QueryParams["after"] = listOpts.After
 		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
 	}
 
+	// Apply request options
+	applyRequestOptions(&reqCfg, opts, c.timeout)
+
 	// Execute request
 	var resp BatchListResponse
 	if err := c.internalHTTP.DoJSON(ctx, reqCfg, &resp); err != nil {
@@ -311,9 +405,94 @@ func (c *Client) ListBatches(ctx context.Context, opts *RequestOptions) (*BatchL
 
 	c.log(ctx, LogLevelDebug, "list batches request succeeded", "count", len(resp.Data))
 
+	for i := range resp.Data {
+		resp.Data[i].clock = c.clock
+	}
 	return &resp, nil
 }
 
+// BatchesIterator iterates over batches, fetching pages with ListBatches as
+// needed. Create one with Client.BatchesIterator.
+type BatchesIterator struct {
+	client *Client
+	ctx    context.Context
+	opts   ListBatchesOptions
+
+	page  []BatchResponse
+	index int
+	batch *BatchResponse
+	done  bool
+	err   error
+}
+
+// BatchesIterator returns an iterator over all batches, following LastID
+// across pages while HasMore is set. listOpts may be nil; its Limit sets the
+// page size, and its After, if set, is where iteration starts.
+//
+// No request is made until the first call to Next.
+//
+// Example:
+//
+//	it := client.BatchesIterator(ctx, nil)
+//	for it.Next() {
+//		batch := it.Batch()
+//		fmt.Printf("%s: %s\n", batch.ID, batch.Status)
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+func (c *Client) BatchesIterator(ctx context.Context, listOpts *ListBatchesOptions) *BatchesIterator {
+	it := &BatchesIterator{client: c, ctx: ctx}
+	if listOpts != nil {
+		it.opts = *listOpts
+	}
+	return it
+}
+
+// Next advances to the next batch, fetching the next page if needed. It
+// returns false when all batches have been read or an error occurs; Err
+// distinguishes the two.
+func (it *BatchesIterator) Next() bool {
+	for it.index >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		list, err := it.client.ListBatches(it.ctx, &it.opts, nil)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = list.Data
+		it.index = 0
+
+		// Stop when the server reports no more pages or repeats the cursor
+		if !list.HasMore || list.LastID == "" || list.LastID == it.opts.After {
+			it.done = true
+		}
+		it.opts.After = list.LastID
+	}
+
+	it.batch = &it.page[it.index]
+	it.index++
+	return true
+}
+
+// Batch returns the batch read by the last successful call to Next.
+func (it *BatchesIterator) Batch() *BatchResponse {
+	return it.batch
+}
+
+// Err returns the error that stopped iteration, or nil if all batches were
+// read.
+func (it *BatchesIterator) Err() error {
+	return it.err
+}
+
 // CancelBatch cancels a batch that is in progress.
 //
 // Example:
@@ -332,24 +511,13 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string, opts *RequestO
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   fmt.Sprintf("/v1/batches/%s/cancel", batchID),
+		Operation: "zaguan.CancelBatch",
+		Method:    "POST",
+		Path:      fmt.Sprintf("/v1/batches/%s/cancel", batchID),
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp BatchResponse
@@ -360,9 +528,422 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string, opts *RequestO
 
 	c.log(ctx, LogLevelDebug, "cancel batch request succeeded", "batch_id", resp.ID)
 
+	resp.clock = c.clock
 	return &resp, nil
 }
 
+// BatchOutputItem represents a single line of a batch output or error file.
+type BatchOutputItem struct {
+	// ID is the identifier of the batch request.
+	ID string `json:"id"`
+
+	// CustomID is the user-provided identifier from the input file.
+	CustomID string `json:"custom_id"`
+
+	// StatusCode is the HTTP status code of the individual request.
+	// Zero if the request failed before a response was produced.
+	StatusCode int `json:"status_code,omitempty"`
+
+	// RequestID is the request identifier of the individual request.
+	RequestID string `json:"request_id,omitempty"`
+
+	// Body is the raw response body of the individual request.
+	Body json.RawMessage `json:"body,omitempty"`
+
+	// ChatResponse is the parsed body for /v1/chat/completions batches.
+	ChatResponse *ChatResponse `json:"chat_response,omitempty"`
+
+	// EmbeddingsResponse is the parsed body for /v1/embeddings batches.
+	EmbeddingsResponse *EmbeddingsResponse `json:"embeddings_response,omitempty"`
+
+	// Error contains error information if the request failed.
+	Error *BatchError `json:"error,omitempty"`
+}
+
+// IsSuccess returns true if the individual request succeeded.
+func (i *BatchOutputItem) IsSuccess() bool {
+	return i.Error == nil && i.StatusCode >= 200 && i.StatusCode < 300
+}
+
+// batchOutputLine is the wire format of a batch output file line.
+type batchOutputLine struct {
+	ID       string `json:"id"`
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		RequestID  string          `json:"request_id"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *BatchError `json:"error"`
+}
+
+// BatchResultsOptions contains options for retrieving batch results.
+type BatchResultsOptions struct {
+	// IncludeErrors also downloads the batch's error file (if any) and appends
+	// its rows to the results.
+	IncludeErrors bool
+}
+
+// GetBatchResults downloads and parses the output of a finished batch.
+//
+// The batch's output file is fetched via the Files API and each JSONL line is
+// decoded into a BatchOutputItem. For chat completion and embeddings batches
+// the response body is also parsed into ChatResponse or EmbeddingsResponse.
+// Set IncludeErrors to also merge the rows of the batch's error file.
+//
+// Example:
+//
+//	batch, err := client.WaitForBatch(ctx, "batch_abc123", 0, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	results, err := client.GetBatchResults(ctx, batch, &zaguansdk.BatchResultsOptions{
+//		IncludeErrors: true,
+//	}, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, item := range results {
+//		if item.IsSuccess() && item.ChatResponse != nil {
+//			fmt.Printf("%s: %v\n", item.CustomID, item.ChatResponse.Choices[0].Message.Content)
+//		}
+//	}
+func (c *Client) GetBatchResults(ctx context.Context, batch *BatchResponse, resultsOpts *BatchResultsOptions, opts *RequestOptions) ([]BatchOutputItem, error) {
+	if batch == nil {
+		return nil, &ValidationError{Field: "batch", Message: "batch is required"}
+	}
+
+	includeErrors := resultsOpts != nil && resultsOpts.IncludeErrors
+
+	fileIDs := make([]string, 0, 2)
+	if batch.OutputFileID != "" {
+		fileIDs = append(fileIDs, batch.OutputFileID)
+	}
+	if includeErrors && batch.ErrorFileID != "" {
+		fileIDs = append(fileIDs, batch.ErrorFileID)
+	}
+	if len(fileIDs) == 0 {
+		return nil, &ValidationError{Field: "output_file_id", Message: "batch has no output file"}
+	}
+
+	c.log(ctx, LogLevelDebug, "getting batch results", "batch_id", batch.ID)
+
+	var items []BatchOutputItem
+	for _, fileID := range fileIDs {
+		fileItems, err := c.getBatchFileItems(ctx, fileID, batch.Endpoint, opts)
+		if err != nil {
+			c.log(ctx, LogLevelError, "get batch results request failed", "error", err)
+			return nil, err
+		}
+		items = append(items, fileItems...)
+	}
+
+	c.log(ctx, LogLevelDebug, "get batch results request succeeded",
+		"batch_id", batch.ID,
+		"count", len(items))
+
+	return items, nil
+}
+
+// getBatchFileItems downloads a batch JSONL file and parses each line.
+func (c *Client) getBatchFileItems(ctx context.Context, fileID, endpoint string, opts *RequestOptions) ([]BatchOutputItem, error) {
+	// Build request config
+	reqCfg := internal.RequestConfig{
+		Operation: "zaguan.GetBatchResults",
+		Method:    "GET",
+		Path:      fmt.Sprintf("/v1/files/%s/content", fileID),
+	}
+
+	// Apply request options
+	applyRequestOptions(&reqCfg, opts, c.timeout)
+
+	// Execute request
+	resp, err := c.internalHTTP.Do(ctx, reqCfg)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check for error status codes
+	if resp.StatusCode >= 400 {
+		return nil, c.internalHTTP.ParseErrorResponse(resp)
+	}
+
+	return parseBatchOutput(resp.Body, endpoint)
+}
+
+// parseBatchOutput decodes a batch output JSONL stream into items, parsing
+// response bodies according to the batch endpoint.
+func parseBatchOutput(r io.Reader, endpoint string) ([]BatchOutputItem, error) {
+	var items []BatchOutputItem
+
+	reader := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read batch output: %w", err)
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			item, parseErr := parseBatchOutputLine(trimmed, endpoint)
+			if parseErr != nil {
+				return nil, fmt.Errorf("failed to parse batch output line %d: %w", lineNum, parseErr)
+			}
+			items = append(items, *item)
+		}
+
+		if err == io.EOF {
+			return items, nil
+		}
+	}
+}
+
+// parseBatchOutputLine decodes a single batch output line.
+func parseBatchOutputLine(line []byte, endpoint string) (*BatchOutputItem, error) {
+	var raw batchOutputLine
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, err
+	}
+
+	item := &BatchOutputItem{
+		ID:       raw.ID,
+		CustomID: raw.CustomID,
+		Error:    raw.Error,
+	}
+	if raw.Response == nil {
+		return item, nil
+	}
+
+	item.StatusCode = raw.Response.StatusCode
+	item.RequestID = raw.Response.RequestID
+	item.Body = raw.Response.Body
+
+	// Only successful bodies have the endpoint's response shape
+	if item.StatusCode < 200 || item.StatusCode >= 300 || len(item.Body) == 0 {
+		return item, nil
+	}
+
+	switch endpoint {
+	case "/v1/chat/completions":
+		var chat ChatResponse
+		if err := json.Unmarshal(item.Body, &chat); err != nil {
+			return nil, err
+		}
+		item.ChatResponse = &chat
+	case "/v1/embeddings":
+		var emb EmbeddingsResponse
+		if err := json.Unmarshal(item.Body, &emb); err != nil {
+			return nil, err
+		}
+		item.EmbeddingsResponse = &emb
+	}
+
+	return item, nil
+}
+
+// GetMessagesBatchResults downloads and parses the results of an ended
+// Messages batch from its ResultsURL.
+//
+// The results are always fetched through the client's base URL: an absolute
+// ResultsURL on another host is reduced to its path, so the API key is never
+// sent elsewhere. A batch that has not ended yet has no ResultsURL and returns
+// a *ValidationError.
+//
+// Example:
+//
+//	batch, err := client.WaitForMessagesBatch(ctx, "msgbatch_abc123", 0, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	results, err := client.GetMessagesBatchResults(ctx, batch, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, result := range results {
+//		if result.IsSuccess() {
+//			fmt.Printf("%s: %s\n", result.CustomID, result.Message.Content[0].Text)
+//		}
+//	}
+func (c *Client) GetMessagesBatchResults(ctx context.Context, batch *MessagesBatchResponse, opts *RequestOptions) ([]MessagesBatchResult, error) {
+	if batch == nil {
+		return nil, &ValidationError{Field: "batch", Message: "batch is required"}
+	}
+	if batch.ResultsURL == "" {
+		return nil, &ValidationError{
+			Field:   "results_url",
+			Message: fmt.Sprintf("batch %s has no results yet (processing_status %q)", batch.ID, batch.ProcessingStatus),
+		}
+	}
+
+	path, err := c.resultsPath(batch.ResultsURL)
+	if err != nil {
+		return nil, &ValidationError{Field: "results_url", Message: err.Error()}
+	}
+
+	c.log(ctx, LogLevelDebug, "getting messages batch results", "batch_id", batch.ID)
+
+	// Build request config
+	reqCfg := internal.RequestConfig{
+		Operation: "zaguan.GetMessagesBatchResults",
+		Method:    "GET",
+		Path:      path,
+	}
+
+	// Apply request options
+	applyRequestOptions(&reqCfg, opts, c.timeout)
+
+	// Execute request
+	resp, err := c.internalHTTP.Do(ctx, reqCfg)
+	if err != nil {
+		c.log(ctx, LogLevelError, "get messages batch results request failed", "error", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Check for error status codes
+	if resp.StatusCode >= 400 {
+		err := c.internalHTTP.ParseErrorResponse(resp)
+		c.log(ctx, LogLevelError, "get messages batch results request failed", "error", err)
+		return nil, err
+	}
+
+	results, err := parseMessagesBatchResults(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	c.log(ctx, LogLevelDebug, "get messages batch results request succeeded",
+		"batch_id", batch.ID,
+		"count", len(results))
+
+	return results, nil
+}
+
+// resultsPath returns the request path for a batch results URL relative to
+// the client's base URL.
+func (c *Client) resultsPath(resultsURL string) (string, error) {
+	if rest, ok := strings.CutPrefix(resultsURL, c.baseURL); ok && strings.HasPrefix(rest, "/") {
+		return rest, nil
+	}
+
+	u, err := url.Parse(resultsURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid results URL: %w", err)
+	}
+	path := u.RequestURI()
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path, nil
+}
+
+// parseMessagesBatchResults decodes a Messages batch results JSONL stream.
+func parseMessagesBatchResults(r io.Reader) ([]MessagesBatchResult, error) {
+	var results []MessagesBatchResult
+
+	reader := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("failed to read batch results: %w", err)
+		}
+
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			var raw messagesBatchResultLine
+			if parseErr := json.Unmarshal(trimmed, &raw); parseErr != nil {
+				return nil, fmt.Errorf("failed to parse batch results line %d: %w", lineNum, parseErr)
+			}
+			result := MessagesBatchResult{
+				CustomID: raw.CustomID,
+				Type:     raw.Result.Type,
+				Message:  raw.Result.Message,
+			}
+			if raw.Result.Error != nil {
+				result.Error = raw.Result.Error.Error
+			}
+			results = append(results, result)
+		}
+
+		if err == io.EOF {
+			return results, nil
+		}
+	}
+}
+
+// DefaultBatchPollInterval is the polling interval used by WaitForBatch and
+// WaitForMessagesBatch when none is specified.
+const DefaultBatchPollInterval = 10 * time.Second
+
+// maxBatchPollInterval caps the backoff applied between batch status polls.
+const maxBatchPollInterval = 2 * time.Minute
+
+// WaitForBatch polls a batch until it reaches a terminal status.
+//
+// The batch is polled every pollInterval, backing off gradually (up to two
+// minutes between polls) so long-running batches don't hammer the endpoint.
+// If pollInterval is zero, DefaultBatchPollInterval is used. Polling stops when
+// the batch is completed, failed, expired, or cancelled, or when ctx is done.
+//
+// Example:
+//
+//	batch, err := client.WaitForBatch(ctx, "batch_abc123", 30*time.Second, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if batch.IsCompleted() {
+//		fmt.Println("Output file:", batch.OutputFileID)
+//	}
+func (c *Client) WaitForBatch(ctx context.Context, batchID string, pollInterval time.Duration, opts *RequestOptions) (*BatchResponse, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultBatchPollInterval
+	}
+
+	interval := pollInterval
+	for {
+		batch, err := c.GetBatch(ctx, batchID, opts)
+		if err != nil {
+			return nil, err
+		}
+		if batch.IsTerminal() {
+			return batch, nil
+		}
+
+		c.log(ctx, LogLevelDebug, "waiting for batch",
+			"batch_id", batchID,
+			"status", batch.Status,
+			"next_poll", interval)
+
+		if err := c.sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+		interval = nextPollInterval(interval)
+	}
+}
+
+// nextPollInterval returns the next polling interval, growing by 50% up to
+// maxBatchPollInterval. Intervals already above the cap are left unchanged.
+func nextPollInterval(interval time.Duration) time.Duration {
+	if interval >= maxBatchPollInterval {
+		return interval
+	}
+	next := interval + interval/2
+	if next > maxBatchPollInterval {
+		next = maxBatchPollInterval
+	}
+	return next
+}
+
+// sleep waits for d on the client's clock or until ctx is done, whichever
+// comes first.
+func (c *Client) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.clock.After(d):
+		return nil
+	}
+}
+
 // IsCompleted returns true if the batch has completed successfully.
 func (b *BatchResponse) IsCompleted() bool {
 	return b.Status == "completed"
@@ -377,3 +958,57 @@ func (b *BatchResponse) IsFailed() bool {
 func (b *BatchResponse) IsInProgress() bool {
 	return b.Status == "in_progress" || b.Status == "validating" || b.Status == "finalizing"
 }
+
+// IsTerminal returns true if the batch has reached a final status and will not
+// change further (completed, failed, expired, or cancelled).
+func (b *BatchResponse) IsTerminal() bool {
+	switch b.Status {
+	case "completed", "failed", "expired", "cancelled":
+		return true
+	}
+	return false
+}
+
+// EstimatedCompletion estimates when the batch will finish by extrapolating
+// the rate at which requests have been processed since InProgressAt.
+//
+// A completed batch returns CompletedAt. It returns false if the batch has
+// not started processing, has not processed any requests yet, or ended
+// without completing.
+//
+// Example:
+//
+//	if eta, ok := batch.EstimatedCompletion(); ok {
+//		fmt.Printf("batch %s: ETA %s\n", batch.ID, eta.Format(time.Kitchen))
+//	}
+func (b *BatchResponse) EstimatedCompletion() (time.Time, bool) {
+	if b.CompletedAt != 0 {
+		return time.Unix(b.CompletedAt, 0), true
+	}
+	if b.IsTerminal() || b.InProgressAt == 0 {
+		return time.Time{}, false
+	}
+
+	counts := b.RequestCounts
+	processed := counts.Completed + counts.Failed
+	if processed == 0 || counts.Total == 0 {
+		return time.Time{}, false
+	}
+
+	var clock Clock = realClock{}
+	if b.clock != nil {
+		clock = b.clock
+	}
+	start := time.Unix(b.InProgressAt, 0)
+	elapsed := clock.Now().Sub(start)
+	if elapsed <= 0 {
+		return time.Time{}, false
+	}
+	if processed >= counts.Total {
+		// All requests are done; the batch is finalizing
+		return clock.Now(), true
+	}
+
+	total := time.Duration(float64(elapsed) * float64(counts.Total) / float64(processed))
+	return start.Add(total), true
+}
@@ -3,9 +3,15 @@ package zaguansdk
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/zaguantest"
 )
 
 func TestCreateBatch(t *testing.T) {
@@ -233,7 +239,7 @@ func TestListBatches(t *testing.T) {
 		APIKey:  "test-key",
 	})
 
-	resp, err := client.ListBatches(context.Background(), nil)
+	resp, err := client.ListBatches(context.Background(), nil, nil)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -243,6 +249,94 @@ func TestListBatches(t *testing.T) {
 	}
 }
 
+func TestListBatches_QueryParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		listOpts  *ListBatchesOptions
+		wantQuery string
+	}{
+		{"nil options", nil, ""},
+		{"limit", &ListBatchesOptions{Limit: 10}, "limit=10"},
+		{"limit and after", &ListBatchesOptions{Limit: 5, After: "batch-2"}, "after=batch-2&limit=5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotQuery string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotQuery = r.URL.Query().Encode()
+				json.NewEncoder(w).Encode(BatchListResponse{Object: "list"})
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{
+				BaseURL: server.URL,
+				APIKey:  "test-key",
+			})
+
+			if _, err := client.ListBatches(context.Background(), tt.listOpts, nil); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if gotQuery != tt.wantQuery {
+				t.Errorf("query = %q, want %q", gotQuery, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestBatchesIterator(t *testing.T) {
+	pages := map[string]BatchListResponse{
+		"": {
+			Data:    []BatchResponse{{ID: "batch-1"}, {ID: "batch-2"}},
+			FirstID: "batch-1",
+			LastID:  "batch-2",
+			HasMore: true,
+		},
+		"batch-2": {
+			Data:    []BatchResponse{{ID: "batch-3"}},
+			FirstID: "batch-3",
+			LastID:  "batch-3",
+			HasMore: false,
+		},
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("limit") != "2" {
+			t.Errorf("limit = %s, want 2", r.URL.Query().Get("limit"))
+		}
+		page, ok := pages[r.URL.Query().Get("after")]
+		if !ok {
+			t.Errorf("unexpected after: %s", r.URL.Query().Get("after"))
+		}
+		json.NewEncoder(w).Encode(page)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	it := client.BatchesIterator(context.Background(), &ListBatchesOptions{Limit: 2})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Batch().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	want := []string{"batch-1", "batch-2", "batch-3"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("batches = %v, want %v", ids, want)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+}
+
 func TestCancelBatch(t *testing.T) {
 	mockResponse := BatchResponse{
 		ID:            "batch-123",
@@ -344,6 +438,320 @@ func TestBatchResponseHelpers(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("IsTerminal", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			status string
+			want   bool
+		}{
+			{"completed", "completed", true},
+			{"failed", "failed", true},
+			{"expired", "expired", true},
+			{"cancelled", "cancelled", true},
+			{"cancelling", "cancelling", false},
+			{"in_progress", "in_progress", false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				batch := BatchResponse{Status: tt.status}
+				if got := batch.IsTerminal(); got != tt.want {
+					t.Errorf("IsTerminal() = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("EstimatedCompletion", func(t *testing.T) {
+		start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		clock := zaguantest.NewFakeClock(start.Add(10 * time.Minute))
+
+		tests := []struct {
+			name   string
+			batch  BatchResponse
+			want   time.Time
+			wantOK bool
+		}{
+			{
+				name: "quarter done",
+				batch: BatchResponse{
+					Status:        "in_progress",
+					InProgressAt:  start.Unix(),
+					RequestCounts: BatchRequestCounts{Total: 100, Completed: 20, Failed: 5},
+				},
+				want:   start.Add(40 * time.Minute),
+				wantOK: true,
+			},
+			{
+				name: "all processed",
+				batch: BatchResponse{
+					Status:        "finalizing",
+					InProgressAt:  start.Unix(),
+					RequestCounts: BatchRequestCounts{Total: 100, Completed: 100},
+				},
+				want:   start.Add(10 * time.Minute),
+				wantOK: true,
+			},
+			{
+				name: "completed",
+				batch: BatchResponse{
+					Status:       "completed",
+					InProgressAt: start.Unix(),
+					CompletedAt:  start.Add(5 * time.Minute).Unix(),
+				},
+				want:   start.Add(5 * time.Minute),
+				wantOK: true,
+			},
+			{
+				name:  "not started",
+				batch: BatchResponse{Status: "validating", RequestCounts: BatchRequestCounts{Total: 100}},
+			},
+			{
+				name: "nothing processed",
+				batch: BatchResponse{
+					Status:        "in_progress",
+					InProgressAt:  start.Unix(),
+					RequestCounts: BatchRequestCounts{Total: 100},
+				},
+			},
+			{
+				name: "cancelled",
+				batch: BatchResponse{
+					Status:        "cancelled",
+					InProgressAt:  start.Unix(),
+					RequestCounts: BatchRequestCounts{Total: 100, Completed: 50},
+				},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				batch := tt.batch
+				batch.clock = clock
+				got, ok := batch.EstimatedCompletion()
+				if ok != tt.wantOK || !got.Equal(tt.want) {
+					t.Errorf("EstimatedCompletion() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+				}
+			})
+		}
+	})
+}
+
+func TestGetBatchResults(t *testing.T) {
+	output := `{"id":"batch_req_1","custom_id":"request-1","response":{"status_code":200,"request_id":"req_1","body":{"id":"chatcmpl-1","object":"chat.completion","model":"openai/gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}},"error":null}
+{"id":"batch_req_2","custom_id":"request-2","response":{"status_code":200,"request_id":"req_2","body":{"id":"chatcmpl-2","object":"chat.completion","model":"openai/gpt-4o","choices":[]}},"error":null}
+`
+	errorsFile := `{"id":"batch_req_3","custom_id":"request-3","response":null,"error":{"code":"invalid_request","message":"bad input"}}
+`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/v1/files/file-out/content":
+			w.Write([]byte(output))
+		case "/v1/files/file-err/content":
+			w.Write([]byte(errorsFile))
+		default:
+			t.Errorf("Unexpected path %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	batch := &BatchResponse{
+		ID:           "batch-123",
+		Endpoint:     "/v1/chat/completions",
+		Status:       "completed",
+		OutputFileID: "file-out",
+		ErrorFileID:  "file-err",
+	}
+
+	t.Run("output only", func(t *testing.T) {
+		items, err := client.GetBatchResults(context.Background(), batch, nil, nil)
+		if err != nil {
+			t.Fatalf("GetBatchResults() error = %v", err)
+		}
+		if len(items) != 2 {
+			t.Fatalf("got %d items, want 2", len(items))
+		}
+		if items[0].CustomID != "request-1" || items[0].StatusCode != 200 {
+			t.Errorf("items[0] = %+v", items[0])
+		}
+		if items[0].ChatResponse == nil || items[0].ChatResponse.Choices[0].Message.Content != "Hi" {
+			t.Errorf("items[0].ChatResponse not parsed: %+v", items[0].ChatResponse)
+		}
+		if !items[1].IsSuccess() {
+			t.Error("items[1].IsSuccess() = false, want true")
+		}
+	})
+
+	t.Run("include errors", func(t *testing.T) {
+		items, err := client.GetBatchResults(context.Background(), batch, &BatchResultsOptions{IncludeErrors: true}, nil)
+		if err != nil {
+			t.Fatalf("GetBatchResults() error = %v", err)
+		}
+		if len(items) != 3 {
+			t.Fatalf("got %d items, want 3", len(items))
+		}
+		failed := items[2]
+		if failed.CustomID != "request-3" || failed.IsSuccess() {
+			t.Errorf("items[2] = %+v, want failed request-3", failed)
+		}
+		if failed.Error == nil || failed.Error.Message != "bad input" {
+			t.Errorf("items[2].Error = %+v, want bad input", failed.Error)
+		}
+	})
+
+	t.Run("no output file", func(t *testing.T) {
+		_, err := client.GetBatchResults(context.Background(), &BatchResponse{ID: "batch-456"}, nil, nil)
+		if err == nil {
+			t.Error("GetBatchResults() should return error when batch has no output file")
+		}
+	})
+}
+
+func TestParseBatchOutput_Embeddings(t *testing.T) {
+	output := `{"id":"batch_req_1","custom_id":"emb-1","response":{"status_code":200,"body":{"object":"list","data":[{"object":"embedding","embedding":[0.1,0.2],"index":0}],"model":"openai/text-embedding-3-small"}}}`
+
+	items, err := parseBatchOutput(strings.NewReader(output), "/v1/embeddings")
+	if err != nil {
+		t.Fatalf("parseBatchOutput() error = %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("got %d items, want 1", len(items))
+	}
+	if items[0].EmbeddingsResponse == nil || len(items[0].EmbeddingsResponse.Data) != 1 {
+		t.Errorf("EmbeddingsResponse not parsed: %+v", items[0].EmbeddingsResponse)
+	}
+}
+
+func TestWaitForBatch(t *testing.T) {
+	statuses := []string{"validating", "in_progress", "completed"}
+	var polls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/batches/batch-123" {
+			t.Errorf("Expected path /v1/batches/batch-123, got %s", r.URL.Path)
+		}
+		n := atomic.AddInt32(&polls, 1)
+		status := statuses[len(statuses)-1]
+		if int(n) <= len(statuses) {
+			status = statuses[n-1]
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(BatchResponse{ID: "batch-123", Status: status})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	batch, err := client.WaitForBatch(context.Background(), "batch-123", time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("WaitForBatch() error = %v", err)
+	}
+	if batch.Status != "completed" {
+		t.Errorf("Status = %s, want completed", batch.Status)
+	}
+	if got := atomic.LoadInt32(&polls); got != 3 {
+		t.Errorf("polled %d times, want 3", got)
+	}
+}
+
+func TestWaitForBatch_ContextCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(BatchResponse{ID: "batch-123", Status: "in_progress"})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.WaitForBatch(ctx, "batch-123", 10*time.Millisecond, nil)
+	if err == nil {
+		t.Fatal("WaitForBatch() should return error when context is done")
+	}
+}
+
+func TestWaitForBatch_FakeClock(t *testing.T) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := "in_progress"
+		if atomic.AddInt32(&polls, 1) == 3 {
+			status = "completed"
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(BatchResponse{ID: "batch-123", Status: status})
+	}))
+	defer server.Close()
+
+	clock := zaguantest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+		Clock:   clock,
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.WaitForBatch(context.Background(), "batch-123", 10*time.Second, nil)
+		done <- err
+	}()
+
+	// Each poll interval grows by half: 10s, then 15s
+	for _, interval := range []time.Duration{10 * time.Second, 15 * time.Second} {
+		for clock.Waiters() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		clock.Advance(interval - time.Nanosecond)
+		if clock.Waiters() != 1 {
+			t.Fatalf("poll fired before %v elapsed", interval)
+		}
+		clock.Advance(time.Nanosecond)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("WaitForBatch() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&polls); got != 3 {
+		t.Errorf("polled %d times, want 3", got)
+	}
+}
+
+func TestNextPollInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		want     time.Duration
+	}{
+		{"grows by half", 10 * time.Second, 15 * time.Second},
+		{"capped", 100 * time.Second, maxBatchPollInterval},
+		{"above cap unchanged", 5 * time.Minute, 5 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextPollInterval(tt.interval); got != tt.want {
+				t.Errorf("nextPollInterval(%v) = %v, want %v", tt.interval, got, tt.want)
+			}
+		})
+	}
 }
 
 func TestValidateBatchRequest(t *testing.T) {
@@ -396,3 +804,93 @@ func TestValidateBatchRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateBatchInput(t *testing.T) {
+	chatItem := func(id string) BatchInputItem {
+		return BatchInputItem{
+			CustomID: id,
+			Method:   "POST",
+			URL:      "/v1/chat/completions",
+			Body: ChatRequest{
+				Model:    "openai/gpt-4o",
+				Messages: []Message{{Role: "user", Content: "Hello"}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		endpoint  string
+		items     []BatchInputItem
+		wantField string
+	}{
+		{
+			name:     "valid",
+			endpoint: "/v1/chat/completions",
+			items:    []BatchInputItem{chatItem("a"), chatItem("b")},
+		},
+		{
+			name:     "untyped body",
+			endpoint: "/v1/embeddings",
+			items: []BatchInputItem{{
+				CustomID: "a", Method: "POST", URL: "/v1/embeddings",
+				Body: map[string]interface{}{"model": "openai/text-embedding-3-small", "input": "hi"},
+			}},
+		},
+		{
+			name:      "missing endpoint",
+			items:     []BatchInputItem{chatItem("a")},
+			wantField: "endpoint",
+		},
+		{
+			name:      "no items",
+			endpoint:  "/v1/chat/completions",
+			wantField: "items",
+		},
+		{
+			name:      "url mismatch",
+			endpoint:  "/v1/embeddings",
+			items:     []BatchInputItem{chatItem("a")},
+			wantField: "items[0].url",
+		},
+		{
+			name:     "body mismatch",
+			endpoint: "/v1/embeddings",
+			items: []BatchInputItem{{
+				CustomID: "a", Method: "POST", URL: "/v1/embeddings",
+				Body: &ChatRequest{Model: "openai/gpt-4o"},
+			}},
+			wantField: "items[0].body",
+		},
+		{
+			name:      "duplicate custom_id",
+			endpoint:  "/v1/chat/completions",
+			items:     []BatchInputItem{chatItem("a"), chatItem("a")},
+			wantField: "items[1].custom_id",
+		},
+		{
+			name:     "wrong method",
+			endpoint: "/v1/chat/completions",
+			items: []BatchInputItem{{
+				CustomID: "a", Method: "GET", URL: "/v1/chat/completions",
+			}},
+			wantField: "items[0].method",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBatchInput(tt.endpoint, tt.items)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("ValidateBatchInput() error = %v, want nil", err)
+				}
+				return
+			}
+			var valErr *ValidationError
+			if !errors.As(err, &valErr) || valErr.Field != tt.wantField {
+				t.Errorf("ValidateBatchInput() error = %v, want ValidationError on %s", err, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package zaguansdk provides prompt caching helpers for the Zaguan SDK.
+//
+// This file implements local token estimation and helpers for deciding when
+// a prompt segment is large enough to benefit from provider-side prompt
+// caching. Providers ignore cache breakpoints on content below a minimum
+// token count, so marking short content wastes one of the limited
+// breakpoint slots.
+package zaguansdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultCacheMinTokens is the minimum cacheable prompt length used by most
+// providers (Anthropic Sonnet/Opus models, OpenAI prompt caching).
+const DefaultCacheMinTokens = 1024
+
+// charsPerToken is the average number of characters per token used for
+// estimation. This matches the common heuristic for English text.
+const charsPerToken = 4
+
+// EstimateTokens returns a rough estimate of the number of tokens in text.
+//
+// The estimate assumes roughly four characters per token and is intended for
+// quick decisions such as prompt caching thresholds. Use CountTokens for an
+// exact count.
+func EstimateTokens(text string) int {
+	n := utf8.RuneCountInString(text)
+	if n == 0 {
+		return 0
+	}
+	return (n + charsPerToken - 1) / charsPerToken
+}
+
+// Per-request overheads used by EstimateChatRequestTokens. They follow
+// OpenAI's published accounting for chat models, which other providers
+// approximate closely.
+const (
+	// tokensPerMessage covers the role and delimiters wrapping each message.
+	tokensPerMessage = 3
+
+	// tokensPerName is added for messages that set Name.
+	tokensPerName = 1
+
+	// replyPrimingTokens covers the assistant turn the model is primed with.
+	replyPrimingTokens = 3
+
+	// toolsOverheadTokens covers the system preamble that introduces tools.
+	toolsOverheadTokens = 12
+
+	// lowDetailImageTokens is the fixed cost of a "low" detail image.
+	lowDetailImageTokens = 85
+
+	// highDetailImageTokens approximates a "high" or "auto" detail image of
+	// about 1024x1024.
+	highDetailImageTokens = 765
+)
+
+// EstimateChatRequestTokens returns a rough estimate of the number of prompt
+// tokens a chat request will consume.
+//
+// Unlike EstimateTokens, it accounts for everything the provider bills as
+// input: every message including system and developer messages, per-message
+// overhead, tool calls in assistant messages, the serialized tool
+// definitions, and a JSON schema response format. Images count a fixed
+// per-detail cost. An error is returned if the tools or content cannot be
+// serialized.
+//
+// Example:
+//
+//	tokens, err := zaguansdk.EstimateChatRequestTokens(req)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if tokens > budget {
+//		req.Messages = trimHistory(req.Messages)
+//	}
+func EstimateChatRequestTokens(req ChatRequest) (int, error) {
+	total := replyPrimingTokens
+
+	for _, msg := range req.Messages {
+		n, err := estimateMessageTokens(msg)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+
+	if len(req.Tools) > 0 {
+		data, err := json.Marshal(req.Tools)
+		if err != nil {
+			return 0, fmt.Errorf("failed to serialize tools: %w", err)
+		}
+		total += toolsOverheadTokens + EstimateTokens(string(data))
+	}
+
+	if req.ResponseFormat != nil {
+		data, err := json.Marshal(req.ResponseFormat)
+		if err != nil {
+			return 0, fmt.Errorf("failed to serialize response format: %w", err)
+		}
+		total += EstimateTokens(string(data))
+	}
+
+	return total, nil
+}
+
+// estimateMessageTokens estimates the tokens of a single message, including
+// its overhead.
+func estimateMessageTokens(msg Message) (int, error) {
+	total := tokensPerMessage + EstimateTokens(msg.Role)
+	if msg.Name != "" {
+		total += tokensPerName + EstimateTokens(msg.Name)
+	}
+
+	switch content := msg.Content.(type) {
+	case nil:
+	case string:
+		total += EstimateTokens(content)
+	case []ContentPart:
+		for _, part := range content {
+			total += estimateContentPartTokens(part)
+		}
+	default:
+		// Decoded or custom content; estimate from its serialized form
+		data, err := json.Marshal(content)
+		if err != nil {
+			return 0, fmt.Errorf("failed to serialize message content: %w", err)
+		}
+		total += EstimateTokens(string(data))
+	}
+
+	for _, tc := range msg.ToolCalls {
+		total += EstimateTokens(tc.Function.Name) + EstimateTokens(tc.Function.Arguments)
+	}
+	if msg.FunctionCall != nil {
+		total += EstimateTokens(msg.FunctionCall.Name) + EstimateTokens(msg.FunctionCall.Arguments)
+	}
+	if msg.ToolCallID != "" {
+		total += EstimateTokens(msg.ToolCallID)
+	}
+
+	return total, nil
+}
+
+// estimateContentPartTokens estimates the tokens of a multimodal content part.
+func estimateContentPartTokens(part ContentPart) int {
+	switch part.Type {
+	case "text":
+		return EstimateTokens(part.Text)
+	case "image_url":
+		if part.ImageURL != nil && part.ImageURL.Detail == "low" {
+			return lowDetailImageTokens
+		}
+		return highDetailImageTokens
+	}
+	return 0
+}
+
+// CacheMinTokens returns the minimum number of tokens a prompt prefix must
+// contain for the given model's provider to cache it.
+//
+// Anthropic Haiku models require 2048 tokens; other models use
+// DefaultCacheMinTokens.
+func CacheMinTokens(model string) int {
+	m := strings.ToLower(model)
+	if strings.Contains(m, "claude") && strings.Contains(m, "haiku") {
+		return 2048
+	}
+	return DefaultCacheMinTokens
+}
+
+// ShouldCache reports whether content is long enough to benefit from a
+// cache_control breakpoint on the given model.
+//
+// Example:
+//
+//	cache := zaguansdk.ShouldCache(document, "anthropic/claude-3-5-sonnet-20241022")
+//	block := zaguansdk.AnthropicTextBlock(document, cache)
+func ShouldCache(content string, model string) bool {
+	return EstimateTokens(content) >= CacheMinTokens(model)
+}
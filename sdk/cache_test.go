@@ -0,0 +1,165 @@
+package zaguansdk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want int
+	}{
+		{name: "empty", text: "", want: 0},
+		{name: "short", text: "Hi", want: 1},
+		{name: "exact multiple", text: "abcdefgh", want: 2},
+		{name: "rounds up", text: "abcdefghi", want: 3},
+		{name: "multi-byte runes", text: "ñandú", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateTokens(tt.text); got != tt.want {
+				t.Errorf("EstimateTokens(%q) = %d, want %d", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheMinTokens(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{model: "anthropic/claude-3-5-sonnet-20241022", want: 1024},
+		{model: "anthropic/claude-3-5-haiku-20241022", want: 2048},
+		{model: "openai/gpt-4o", want: 1024},
+		{model: "", want: 1024},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			if got := CacheMinTokens(tt.model); got != tt.want {
+				t.Errorf("CacheMinTokens(%q) = %d, want %d", tt.model, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldCache(t *testing.T) {
+	short := "You are a helpful assistant."
+	medium := strings.Repeat("a", 1024*4)
+	long := strings.Repeat("a", 2048*4)
+
+	tests := []struct {
+		name    string
+		content string
+		model   string
+		want    bool
+	}{
+		{name: "short content", content: short, model: "anthropic/claude-3-5-sonnet-20241022", want: false},
+		{name: "at sonnet minimum", content: medium, model: "anthropic/claude-3-5-sonnet-20241022", want: true},
+		{name: "below haiku minimum", content: medium, model: "anthropic/claude-3-haiku-20240307", want: false},
+		{name: "at haiku minimum", content: long, model: "anthropic/claude-3-haiku-20240307", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ShouldCache(tt.content, tt.model); got != tt.want {
+				t.Errorf("ShouldCache() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateChatRequestTokens(t *testing.T) {
+	base := ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "What is the weather in Paris?"},
+		},
+	}
+	baseTokens, err := EstimateChatRequestTokens(base)
+	if err != nil {
+		t.Fatalf("EstimateChatRequestTokens() error = %v", err)
+	}
+	// reply priming + message overhead + role + content
+	want := replyPrimingTokens + tokensPerMessage + EstimateTokens("user") + EstimateTokens("What is the weather in Paris?")
+	if baseTokens != want {
+		t.Errorf("EstimateChatRequestTokens() = %d, want %d", baseTokens, want)
+	}
+
+	withSystem := base
+	withSystem.Messages = append([]Message{{Role: "system", Content: "You are a helpful assistant."}}, base.Messages...)
+	got, err := EstimateChatRequestTokens(withSystem)
+	if err != nil {
+		t.Fatalf("EstimateChatRequestTokens() error = %v", err)
+	}
+	if got <= baseTokens {
+		t.Errorf("EstimateChatRequestTokens() with system message = %d, want > %d", got, baseTokens)
+	}
+
+	withTools := base
+	withTools.Tools = []Tool{{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        "get_weather",
+			Description: "Get the current weather for a city",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"city": map[string]interface{}{"type": "string"},
+				},
+			},
+		},
+	}}
+	got, err = EstimateChatRequestTokens(withTools)
+	if err != nil {
+		t.Fatalf("EstimateChatRequestTokens() error = %v", err)
+	}
+	if got <= baseTokens+toolsOverheadTokens {
+		t.Errorf("EstimateChatRequestTokens() with tools = %d, want > %d", got, baseTokens+toolsOverheadTokens)
+	}
+}
+
+func TestEstimateChatRequestTokens_ContentParts(t *testing.T) {
+	tests := []struct {
+		name   string
+		detail string
+		want   int
+	}{
+		{name: "low detail", detail: "low", want: lowDetailImageTokens},
+		{name: "high detail", detail: "high", want: highDetailImageTokens},
+		{name: "auto detail", detail: "", want: highDetailImageTokens},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := ChatRequest{Messages: []Message{
+				NewUserMessage(TextPart("Describe"), ImageURLPart("https://example.com/a.png", tt.detail)),
+			}}
+			got, err := EstimateChatRequestTokens(req)
+			if err != nil {
+				t.Fatalf("EstimateChatRequestTokens() error = %v", err)
+			}
+			want := replyPrimingTokens + tokensPerMessage + EstimateTokens("user") + EstimateTokens("Describe") + tt.want
+			if got != want {
+				t.Errorf("EstimateChatRequestTokens() = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestEstimateChatRequestTokens_Error(t *testing.T) {
+	req := ChatRequest{
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+		Tools: []Tool{{
+			Type:     "function",
+			Function: FunctionDefinition{Name: "bad", Parameters: map[string]interface{}{"ch": make(chan int)}},
+		}},
+	}
+	if _, err := EstimateChatRequestTokens(req); err == nil {
+		t.Error("EstimateChatRequestTokens() error = nil, want error for unserializable tools")
+	}
+}
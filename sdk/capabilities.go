@@ -2,6 +2,10 @@ package zaguansdk
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
 )
@@ -66,6 +70,47 @@ type ModelCapabilities struct {
 	// Modalities is a list of supported modalities.
 	// Examples: "text", "image", "audio"
 	Modalities []string `json:"modalities,omitempty"`
+
+	// AudioVoices is the list of voices supported by a text-to-speech model.
+	// Examples: "alloy", "echo", "nova"
+	AudioVoices []string `json:"audio_voices,omitempty"`
+
+	// AudioFormats is the list of audio output formats supported by a
+	// text-to-speech model.
+	// Examples: "mp3", "opus", "wav"
+	AudioFormats []string `json:"audio_formats,omitempty"`
+}
+
+// ValidateSpeechRequest checks that the voice and response format of req are
+// supported by this model.
+//
+// A check is skipped when the model does not report the corresponding list,
+// and the format check is skipped when req.ResponseFormat is empty.
+// Returns a *ValidationError for unsupported values.
+func (mc *ModelCapabilities) ValidateSpeechRequest(req *AudioSpeechRequest) error {
+	if req.Voice != "" && len(mc.AudioVoices) > 0 && !containsString(mc.AudioVoices, req.Voice) {
+		return &ValidationError{
+			Field:   "voice",
+			Message: fmt.Sprintf("voice %q is not supported by %s (supported: %s)", req.Voice, mc.ModelID, strings.Join(mc.AudioVoices, ", ")),
+		}
+	}
+	if req.ResponseFormat != "" && len(mc.AudioFormats) > 0 && !containsString(mc.AudioFormats, req.ResponseFormat) {
+		return &ValidationError{
+			Field:   "response_format",
+			Message: fmt.Sprintf("response_format %q is not supported by %s (supported: %s)", req.ResponseFormat, mc.ModelID, strings.Join(mc.AudioFormats, ", ")),
+		}
+	}
+	return nil
+}
+
+// containsString reports whether values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
 }
 
 // CapabilitiesResponse represents the response from GET /v1/capabilities.
@@ -77,11 +122,43 @@ type CapabilitiesResponse struct {
 	Models []ModelCapabilities `json:"models,omitempty"`
 }
 
+// DefaultCapabilitiesTTL is how long capabilities are cached when
+// Config.CapabilitiesTTL is zero.
+const DefaultCapabilitiesTTL = 5 * time.Minute
+
+// capabilitiesCache holds the capabilities last fetched by a Client.
+//
+// Concurrent callers that miss the cache share a single in-flight fetch.
+type capabilitiesCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	caps      []ModelCapabilities
+	expiresAt time.Time
+	inflight  *capabilitiesFetch
+}
+
+// capabilitiesFetch is a capabilities request shared by concurrent callers.
+type capabilitiesFetch struct {
+	done    chan struct{}
+	caps    []ModelCapabilities
+	err     error
+	cancel  context.CancelFunc
+	waiters int // callers waiting on done; guarded by capabilitiesCache.mu
+}
+
 // GetCapabilities retrieves capability information for all models.
 //
 // This endpoint provides detailed information about what each model supports,
 // including vision, tools, reasoning, context limits, and pricing.
 //
+// Results are cached on the client for Config.CapabilitiesTTL, so helpers
+// such as SupportsVision can be called in loops without re-fetching. Requests
+// that override the API key or add headers bypass the cache, since they may
+// see a different set of models. For cached lookups, opts.Timeout bounds how
+// long this call waits; other options apply only to requests that bypass the
+// cache. Use RefreshCapabilities to force a reload.
+//
 // Example:
 //
 //	caps, err := client.GetCapabilities(ctx, nil)
@@ -93,28 +170,128 @@ type CapabilitiesResponse struct {
 //			cap.ModelID, cap.SupportsVision, cap.SupportsTools, cap.SupportsReasoning)
 //	}
 func (c *Client) GetCapabilities(ctx context.Context, opts *RequestOptions) ([]ModelCapabilities, error) {
+	if opts != nil && (opts.APIKey != "" || len(opts.Headers) > 0) {
+		return c.fetchCapabilities(ctx, opts)
+	}
+	if opts != nil && opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	return c.cachedCapabilities(ctx, false)
+}
+
+// RefreshCapabilities reloads the capabilities cache from the server, even
+// if the cached capabilities have not expired.
+func (c *Client) RefreshCapabilities(ctx context.Context) error {
+	_, err := c.cachedCapabilities(ctx, true)
+	return err
+}
+
+// ClearCapabilitiesCache discards cached capabilities, so the next lookup
+// fetches them from the server.
+func (c *Client) ClearCapabilitiesCache() {
+	c.capabilities.mu.Lock()
+	c.capabilities.caps = nil
+	c.capabilities.expiresAt = time.Time{}
+	c.capabilities.mu.Unlock()
+}
+
+// cachedCapabilities returns the cached capabilities, fetching them if they
+// are missing, expired, or force is set. Callers that arrive while a fetch is
+// in flight wait for it instead of starting their own.
+//
+// The fetch is shared by every waiting caller, so it runs in the background
+// on a context detached from the caller that started it, bounded by
+// Config.Timeout. Each caller stops waiting when its own ctx is done, and
+// once no caller is left waiting the fetch is canceled, so a hung request
+// cannot block later callers.
+func (c *Client) cachedCapabilities(ctx context.Context, force bool) ([]ModelCapabilities, error) {
+	// A closed client must not keep answering from the cache
+	if c.internalHTTP.Closed() {
+		return nil, ErrClientClosed
+	}
+
+	cache := c.capabilities
+
+	cache.mu.Lock()
+	if !force && cache.caps != nil && c.clock.Now().Before(cache.expiresAt) {
+		caps := cache.caps
+		cache.mu.Unlock()
+		return copyCapabilities(caps), nil
+	}
+
+	fetch := cache.inflight
+	if fetch == nil {
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		fetch = &capabilitiesFetch{done: make(chan struct{}), cancel: cancel}
+		cache.inflight = fetch
+		go c.runCapabilitiesFetch(fetchCtx, fetch)
+	}
+	fetch.waiters++
+	cache.mu.Unlock()
+
+	select {
+	case <-fetch.done:
+	case <-ctx.Done():
+		cache.mu.Lock()
+		fetch.waiters--
+		if fetch.waiters == 0 && cache.inflight == fetch {
+			cache.inflight = nil
+			fetch.cancel()
+		}
+		cache.mu.Unlock()
+		return nil, ctx.Err()
+	}
+
+	if fetch.err != nil {
+		return nil, fetch.err
+	}
+	return copyCapabilities(fetch.caps), nil
+}
+
+// runCapabilitiesFetch performs a shared capabilities fetch, stores the
+// result in the cache, and releases the callers waiting on fetch.
+func (c *Client) runCapabilitiesFetch(ctx context.Context, fetch *capabilitiesFetch) {
+	defer fetch.cancel()
+	caps, err := c.fetchCapabilities(ctx, nil)
+
+	cache := c.capabilities
+	cache.mu.Lock()
+	fetch.caps, fetch.err = caps, err
+	if err == nil && cache.ttl > 0 {
+		cache.caps = caps
+		cache.expiresAt = c.clock.Now().Add(cache.ttl)
+	}
+	// An abandoned fetch may already have been replaced by a newer one
+	if cache.inflight == fetch {
+		cache.inflight = nil
+	}
+	cache.mu.Unlock()
+	close(fetch.done)
+}
+
+// copyCapabilities returns a copy of caps so callers cannot modify the cache.
+func copyCapabilities(caps []ModelCapabilities) []ModelCapabilities {
+	if caps == nil {
+		return nil
+	}
+	return append([]ModelCapabilities(nil), caps...)
+}
+
+// fetchCapabilities requests capabilities from the server.
+func (c *Client) fetchCapabilities(ctx context.Context, opts *RequestOptions) ([]ModelCapabilities, error) {
 	c.log(ctx, LogLevelDebug, "getting model capabilities")
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "GET",
-		Path:   "/v1/capabilities",
+		Operation: "zaguan.GetCapabilities",
+		Method:    "GET",
+		Path:      "/v1/capabilities",
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp CapabilitiesResponse
@@ -124,7 +301,7 @@ func (c *Client) GetCapabilities(ctx context.Context, opts *RequestOptions) ([]M
 	}
 
 	// Handle both response formats (map or array)
-	var capabilities []ModelCapabilities
+	capabilities := []ModelCapabilities{}
 	if len(resp.Models) > 0 {
 		capabilities = resp.Models
 	} else if len(resp.Capabilities) > 0 {
@@ -141,6 +318,8 @@ func (c *Client) GetCapabilities(ctx context.Context, opts *RequestOptions) ([]M
 }
 
 // GetModelCapabilities retrieves capability information for a specific model.
+// If no model_id matches exactly, it falls back to the base-name and prefix
+// matching described on ResolveModel.
 //
 // Example:
 //
@@ -158,9 +337,114 @@ func (c *Client) GetModelCapabilities(ctx context.Context, modelID string, opts
 	}
 
 	// Find the specific model
-	for _, cap := range caps {
-		if cap.ModelID == modelID {
-			return &cap, nil
+	return resolveModel(caps, modelID)
+}
+
+// ResolveModel finds the capabilities for query, which may be an exact model
+// ID or a looser name for one. It tries, in order:
+//
+//   - an exact model_id match
+//   - a match on the name without the provider, e.g. "gpt-4o" for
+//     "openai/gpt-4o"
+//   - a dated or aliased variant of query, e.g. "anthropic/claude-3-5-sonnet"
+//     for "anthropic/claude-3-5-sonnet-20241022"
+//   - the base model that query is a variant of, e.g. "openai/gpt-4o" for
+//     "openai/gpt-4o-2024-08-06"
+//
+// Variants differ only by a "-YYYYMMDD", "-YYYY-MM-DD" or "-latest" suffix;
+// other suffixes name different models, so "openai/gpt-4o-mini" never
+// resolves to "openai/gpt-4o".
+//
+// If a step matches more than one model, ResolveModel returns an error listing
+// the candidates. GetModelCapabilities uses the same resolution.
+//
+// Example:
+//
+//	cap, err := client.ResolveModel(ctx, "anthropic/claude-3-5-sonnet", nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println("Using", cap.ModelID)
+func (c *Client) ResolveModel(ctx context.Context, query string, opts *RequestOptions) (ModelCapabilities, error) {
+	cap, err := c.GetModelCapabilities(ctx, query, opts)
+	if err != nil {
+		return ModelCapabilities{}, err
+	}
+	return *cap, nil
+}
+
+// trimVersionSuffix removes a trailing "-YYYYMMDD", "-YYYY-MM-DD" or
+// "-latest" from id. The second return value reports whether one was removed.
+func trimVersionSuffix(id string) (string, bool) {
+	if base, ok := strings.CutSuffix(id, "-latest"); ok {
+		return base, true
+	}
+	for _, layout := range []string{"20060102", "2006-01-02"} {
+		if len(id) <= len(layout)+1 {
+			continue
+		}
+		cut := len(id) - len(layout) - 1
+		if id[cut] != '-' {
+			continue
+		}
+		if _, err := time.Parse(layout, id[cut+1:]); err == nil {
+			return id[:cut], true
+		}
+	}
+	return id, false
+}
+
+// resolveModel implements the lookup described on ResolveModel.
+func resolveModel(caps []ModelCapabilities, query string) (*ModelCapabilities, error) {
+	if query != "" {
+		for i := range caps {
+			if caps[i].ModelID == query {
+				cap := caps[i]
+				return &cap, nil
+			}
+		}
+
+		// Without a provider, compare against the name after it
+		name := func(id string) string { return id }
+		if !strings.Contains(query, "/") {
+			name = func(id string) string { return id[strings.LastIndex(id, "/")+1:] }
+		}
+
+		// Only date and alias suffixes make a variant, so that e.g.
+		// "gpt-4o-mini" never resolves to "gpt-4o"
+		queryBase, queryVersioned := trimVersionSuffix(query)
+
+		var base, variants, bases []int
+		for i := range caps {
+			id := name(caps[i].ModelID)
+			idBase, idVersioned := trimVersionSuffix(id)
+			switch {
+			case id == query:
+				base = append(base, i)
+			case idVersioned && idBase == query:
+				variants = append(variants, i)
+			case queryVersioned && id == queryBase:
+				bases = append(bases, i)
+			}
+		}
+
+		for _, matches := range [][]int{base, variants, bases} {
+			switch len(matches) {
+			case 0:
+				continue
+			case 1:
+				cap := caps[matches[0]]
+				return &cap, nil
+			}
+			candidates := make([]string, len(matches))
+			for j, i := range matches {
+				candidates[j] = caps[i].ModelID
+			}
+			return nil, &APIError{
+				StatusCode: 404,
+				Message:    fmt.Sprintf("model %q is ambiguous; candidates: %s", query, strings.Join(candidates, ", ")),
+				Type:       "not_found",
+			}
 		}
 	}
 
@@ -203,3 +487,234 @@ func (c *Client) SupportsReasoning(ctx context.Context, modelID string, opts *Re
 	}
 	return cap.SupportsReasoning
 }
+
+// SupportsStreaming checks if a model supports streaming responses.
+//
+// Example:
+//
+//	if client.SupportsStreaming(ctx, "openai/gpt-4o", nil) {
+//		stream, err := client.ChatStream(ctx, req, nil)
+//		// ...
+//	}
+func (c *Client) SupportsStreaming(ctx context.Context, modelID string, opts *RequestOptions) bool {
+	cap, err := c.GetModelCapabilities(ctx, modelID, opts)
+	if err != nil {
+		return false
+	}
+	return cap.SupportsStreaming
+}
+
+// SupportsAudio checks if a model supports audio input or output, either
+// through its audio flags or an "audio" entry in its modalities.
+func (c *Client) SupportsAudio(ctx context.Context, modelID string, opts *RequestOptions) bool {
+	cap, err := c.GetModelCapabilities(ctx, modelID, opts)
+	if err != nil {
+		return false
+	}
+	return cap.SupportsAudioInput || cap.SupportsAudioOutput || containsString(cap.Modalities, "audio")
+}
+
+// SupportsModality checks if a model lists modality (e.g. "image" or
+// "audio") among its modalities.
+//
+// Example:
+//
+//	if client.SupportsModality(ctx, "google/gemini-2.0-flash", "image", nil) {
+//		// Attach the screenshot
+//	}
+func (c *Client) SupportsModality(ctx context.Context, modelID, modality string, opts *RequestOptions) bool {
+	cap, err := c.GetModelCapabilities(ctx, modelID, opts)
+	if err != nil {
+		return false
+	}
+	return containsString(cap.Modalities, modality)
+}
+
+// HasFeature checks if a model lists feature (e.g. "structured_outputs" or
+// "prompt_caching") among its features.
+//
+// Example:
+//
+//	if client.HasFeature(ctx, "openai/gpt-4o", "structured_outputs", nil) {
+//		req.ResponseFormat = zaguansdk.ResponseFormatJSONSchema("answer", schema, true)
+//	}
+func (c *Client) HasFeature(ctx context.Context, modelID, feature string, opts *RequestOptions) bool {
+	cap, err := c.GetModelCapabilities(ctx, modelID, opts)
+	if err != nil {
+		return false
+	}
+	return containsString(cap.Features, feature)
+}
+
+// precheckStreaming returns a *ValidationError if the capabilities for
+// modelID report that it does not support streaming. Lookup failures are not
+// treated as unsupported, so the stream request proceeds as usual.
+func (c *Client) precheckStreaming(ctx context.Context, modelID string, opts *RequestOptions) error {
+	cap, err := c.GetModelCapabilities(ctx, modelID, opts)
+	if err != nil {
+		c.log(ctx, LogLevelDebug, "skipping streaming support check", "model_id", modelID, "error", err)
+		return nil
+	}
+	if !cap.SupportsStreaming {
+		return &ValidationError{
+			Field:   "stream",
+			Message: fmt.Sprintf("model %s does not support streaming; use Chat instead", modelID),
+		}
+	}
+	return nil
+}
+
+// precheckAudioOutput returns a *ValidationError if the capabilities for
+// modelID report that it does not support audio output. As with
+// precheckStreaming, lookup failures let the request proceed.
+func (c *Client) precheckAudioOutput(ctx context.Context, modelID string, opts *RequestOptions) error {
+	cap, err := c.GetModelCapabilities(ctx, modelID, opts)
+	if err != nil {
+		c.log(ctx, LogLevelDebug, "skipping audio output support check", "model_id", modelID, "error", err)
+		return nil
+	}
+	if !cap.SupportsAudioOutput {
+		return &ValidationError{
+			Field:   "modalities",
+			Message: fmt.Sprintf("model %s does not support audio output", modelID),
+		}
+	}
+	return nil
+}
+
+// EstimateCostFromCapabilities computes the cost in USD of usage at the
+// per-token rates reported in cap.
+//
+// Reasoning tokens are part of CompletionTokens; when cap reports a
+// ReasoningCostPer1M they are billed at that rate and the remaining
+// completion tokens at OutputCostPer1M. Cached prompt tokens are billed at
+// InputCostPer1M, since capabilities do not report a cache discount, so the
+// estimate is an upper bound for cached requests.
+//
+// Example:
+//
+//	cost := zaguansdk.EstimateCostFromCapabilities(*cap, resp.Usage)
+//	fmt.Printf("cost: $%.6f\n", cost)
+func EstimateCostFromCapabilities(cap ModelCapabilities, usage Usage) float64 {
+	outputTokens := usage.CompletionTokens
+	var reasoningCost float64
+	if cap.ReasoningCostPer1M > 0 && usage.HasReasoningTokens() {
+		reasoningTokens := usage.CompletionTokensDetails.ReasoningTokens
+		if reasoningTokens > outputTokens {
+			reasoningTokens = outputTokens
+		}
+		outputTokens -= reasoningTokens
+		reasoningCost = float64(reasoningTokens) * cap.ReasoningCostPer1M / 1e6
+	}
+
+	inputCost := float64(usage.PromptTokens) * cap.InputCostPer1M / 1e6
+	outputCost := float64(outputTokens) * cap.OutputCostPer1M / 1e6
+	return inputCost + outputCost + reasoningCost
+}
+
+// EstimateCost computes the cost in USD of usage for a model, using the
+// rates reported by its capabilities. See EstimateCostFromCapabilities for
+// how reasoning and cached tokens are counted.
+//
+// Example:
+//
+//	cost, err := client.EstimateCost(ctx, "openai/gpt-4o", resp.Usage, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Printf("cost: $%.6f\n", cost)
+func (c *Client) EstimateCost(ctx context.Context, modelID string, usage Usage, opts *RequestOptions) (float64, error) {
+	cap, err := c.GetModelCapabilities(ctx, modelID, opts)
+	if err != nil {
+		return 0, err
+	}
+	return EstimateCostFromCapabilities(*cap, usage), nil
+}
+
+// WouldExceedContext reports whether req would exceed its model's context
+// window: the input tokens counted by CountTokens plus MaxTokens, compared
+// with the MaxContextTokens reported by the model's capabilities.
+//
+// The returned headroom is the number of tokens left in the window after the
+// request, and is negative when the window would be exceeded. An error is
+// returned if either lookup fails or the model does not report a context
+// window.
+//
+// Example:
+//
+//	exceeds, headroom, err := client.WouldExceedContext(ctx, req, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if exceeds {
+//		log.Printf("request is %d tokens over the context window", -headroom)
+//	}
+func (c *Client) WouldExceedContext(ctx context.Context, req MessagesRequest, opts *RequestOptions) (bool, int, error) {
+	cap, err := c.GetModelCapabilities(ctx, req.Model, opts)
+	if err != nil {
+		return false, 0, err
+	}
+	if cap.MaxContextTokens <= 0 {
+		return false, 0, fmt.Errorf("model %s does not report a context window", req.Model)
+	}
+
+	count, err := c.CountTokens(ctx, CountTokensRequest{
+		Model:    req.Model,
+		Messages: req.Messages,
+		System:   req.System,
+		Tools:    req.Tools,
+	}, opts)
+	if err != nil {
+		return false, 0, err
+	}
+
+	headroom := cap.MaxContextTokens - count.InputTokens - req.MaxTokens
+	return headroom < 0, headroom, nil
+}
+
+// AudioVoices returns the voices supported by a text-to-speech model.
+//
+// Example:
+//
+//	voices, err := client.AudioVoices(ctx, "openai/tts-1", nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(voices)
+func (c *Client) AudioVoices(ctx context.Context, modelID string, opts *RequestOptions) ([]string, error) {
+	cap, err := c.GetModelCapabilities(ctx, modelID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return cap.AudioVoices, nil
+}
+
+// AudioFormats returns the audio output formats supported by a text-to-speech model.
+func (c *Client) AudioFormats(ctx context.Context, modelID string, opts *RequestOptions) ([]string, error) {
+	cap, err := c.GetModelCapabilities(ctx, modelID, opts)
+	if err != nil {
+		return nil, err
+	}
+	return cap.AudioFormats, nil
+}
+
+// ValidateSpeechRequest checks the request's voice and response format
+// against the capabilities reported for req.Model.
+//
+// Call it before CreateSpeech to catch unsupported voices or formats without
+// spending a request on the provider. Returns a *ValidationError for
+// unsupported values, or the capabilities lookup error.
+//
+// Example:
+//
+//	req := zaguansdk.AudioSpeechRequest{Model: "openai/tts-1", Input: "Hi", Voice: "nova"}
+//	if err := client.ValidateSpeechRequest(ctx, &req, nil); err != nil {
+//		log.Fatal(err)
+//	}
+func (c *Client) ValidateSpeechRequest(ctx context.Context, req *AudioSpeechRequest, opts *RequestOptions) error {
+	cap, err := c.GetModelCapabilities(ctx, req.Model, opts)
+	if err != nil {
+		return err
+	}
+	return cap.ValidateSpeechRequest(req)
+}
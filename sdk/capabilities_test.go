@@ -2,8 +2,14 @@ package zaguansdk
 
 import (
 	"context"
+	"errors"
+	"math"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
 )
@@ -144,6 +150,102 @@ func TestClient_GetModelCapabilities(t *testing.T) {
 	}
 }
 
+func TestClient_ResolveModel(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+				"models": [
+					{"model_id": "openai/gpt-4o"},
+					{"model_id": "openai/gpt-4o-mini"},
+					{"model_id": "azure/gpt-4o-mini"},
+					{"model_id": "anthropic/claude-3-5-sonnet-20241022"},
+					{"model_id": "anthropic/claude-3-haiku-20240307"},
+					{"model_id": "anthropic/claude-3-haiku-20240229"}
+				]
+			}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr string
+	}{
+		{name: "exact", query: "openai/gpt-4o", want: "openai/gpt-4o"},
+		{name: "exact beats prefix", query: "openai/gpt-4o-mini", want: "openai/gpt-4o-mini"},
+		{name: "without provider", query: "gpt-4o", want: "openai/gpt-4o"},
+		{name: "dated variant", query: "anthropic/claude-3-5-sonnet", want: "anthropic/claude-3-5-sonnet-20241022"},
+		{name: "dated variant without provider", query: "claude-3-5-sonnet", want: "anthropic/claude-3-5-sonnet-20241022"},
+		{name: "base of dated query", query: "openai/gpt-4o-2024-08-06", want: "openai/gpt-4o"},
+		{name: "longest base", query: "openai/gpt-4o-mini-2024-07-18", want: "openai/gpt-4o-mini"},
+		{
+			name:    "ambiguous variants",
+			query:   "anthropic/claude-3-haiku",
+			wantErr: `candidates: anthropic/claude-3-haiku-20240307, anthropic/claude-3-haiku-20240229`,
+		},
+		{
+			name:    "ambiguous providers",
+			query:   "gpt-4o-mini",
+			wantErr: `candidates: openai/gpt-4o-mini, azure/gpt-4o-mini`,
+		},
+		{name: "latest alias of base", query: "openai/gpt-4o-latest", want: "openai/gpt-4o"},
+		{name: "non-date suffix is a different model", query: "openai/gpt-4o-audio-preview", wantErr: "model not found"},
+		{name: "not found", query: "google/gemini-pro", wantErr: "model not found"},
+		{name: "empty", query: "", wantErr: "model not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cap, err := client.ResolveModel(context.Background(), tt.query, nil)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveModel(%q) error = %v, want containing %q", tt.query, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveModel(%q) error = %v", tt.query, err)
+			}
+			if cap.ModelID != tt.want {
+				t.Errorf("ResolveModel(%q) = %s, want %s", tt.query, cap.ModelID, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_GetModelCapabilities_NoSiblingFallback(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"models": [{"model_id": "openai/gpt-4o", "supports_vision": true}]}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	// gpt-4o-mini is a different model, not a variant of gpt-4o
+	if cap, err := client.GetModelCapabilities(context.Background(), "openai/gpt-4o-mini", nil); err == nil {
+		t.Errorf("GetModelCapabilities(gpt-4o-mini) = %s, want not found", cap.ModelID)
+	}
+	if client.SupportsVision(context.Background(), "openai/gpt-4o-mini", nil) {
+		t.Error("SupportsVision(gpt-4o-mini) should not use gpt-4o's capabilities")
+	}
+}
+
 func TestClient_SupportsVision(t *testing.T) {
 	mockServer := testutil.NewMockServer(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -229,6 +331,625 @@ func TestClient_SupportsReasoning(t *testing.T) {
 	}
 }
 
+func TestClient_SupportsStreaming(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+				"models": [
+					{"model_id": "openai/gpt-4o", "supports_streaming": true},
+					{"model_id": "openai/o1-pro", "supports_streaming": false}
+				]
+			}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	if !client.SupportsStreaming(context.Background(), "openai/gpt-4o", nil) {
+		t.Error("SupportsStreaming() should return true for gpt-4o")
+	}
+	if client.SupportsStreaming(context.Background(), "openai/o1-pro", nil) {
+		t.Error("SupportsStreaming() should return false for o1-pro")
+	}
+	if client.SupportsStreaming(context.Background(), "unknown/model", nil) {
+		t.Error("SupportsStreaming() should return false for an unknown model")
+	}
+}
+
+func TestClient_ModalityAndFeaturePredicates(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+				"models": [
+					{"model_id": "openai/gpt-4o-audio-preview", "supports_audio_input": true, "modalities": ["text"]},
+					{"model_id": "google/gemini-2.0-flash", "modalities": ["text", "image", "audio"], "features": ["structured_outputs", "grounding"]},
+					{"model_id": "openai/gpt-4o", "modalities": ["text", "image"], "features": ["structured_outputs"]}
+				]
+			}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"audio flag", client.SupportsAudio(ctx, "openai/gpt-4o-audio-preview", nil), true},
+		{"audio modality", client.SupportsAudio(ctx, "google/gemini-2.0-flash", nil), true},
+		{"no audio", client.SupportsAudio(ctx, "openai/gpt-4o", nil), false},
+		{"unknown model audio", client.SupportsAudio(ctx, "unknown/model", nil), false},
+		{"image modality", client.SupportsModality(ctx, "openai/gpt-4o", "image", nil), true},
+		{"missing modality", client.SupportsModality(ctx, "openai/gpt-4o", "audio", nil), false},
+		{"unknown model modality", client.SupportsModality(ctx, "unknown/model", "text", nil), false},
+		{"feature present", client.HasFeature(ctx, "google/gemini-2.0-flash", "grounding", nil), true},
+		{"feature absent", client.HasFeature(ctx, "openai/gpt-4o", "grounding", nil), false},
+		{"unknown model feature", client.HasFeature(ctx, "unknown/model", "structured_outputs", nil), false},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestClient_ChatStream_CheckStreamingSupport(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/capabilities" {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{
+					"models": [
+						{"model_id": "openai/gpt-4o", "supports_streaming": true},
+						{"model_id": "openai/o1-pro", "supports_streaming": false}
+					]
+				}`))
+				return
+			}
+			testutil.StreamingHandler([]string{testutil.ChatStreamEventFixture("Hi")})(w, r)
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL:               mockServer.URL(),
+		APIKey:                "test-key",
+		CheckStreamingSupport: true,
+	})
+
+	stream := func(model string) error {
+		s, err := client.ChatStream(context.Background(), ChatRequest{
+			Model:    model,
+			Messages: []Message{{Role: "user", Content: "Hello"}},
+		}, nil)
+		if err == nil {
+			s.Close()
+		}
+		return err
+	}
+
+	if err := stream("openai/gpt-4o"); err != nil {
+		t.Errorf("ChatStream() error = %v for a streaming model", err)
+	}
+
+	err := stream("openai/o1-pro")
+	valErr, ok := err.(*ValidationError)
+	if !ok || valErr.Field != "stream" {
+		t.Errorf("ChatStream() error = %v, want a stream ValidationError", err)
+	}
+
+	// Models missing from the capabilities are not blocked
+	if err := stream("unknown/model"); err != nil {
+		t.Errorf("ChatStream() error = %v for an unknown model", err)
+	}
+}
+
+func TestClient_Chat_AudioOutputSupport(t *testing.T) {
+	var lookups int
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/v1/capabilities" {
+				lookups++
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{
+					"models": [
+						{"model_id": "openai/gpt-4o-audio-preview", "supports_audio_output": true},
+						{"model_id": "openai/gpt-4o-mini", "supports_audio_output": false}
+					]
+				}`))
+				return
+			}
+			testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL:                 mockServer.URL(),
+		APIKey:                  "test-key",
+		CheckAudioOutputSupport: true,
+	})
+
+	chat := func(model string) error {
+		_, err := client.Chat(context.Background(), ChatRequest{
+			Model:      model,
+			Messages:   []Message{{Role: "user", Content: "Hello"}},
+			Modalities: []string{"text", "audio"},
+			Audio:      &AudioConfig{Voice: "alloy", Format: "wav"},
+		}, nil)
+		return err
+	}
+
+	if err := chat("openai/gpt-4o-audio-preview"); err != nil {
+		t.Errorf("Chat() error = %v for an audio model", err)
+	}
+
+	err := chat("openai/gpt-4o-mini")
+	valErr, ok := err.(*ValidationError)
+	if !ok || valErr.Field != "modalities" {
+		t.Errorf("Chat() error = %v, want a modalities ValidationError", err)
+	}
+
+	// Models missing from the capabilities are not blocked
+	if err := chat("unknown/model"); err != nil {
+		t.Errorf("Chat() error = %v for an unknown model", err)
+	}
+
+	// Without CheckAudioOutputSupport, no capabilities lookup is made
+	unchecked := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+	lookups = 0
+	if _, err := unchecked.Chat(context.Background(), ChatRequest{
+		Model:      "openai/gpt-4o-mini",
+		Messages:   []Message{{Role: "user", Content: "Hello"}},
+		Modalities: []string{"text", "audio"},
+		Audio:      &AudioConfig{Voice: "alloy", Format: "wav"},
+	}, nil); err != nil {
+		t.Errorf("Chat() error = %v without CheckAudioOutputSupport", err)
+	}
+	if lookups != 0 {
+		t.Errorf("capabilities lookups = %d, want 0 without CheckAudioOutputSupport", lookups)
+	}
+}
+
+func TestEstimateCostFromCapabilities(t *testing.T) {
+	cap := ModelCapabilities{
+		ModelID:            "openai/o3",
+		InputCostPer1M:     2.0,
+		OutputCostPer1M:    8.0,
+		ReasoningCostPer1M: 10.0,
+	}
+
+	tests := []struct {
+		name  string
+		cap   ModelCapabilities
+		usage Usage
+		want  float64
+	}{
+		{
+			name:  "prompt and completion",
+			cap:   cap,
+			usage: Usage{PromptTokens: 1000, CompletionTokens: 500},
+			// 1000 * 2 / 1M + 500 * 8 / 1M
+			want: 0.006,
+		},
+		{
+			name: "reasoning tokens at reasoning rate",
+			cap:  cap,
+			usage: Usage{
+				PromptTokens:            1000,
+				CompletionTokens:        500,
+				CompletionTokensDetails: &TokenDetails{ReasoningTokens: 200},
+			},
+			// 1000 * 2 / 1M + 300 * 8 / 1M + 200 * 10 / 1M
+			want: 0.0064,
+		},
+		{
+			name: "reasoning tokens without reasoning rate",
+			cap:  ModelCapabilities{InputCostPer1M: 2.0, OutputCostPer1M: 8.0},
+			usage: Usage{
+				PromptTokens:            1000,
+				CompletionTokens:        500,
+				CompletionTokensDetails: &TokenDetails{ReasoningTokens: 200},
+			},
+			want: 0.006,
+		},
+		{
+			name: "cached tokens at input rate",
+			cap:  cap,
+			usage: Usage{
+				PromptTokens:        1000,
+				PromptTokensDetails: &TokenDetails{CachedTokens: 800},
+			},
+			want: 0.002,
+		},
+		{
+			name:  "no rates",
+			cap:   ModelCapabilities{},
+			usage: Usage{PromptTokens: 1000, CompletionTokens: 500},
+			want:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := EstimateCostFromCapabilities(tt.cap, tt.usage)
+			if math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("EstimateCostFromCapabilities() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_EstimateCost(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+				"models": [
+					{"model_id": "openai/gpt-4o", "input_cost_per_1m": 2.5, "output_cost_per_1m": 10}
+				]
+			}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	cost, err := client.EstimateCost(context.Background(), "openai/gpt-4o", Usage{
+		PromptTokens:     2000,
+		CompletionTokens: 1000,
+	}, nil)
+	if err != nil {
+		t.Fatalf("EstimateCost() error = %v", err)
+	}
+	// 2000 * 2.5 / 1M + 1000 * 10 / 1M
+	if math.Abs(cost-0.015) > 1e-12 {
+		t.Errorf("EstimateCost() = %v, want 0.015", cost)
+	}
+
+	if _, err := client.EstimateCost(context.Background(), "unknown/model", Usage{}, nil); err == nil {
+		t.Error("EstimateCost() should fail for an unknown model")
+	}
+}
+
+func TestClient_WouldExceedContext(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/v1/capabilities":
+				w.Write([]byte(`{
+					"models": [
+						{"model_id": "anthropic/claude-3-5-sonnet", "max_context_tokens": 200000},
+						{"model_id": "anthropic/claude-unknown"}
+					]
+				}`))
+			case "/v1/messages/count_tokens":
+				w.Write([]byte(`{"input_tokens": 150000}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	tests := []struct {
+		name         string
+		maxTokens    int
+		wantExceeds  bool
+		wantHeadroom int
+	}{
+		{name: "fits", maxTokens: 8192, wantExceeds: false, wantHeadroom: 41808},
+		{name: "exceeds", maxTokens: 64000, wantExceeds: true, wantHeadroom: -14000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exceeds, headroom, err := client.WouldExceedContext(context.Background(), MessagesRequest{
+				Model:     "anthropic/claude-3-5-sonnet",
+				MaxTokens: tt.maxTokens,
+				Messages:  []AnthropicMessage{{Role: "user", Content: "Summarize this book."}},
+			}, nil)
+			if err != nil {
+				t.Fatalf("WouldExceedContext() error = %v", err)
+			}
+			if exceeds != tt.wantExceeds || headroom != tt.wantHeadroom {
+				t.Errorf("WouldExceedContext() = %v, %d, want %v, %d", exceeds, headroom, tt.wantExceeds, tt.wantHeadroom)
+			}
+		})
+	}
+
+	// A model without a reported context window cannot be checked
+	_, _, err := client.WouldExceedContext(context.Background(), MessagesRequest{
+		Model:     "anthropic/claude-unknown",
+		MaxTokens: 1024,
+		Messages:  []AnthropicMessage{{Role: "user", Content: "Hello"}},
+	}, nil)
+	if err == nil {
+		t.Error("WouldExceedContext() should fail when the context window is unknown")
+	}
+}
+
+func TestClient_CapabilitiesCache(t *testing.T) {
+	var fetches atomic.Int32
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"models": [{"model_id": "openai/gpt-4o", "supports_vision": true}]}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if !client.SupportsVision(ctx, "openai/gpt-4o", nil) {
+			t.Fatal("SupportsVision() should return true for gpt-4o")
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d after cached lookups, want 1", got)
+	}
+
+	// Modifying returned capabilities does not affect the cache
+	caps, _ := client.GetCapabilities(ctx, nil)
+	caps[0].SupportsVision = false
+	if !client.SupportsVision(ctx, "openai/gpt-4o", nil) {
+		t.Error("cache was modified through the returned slice")
+	}
+
+	if err := client.RefreshCapabilities(ctx); err != nil {
+		t.Fatalf("RefreshCapabilities() error = %v", err)
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d after RefreshCapabilities, want 2", got)
+	}
+
+	client.ClearCapabilitiesCache()
+	client.SupportsTools(ctx, "openai/gpt-4o", nil)
+	if got := fetches.Load(); got != 3 {
+		t.Errorf("fetches = %d after ClearCapabilitiesCache, want 3", got)
+	}
+
+	// API key overrides bypass the cache
+	client.GetCapabilities(ctx, WithAPIKey("other-key"))
+	if got := fetches.Load(); got != 4 {
+		t.Errorf("fetches = %d after API key override, want 4", got)
+	}
+}
+
+func TestClient_CapabilitiesCache_TTL(t *testing.T) {
+	var fetches atomic.Int32
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"models": [{"model_id": "openai/gpt-4o"}]}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	ctx := context.Background()
+
+	expiring := NewClient(Config{
+		BaseURL:         mockServer.URL(),
+		APIKey:          "test-key",
+		CapabilitiesTTL: 20 * time.Millisecond,
+	})
+	expiring.GetCapabilities(ctx, nil)
+	time.Sleep(40 * time.Millisecond)
+	expiring.GetCapabilities(ctx, nil)
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d after expiry, want 2", got)
+	}
+
+	fetches.Store(0)
+	uncached := NewClient(Config{
+		BaseURL:         mockServer.URL(),
+		APIKey:          "test-key",
+		CapabilitiesTTL: -1,
+	})
+	uncached.GetCapabilities(ctx, nil)
+	uncached.GetCapabilities(ctx, nil)
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d with caching disabled, want 2", got)
+	}
+}
+
+func TestClient_CapabilitiesCache_SharedFetch(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			<-release
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"models": [{"model_id": "openai/gpt-4o", "supports_tools": true}]}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make(chan bool, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- client.SupportsTools(context.Background(), "openai/gpt-4o", nil)
+		}()
+	}
+
+	// Give the callers time to queue behind the first fetch
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for ok := range results {
+		if !ok {
+			t.Error("SupportsTools() should return true for every caller")
+		}
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d for concurrent callers, want 1", got)
+	}
+}
+
+func TestClient_CapabilitiesCache_LeaderCancelled(t *testing.T) {
+	var fetches atomic.Int32
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			started <- struct{}{}
+			<-release
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"models": [{"model_id": "openai/gpt-4o", "supports_tools": true}]}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	// The caller that starts the fetch gives up first
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := client.GetCapabilities(leaderCtx, nil)
+		leaderErr <- err
+	}()
+	<-started
+
+	waiter := make(chan bool, 1)
+	go func() {
+		waiter <- client.SupportsTools(context.Background(), "openai/gpt-4o", nil)
+	}()
+	waitForCapabilitiesWaiters(t, client, 2)
+
+	cancel()
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader GetCapabilities() error = %v, want context.Canceled", err)
+	}
+
+	// The shared fetch carries on for the remaining caller
+	close(release)
+	if !<-waiter {
+		t.Error("SupportsTools() = false, want the shared fetch to survive the leader's cancellation")
+	}
+	if got := fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+}
+
+func TestClient_CapabilitiesCache_AbandonedFetch(t *testing.T) {
+	var fetches atomic.Int32
+	var hang atomic.Bool
+	hang.Store(true)
+	cancelled := make(chan struct{}, 1)
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fetches.Add(1)
+			if hang.Load() {
+				<-r.Context().Done()
+				cancelled <- struct{}{}
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"models": [{"model_id": "openai/gpt-4o", "supports_tools": true}]}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	// No Config.Timeout, so only the callers bound the fetch
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetCapabilities(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetCapabilities() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	// With no caller left waiting, the hung request is canceled
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("hung capabilities request was not canceled")
+	}
+
+	// Later callers start a fresh fetch instead of joining the hung one
+	hang.Store(false)
+	if !client.SupportsTools(context.Background(), "openai/gpt-4o", nil) {
+		t.Error("SupportsTools() = false, want true from a fresh fetch")
+	}
+	if got := fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2", got)
+	}
+}
+
+// waitForCapabilitiesWaiters waits until n callers are waiting on the
+// client's in-flight capabilities fetch.
+func waitForCapabilitiesWaiters(t *testing.T, client *Client, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		client.capabilities.mu.Lock()
+		fetch := client.capabilities.inflight
+		waiting := fetch != nil && fetch.waiters >= n
+		client.capabilities.mu.Unlock()
+		if waiting {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %d capabilities waiters", n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
 func TestCapabilitiesResponse_MapFormat(t *testing.T) {
 	// Test that we can handle the map format response
 	mockServer := testutil.NewMockServer(
@@ -261,3 +982,83 @@ func TestCapabilitiesResponse_MapFormat(t *testing.T) {
 		t.Errorf("GetCapabilities() returned %d capabilities, want 1", len(caps))
 	}
 }
+
+func TestClient_AudioVoicesAndFormats(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+				"models": [
+					{
+						"model_id": "openai/tts-1",
+						"supports_audio_output": true,
+						"audio_voices": ["alloy", "nova"],
+						"audio_formats": ["mp3", "opus"]
+					}
+				]
+			}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	voices, err := client.AudioVoices(context.Background(), "openai/tts-1", nil)
+	if err != nil {
+		t.Fatalf("AudioVoices() error = %v", err)
+	}
+	if len(voices) != 2 || voices[0] != "alloy" || voices[1] != "nova" {
+		t.Errorf("AudioVoices() = %v, want [alloy nova]", voices)
+	}
+
+	formats, err := client.AudioFormats(context.Background(), "openai/tts-1", nil)
+	if err != nil {
+		t.Fatalf("AudioFormats() error = %v", err)
+	}
+	if len(formats) != 2 || formats[0] != "mp3" || formats[1] != "opus" {
+		t.Errorf("AudioFormats() = %v, want [mp3 opus]", formats)
+	}
+
+	tests := []struct {
+		name      string
+		req       AudioSpeechRequest
+		wantField string
+	}{
+		{"supported", AudioSpeechRequest{Model: "openai/tts-1", Input: "Hi", Voice: "nova", ResponseFormat: "opus"}, ""},
+		{"default format", AudioSpeechRequest{Model: "openai/tts-1", Input: "Hi", Voice: "alloy"}, ""},
+		{"unsupported voice", AudioSpeechRequest{Model: "openai/tts-1", Input: "Hi", Voice: "shimmer"}, "voice"},
+		{"unsupported format", AudioSpeechRequest{Model: "openai/tts-1", Input: "Hi", Voice: "nova", ResponseFormat: "flac"}, "response_format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.ValidateSpeechRequest(context.Background(), &tt.req, nil)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("ValidateSpeechRequest() error = %v, want nil", err)
+				}
+				return
+			}
+			valErr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("ValidateSpeechRequest() error = %v, want *ValidationError", err)
+			}
+			if valErr.Field != tt.wantField {
+				t.Errorf("ValidationError.Field = %s, want %s", valErr.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestModelCapabilities_ValidateSpeechRequest_Unknown(t *testing.T) {
+	// Models that don't report voices or formats accept any value
+	mc := &ModelCapabilities{ModelID: "custom/tts"}
+	req := &AudioSpeechRequest{Model: "custom/tts", Input: "Hi", Voice: "anything", ResponseFormat: "ogg"}
+	if err := mc.ValidateSpeechRequest(req); err != nil {
+		t.Errorf("ValidateSpeechRequest() error = %v, want nil", err)
+	}
+}
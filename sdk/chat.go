@@ -1,5 +1,14 @@
 package zaguansdk
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
 // ChatRequest represents a request to the chat completions endpoint.
 //
 // This follows the OpenAI chat completions API format with Zaguan extensions.
@@ -22,6 +31,29 @@ type ChatRequest struct {
 	// Optional.
 	MaxTokens *int `json:"max_tokens,omitempty"`
 
+	// N is the number of choices to generate for each request (at least 1).
+	// Use ChatResponse.Contents to read every choice, e.g. for best-of-N
+	// sampling. Each choice is billed for its output tokens.
+	// Optional.
+	N *int `json:"n,omitempty"`
+
+	// Seed requests deterministic sampling: repeated requests with the same
+	// seed and parameters should return the same result. Determinism is
+	// best-effort and only holds while the backend is unchanged; compare
+	// responses with ChatResponse.SameBackend.
+	// Optional.
+	Seed *int `json:"seed,omitempty"`
+
+	// Logprobs requests the log probability of each output token, returned
+	// in each choice's Logprobs (see Choice.ParseLogprobs).
+	// Optional.
+	Logprobs *bool `json:"logprobs,omitempty"`
+
+	// TopLogprobs is the number of most likely alternatives (0 - 20) to
+	// return for each token. Requires Logprobs to be true.
+	// Optional.
+	TopLogprobs *int `json:"top_logprobs,omitempty"`
+
 	// TopP controls nucleus sampling (0.0 - 1.0).
 	// Alternative to temperature for controlling randomness.
 	// Optional.
@@ -67,14 +99,20 @@ type ChatRequest struct {
 
 	// ResponseFormat specifies the output format.
 	// Can be {"type": "text"}, {"type": "json_object"}, or {"type": "json_schema", "json_schema": {...}}
+	// Use ResponseFormatText, ResponseFormatJSONObject or
+	// ResponseFormatJSONSchema to build it.
 	// Optional.
 	ResponseFormat interface{} `json:"response_format,omitempty"`
 
 	// Modalities specifies input/output modalities (e.g., ["text", "audio"]).
+	// Requesting "audio" requires Audio with a voice and format, and fails
+	// with a *ValidationError if the model's capabilities report no audio
+	// output support.
 	// Optional.
 	Modalities []string `json:"modalities,omitempty"`
 
 	// Audio configuration for audio output (GPT-4o Audio).
+	// Only valid when Modalities includes "audio".
 	// Optional.
 	Audio *AudioConfig `json:"audio,omitempty"`
 
@@ -95,10 +133,15 @@ type ChatRequest struct {
 	ExtraBody map[string]interface{} `json:"extra_body,omitempty"`
 
 	// VirtualModelID specifies a virtual model alias.
+	// How it is sent depends on Config.VirtualModelMode; by default it is
+	// sent in the virtual_model_id field. In VirtualModelModeModel it
+	// replaces Model, which may then be left empty.
 	// Optional.
 	VirtualModelID string `json:"virtual_model_id,omitempty"`
 
 	// Metadata for application-specific tracking (not interpreted by CoreX).
+	// Non-string values are sent JSON-encoded as strings, or rejected if
+	// Config.StrictMetadata is set.
 	// Optional.
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 
@@ -171,6 +214,83 @@ type ImageURL struct {
 	Detail string `json:"detail,omitempty"`
 }
 
+// lowDetailMaxDimension is the largest image side that "low" detail can
+// represent without losing information; low detail downsamples to 512x512.
+const lowDetailMaxDimension = 512
+
+// ImageContentAuto creates an image_url content part, choosing the detail
+// level from the image dimensions.
+//
+// Images that fit within 512x512 use "low" detail, which costs a fixed, small
+// number of tokens without losing information. Larger images use "high"
+// detail. If either dimension is unknown (zero or negative), "auto" is used
+// and the provider decides.
+//
+// Example:
+//
+//	part := zaguansdk.ImageContentAuto("https://example.com/chart.png", 1920, 1080)
+func ImageContentAuto(url string, imageWidth, imageHeight int) ContentPart {
+	detail := "auto"
+	if imageWidth > 0 && imageHeight > 0 {
+		if imageWidth <= lowDetailMaxDimension && imageHeight <= lowDetailMaxDimension {
+			detail = "low"
+		} else {
+			detail = "high"
+		}
+	}
+
+	return ImageURLPart(url, detail)
+}
+
+// TextPart creates a text content part.
+func TextPart(text string) ContentPart {
+	return ContentPart{
+		Type: "text",
+		Text: text,
+	}
+}
+
+// ImageURLPart creates an image_url content part for an image URL.
+//
+// detail is "low", "high" or "auto"; an empty detail is omitted and the
+// provider default is used.
+func ImageURLPart(url, detail string) ContentPart {
+	return ContentPart{
+		Type: "image_url",
+		ImageURL: &ImageURL{
+			URL:    url,
+			Detail: detail,
+		},
+	}
+}
+
+// ImageBase64Part creates an image_url content part from raw image bytes,
+// encoded as a base64 data URI.
+//
+// Example:
+//
+//	data, _ := os.ReadFile("chart.png")
+//	part := zaguansdk.ImageBase64Part("image/png", data, "high")
+func ImageBase64Part(mimeType string, data []byte, detail string) ContentPart {
+	url := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	return ImageURLPart(url, detail)
+}
+
+// NewUserMessage creates a user message from content parts.
+//
+// Example:
+//
+//	msg := zaguansdk.NewUserMessage(
+//		zaguansdk.TextPart("What is in this image?"),
+//		zaguansdk.ImageURLPart("https://example.com/photo.jpg", "auto"),
+//	)
+func NewUserMessage(parts ...ContentPart) Message {
+	return Message{
+		Role:    "user",
+		Content: parts,
+	}
+}
+
 // InputAudio represents audio input.
 type InputAudio struct {
 	// Data is the base64-encoded audio data.
@@ -181,17 +301,105 @@ type InputAudio struct {
 	Format string `json:"format"`
 }
 
+// ToolErrorResult creates a tool message reporting that the tool call
+// identified by toolCallID failed with err.
+//
+// Example:
+//
+//	result, err := runTool(call.Function.Arguments)
+//	if err != nil {
+//		req.Messages = append(req.Messages, zaguansdk.ToolErrorResult(call.ID, err))
+//	}
+func ToolErrorResult(toolCallID string, err error) Message {
+	return Message{
+		Role:       "tool",
+		Content:    toolErrorText(err),
+		ToolCallID: toolCallID,
+	}
+}
+
+// toolErrorText formats a tool execution error for the model.
+func toolErrorText(err error) string {
+	if err == nil {
+		return "Error: tool execution failed"
+	}
+	return "Error: " + err.Error()
+}
+
 // AudioConfig represents audio output configuration.
 type AudioConfig struct {
 	// Voice is the voice to use for audio output.
-	// Values: "alloy", "echo", "fable", "onyx", "nova", "shimmer"
+	// Values: "alloy", "ash", "ballad", "coral", "echo", "fable", "onyx",
+	// "nova", "sage", "shimmer", "verse"
 	Voice string `json:"voice,omitempty"`
 
 	// Format is the audio output format.
-	// Values: "wav", "mp3", "opus", "aac", "flac", "pcm"
+	// Values: "wav", "mp3", "opus", "aac", "flac", "pcm", "pcm16"
 	Format string `json:"format,omitempty"`
 }
 
+// ChatResponseFormat is the typed form of ChatRequest.ResponseFormat.
+type ChatResponseFormat struct {
+	// Type is the response format type.
+	// Values: "text", "json_object", "json_schema"
+	Type string `json:"type"`
+
+	// JSONSchema is the schema the output must follow (for type="json_schema").
+	JSONSchema *JSONSchemaFormat `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaFormat describes a JSON Schema for structured outputs.
+type JSONSchemaFormat struct {
+	// Name is the schema name.
+	// Must contain only a-z, A-Z, 0-9, underscores and dashes.
+	Name string `json:"name"`
+
+	// Description explains what the response is for.
+	Description string `json:"description,omitempty"`
+
+	// Schema is the JSON Schema the response must follow.
+	Schema interface{} `json:"schema,omitempty"`
+
+	// Strict enables strict schema adherence.
+	Strict *bool `json:"strict,omitempty"`
+}
+
+// ResponseFormatText returns a response format requesting plain text output.
+func ResponseFormatText() interface{} {
+	return ChatResponseFormat{Type: "text"}
+}
+
+// ResponseFormatJSONObject returns a response format requesting a valid JSON
+// object, without enforcing a schema.
+func ResponseFormatJSONObject() interface{} {
+	return ChatResponseFormat{Type: "json_object"}
+}
+
+// ResponseFormatJSONSchema returns a response format requesting output that
+// follows schema. With strict set, the model adheres exactly to the schema.
+//
+// Example:
+//
+//	req.ResponseFormat = zaguansdk.ResponseFormatJSONSchema("weather", map[string]interface{}{
+//		"type": "object",
+//		"properties": map[string]interface{}{
+//			"city":        map[string]interface{}{"type": "string"},
+//			"temperature": map[string]interface{}{"type": "number"},
+//		},
+//		"required":             []string{"city", "temperature"},
+//		"additionalProperties": false,
+//	}, true)
+func ResponseFormatJSONSchema(name string, schema interface{}, strict bool) interface{} {
+	return ChatResponseFormat{
+		Type: "json_schema",
+		JSONSchema: &JSONSchemaFormat{
+			Name:   name,
+			Schema: schema,
+			Strict: &strict,
+		},
+	}
+}
+
 // Tool represents a tool/function available to the model.
 type Tool struct {
 	// Type is the tool type (currently only "function").
@@ -218,6 +426,11 @@ type FunctionDefinition struct {
 
 // ToolCall represents a tool call made by the model.
 type ToolCall struct {
+	// Index is the position of this tool call in the response.
+	// Only present in streaming deltas, where it identifies which tool call
+	// a fragment belongs to.
+	Index *int `json:"index,omitempty"`
+
 	// ID is the unique identifier for this tool call.
 	ID string `json:"id"`
 
@@ -259,6 +472,10 @@ type ChatResponse struct {
 
 	// SystemFingerprint is a unique identifier for the backend configuration.
 	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// Citations lists the source URLs used by search-augmented models
+	// (e.g. Perplexity), in the order the answer refers to them.
+	Citations []string `json:"citations,omitempty"`
 }
 
 // Choice represents a completion choice.
@@ -276,8 +493,279 @@ type Choice struct {
 	// Values: "stop", "length", "tool_calls", "content_filter", "function_call"
 	FinishReason string `json:"finish_reason,omitempty"`
 
-	// Logprobs contains log probability information.
+	// Logprobs contains log probability information, when requested with
+	// ChatRequest.Logprobs. Use ParseLogprobs for a typed view.
 	Logprobs interface{} `json:"logprobs,omitempty"`
+
+	// ContentFilterResults reports the content filter verdict per category
+	// (e.g. "hate", "sexual", "violence", "self_harm"), when the provider
+	// includes it. It explains a "content_filter" FinishReason.
+	ContentFilterResults ContentFilterResults `json:"content_filter_results,omitempty"`
+}
+
+// LogProbs holds the token log probabilities of a choice.
+type LogProbs struct {
+	// Content lists the log probability of each content token.
+	Content []TokenLogprob `json:"content"`
+
+	// Refusal lists the log probability of each refusal token, if any.
+	Refusal []TokenLogprob `json:"refusal,omitempty"`
+}
+
+// TokenLogprob is the log probability of a single output token.
+type TokenLogprob struct {
+	// Token is the token text.
+	Token string `json:"token"`
+
+	// Logprob is the log probability of the token.
+	Logprob float64 `json:"logprob"`
+
+	// Bytes is the UTF-8 encoding of the token, or nil if it has none.
+	// Tokens can split multi-byte characters, so combine Bytes rather than
+	// Token to reconstruct the text exactly.
+	Bytes []int `json:"bytes"`
+
+	// TopLogprobs lists the most likely tokens at this position, as
+	// requested by ChatRequest.TopLogprobs.
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+// TopLogprob is one of the most likely tokens at a position.
+type TopLogprob struct {
+	// Token is the token text.
+	Token string `json:"token"`
+
+	// Logprob is the log probability of the token.
+	Logprob float64 `json:"logprob"`
+
+	// Bytes is the UTF-8 encoding of the token, or nil if it has none.
+	Bytes []int `json:"bytes"`
+}
+
+// ParseLogprobs decodes Logprobs into a LogProbs. It returns nil and no error
+// if the choice has no log probabilities.
+//
+// Example:
+//
+//	lp, err := resp.Choices[0].ParseLogprobs()
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, token := range lp.Content {
+//		fmt.Printf("%q %.3f\n", token.Token, token.Logprob)
+//	}
+func (c *Choice) ParseLogprobs() (*LogProbs, error) {
+	if c.Logprobs == nil {
+		return nil, nil
+	}
+
+	// Logprobs is decoded as a generic value; re-decode it into the typed form
+	raw, err := json.Marshal(c.Logprobs)
+	if err != nil {
+		return nil, err
+	}
+	var lp LogProbs
+	if err := json.Unmarshal(raw, &lp); err != nil {
+		return nil, fmt.Errorf("failed to parse logprobs: %w", err)
+	}
+	return &lp, nil
+}
+
+// ContentFilterResults maps content filter categories to their results.
+type ContentFilterResults map[string]ContentFilterResult
+
+// ContentFilterResult is the content filter verdict for a single category.
+type ContentFilterResult struct {
+	// Filtered is true if this category caused content to be filtered.
+	Filtered bool `json:"filtered"`
+
+	// Severity is the detected severity: "safe", "low", "medium", or "high".
+	// Empty for categories that are detected rather than graded.
+	Severity string `json:"severity,omitempty"`
+
+	// Detected reports whether the category was detected, for categories
+	// such as "jailbreak" or "protected_material_text" that have no severity.
+	Detected *bool `json:"detected,omitempty"`
+}
+
+// FilterResults returns the content filter results for this choice, or nil
+// if the provider did not report any.
+//
+// Example:
+//
+//	choice := resp.Choices[0]
+//	if choice.FinishReason == "content_filter" {
+//		fmt.Println("blocked for:", choice.FilterResults().Filtered())
+//	}
+func (c *Choice) FilterResults() ContentFilterResults {
+	if c == nil {
+		return nil
+	}
+	return c.ContentFilterResults
+}
+
+// Filtered returns the categories that caused content to be filtered, sorted
+// by name.
+func (r ContentFilterResults) Filtered() []string {
+	var categories []string
+	for category, result := range r {
+		if result.Filtered {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// FirstContent returns the text content of the first choice's message.
+//
+// Content is returned as-is when it is a string. When it is an array of
+// content parts, the text parts are concatenated. The second return value is
+// false if the response has no choices or the message has no text content.
+//
+// Example:
+//
+//	if text, ok := resp.FirstContent(); ok {
+//		fmt.Println(text)
+//	}
+func (r *ChatResponse) FirstContent() (string, bool) {
+	if len(r.Choices) == 0 || r.Choices[0].Message == nil {
+		return "", false
+	}
+	return contentText(r.Choices[0].Message.Content)
+}
+
+// contentText extracts the text of message content that is a string or an
+// array of content parts, concatenating text parts. The second return value
+// is false if the content has no text.
+func contentText(content interface{}) (string, bool) {
+	switch content := content.(type) {
+	case string:
+		return content, true
+	case []ContentPart:
+		var sb strings.Builder
+		found := false
+		for _, part := range content {
+			if part.Type == "text" {
+				sb.WriteString(part.Text)
+				found = true
+			}
+		}
+		return sb.String(), found
+	case []interface{}:
+		// Content parts decoded from JSON
+		var sb strings.Builder
+		found := false
+		for _, item := range content {
+			part, ok := item.(map[string]interface{})
+			if !ok || part["type"] != "text" {
+				continue
+			}
+			if text, ok := part["text"].(string); ok {
+				sb.WriteString(text)
+				found = true
+			}
+		}
+		return sb.String(), found
+	}
+	return "", false
+}
+
+// SameBackend reports whether r and other were served by the same backend
+// configuration, according to their SystemFingerprint. Seeded requests are
+// only expected to be reproducible when this is true. It returns false if
+// either response has no fingerprint.
+func (r *ChatResponse) SameBackend(other *ChatResponse) bool {
+	if other == nil || r.SystemFingerprint == "" {
+		return false
+	}
+	return r.SystemFingerprint == other.SystemFingerprint
+}
+
+// Contents returns the text content of every choice, in choice order. A
+// choice without text content contributes an empty string, so the result
+// lines up with Choices.
+//
+// Example:
+//
+//	n := 3
+//	resp, err := client.Chat(ctx, zaguansdk.ChatRequest{
+//		Model:    "openai/gpt-4o",
+//		Messages: messages,
+//		N:        &n,
+//	}, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for i, text := range resp.Contents() {
+//		fmt.Printf("Candidate %d: %s\n", i, text)
+//	}
+func (r *ChatResponse) Contents() []string {
+	contents := make([]string, len(r.Choices))
+	for i, choice := range r.Choices {
+		if choice.Message != nil {
+			contents[i], _ = contentText(choice.Message.Content)
+		}
+	}
+	return contents
+}
+
+// FirstToolCalls returns the tool calls of the first choice's message, or nil
+// if the response has no choices or the model made no tool calls.
+func (r *ChatResponse) FirstToolCalls() []ToolCall {
+	if len(r.Choices) == 0 || r.Choices[0].Message == nil {
+		return nil
+	}
+	return r.Choices[0].Message.ToolCalls
+}
+
+// MeanLogProb returns the mean token log probability of the first choice.
+//
+// Values closer to 0 indicate higher model confidence. The second return
+// value is false if the response has no token logprobs (logprobs were not
+// requested, or the provider does not support them).
+func (r *ChatResponse) MeanLogProb() (float64, bool) {
+	logprobs := r.tokenLogprobs()
+	if len(logprobs) == 0 {
+		return 0, false
+	}
+
+	var sum float64
+	for _, lp := range logprobs {
+		sum += lp
+	}
+	return sum / float64(len(logprobs)), true
+}
+
+// PerplexityScore returns the perplexity of the first choice, computed as
+// exp(-MeanLogProb).
+//
+// A perplexity of 1 means the model was certain of every token; higher values
+// indicate lower confidence. The second return value is false if the response
+// has no token logprobs.
+func (r *ChatResponse) PerplexityScore() (float64, bool) {
+	mean, ok := r.MeanLogProb()
+	if !ok {
+		return 0, false
+	}
+	return math.Exp(-mean), true
+}
+
+// tokenLogprobs extracts the per-token log probabilities of the first choice.
+func (r *ChatResponse) tokenLogprobs() []float64 {
+	if len(r.Choices) == 0 {
+		return nil
+	}
+	lp, err := r.Choices[0].ParseLogprobs()
+	if err != nil || lp == nil {
+		return nil
+	}
+
+	logprobs := make([]float64, len(lp.Content))
+	for i, token := range lp.Content {
+		logprobs[i] = token.Logprob
+	}
+	return logprobs
 }
 
 // Usage represents token usage information.
@@ -320,6 +808,55 @@ type TokenDetails struct {
 	RejectedPredictionTokens int `json:"rejected_prediction_tokens,omitempty"`
 }
 
+// Add returns the sum of u and other, field by field, including the token
+// details. Missing details count as zero; the result has details only if u
+// or other does.
+//
+// Example:
+//
+//	var total zaguansdk.Usage
+//	for _, resp := range responses {
+//		total = total.Add(resp.Usage)
+//	}
+func (u *Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:            u.PromptTokens + other.PromptTokens,
+		CompletionTokens:        u.CompletionTokens + other.CompletionTokens,
+		TotalTokens:             u.TotalTokens + other.TotalTokens,
+		PromptTokensDetails:     addTokenDetails(u.PromptTokensDetails, other.PromptTokensDetails),
+		CompletionTokensDetails: addTokenDetails(u.CompletionTokensDetails, other.CompletionTokensDetails),
+	}
+}
+
+// SumUsage returns the total of usages, as computed by Usage.Add.
+func SumUsage(usages ...Usage) Usage {
+	var total Usage
+	for _, u := range usages {
+		total = total.Add(u)
+	}
+	return total
+}
+
+// addTokenDetails returns the sum of a and b, treating nil as zero. It
+// returns nil if both are nil.
+func addTokenDetails(a, b *TokenDetails) *TokenDetails {
+	if a == nil && b == nil {
+		return nil
+	}
+	var sum TokenDetails
+	for _, d := range []*TokenDetails{a, b} {
+		if d == nil {
+			continue
+		}
+		sum.ReasoningTokens += d.ReasoningTokens
+		sum.CachedTokens += d.CachedTokens
+		sum.AudioTokens += d.AudioTokens
+		sum.AcceptedPredictionTokens += d.AcceptedPredictionTokens
+		sum.RejectedPredictionTokens += d.RejectedPredictionTokens
+	}
+	return &sum
+}
+
 // HasReasoningTokens returns true if reasoning tokens are present.
 func (u *Usage) HasReasoningTokens() bool {
 	return u.CompletionTokensDetails != nil && u.CompletionTokensDetails.ReasoningTokens > 0
@@ -1,6 +1,11 @@
 package zaguansdk
 
 import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -410,3 +415,497 @@ func TestChoice_WithDelta(t *testing.T) {
 		t.Error("Delta role not set")
 	}
 }
+
+func TestImageContentAuto(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		wantDetail    string
+	}{
+		{"small image", 256, 256, "low"},
+		{"at threshold", 512, 512, "low"},
+		{"wide image", 1024, 256, "high"},
+		{"large image", 1920, 1080, "high"},
+		{"unknown width", 0, 512, "auto"},
+		{"unknown dimensions", 0, 0, "auto"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			part := ImageContentAuto("https://example.com/image.png", tt.width, tt.height)
+			if part.Type != "image_url" {
+				t.Errorf("Type = %s, want image_url", part.Type)
+			}
+			if part.ImageURL == nil {
+				t.Fatal("ImageURL is nil")
+			}
+			if part.ImageURL.URL != "https://example.com/image.png" {
+				t.Errorf("URL = %s, want https://example.com/image.png", part.ImageURL.URL)
+			}
+			if part.ImageURL.Detail != tt.wantDetail {
+				t.Errorf("Detail = %s, want %s", part.ImageURL.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestNewUserMessage_ContentParts(t *testing.T) {
+	msg := NewUserMessage(
+		TextPart("What is in these images?"),
+		ImageURLPart("https://example.com/photo.jpg", "low"),
+		ImageBase64Part("image/png", []byte("png-bytes"), ""),
+	)
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `{"role":"user","content":[` +
+		`{"type":"text","text":"What is in these images?"},` +
+		`{"type":"image_url","image_url":{"url":"https://example.com/photo.jpg","detail":"low"}},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5nLWJ5dGVz"}}]}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestChatResponse_MeanLogProbAndPerplexity(t *testing.T) {
+	raw := `{
+		"id": "chatcmpl-1",
+		"choices": [{
+			"index": 0,
+			"message": {"role": "assistant", "content": "Hi there"},
+			"logprobs": {"content": [
+				{"token": "Hi", "logprob": -0.5},
+				{"token": " there", "logprob": -1.5}
+			]}
+		}]
+	}`
+
+	var resp ChatResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		t.Fatalf("unmarshal error = %v", err)
+	}
+
+	mean, ok := resp.MeanLogProb()
+	if !ok {
+		t.Fatal("MeanLogProb() ok = false, want true")
+	}
+	if math.Abs(mean-(-1.0)) > 1e-9 {
+		t.Errorf("MeanLogProb() = %f, want -1.0", mean)
+	}
+
+	perplexity, ok := resp.PerplexityScore()
+	if !ok {
+		t.Fatal("PerplexityScore() ok = false, want true")
+	}
+	if math.Abs(perplexity-math.E) > 1e-9 {
+		t.Errorf("PerplexityScore() = %f, want %f", perplexity, math.E)
+	}
+}
+
+func TestChatResponse_MeanLogProb_Missing(t *testing.T) {
+	tests := []struct {
+		name string
+		resp ChatResponse
+	}{
+		{"no choices", ChatResponse{}},
+		{"no logprobs", ChatResponse{Choices: []Choice{{Index: 0}}}},
+		{"empty content", ChatResponse{Choices: []Choice{{Logprobs: map[string]interface{}{"content": []interface{}{}}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := tt.resp.MeanLogProb(); ok {
+				t.Error("MeanLogProb() ok = true, want false")
+			}
+			if _, ok := tt.resp.PerplexityScore(); ok {
+				t.Error("PerplexityScore() ok = true, want false")
+			}
+		})
+	}
+}
+
+func TestChoice_ParseLogprobs(t *testing.T) {
+	data := `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hi!"},"logprobs":{"content":[` +
+		`{"token":"Hi","logprob":-0.0012,"bytes":[72,105],"top_logprobs":[` +
+		`{"token":"Hi","logprob":-0.0012,"bytes":[72,105]},{"token":"Hello","logprob":-6.9,"bytes":[72,101,108,108,111]}]},` +
+		`{"token":"!","logprob":-0.25,"bytes":[33],"top_logprobs":[]}],"refusal":null},"finish_reason":"stop"}]}`
+
+	var resp ChatResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	lp, err := resp.Choices[0].ParseLogprobs()
+	if err != nil {
+		t.Fatalf("ParseLogprobs() error = %v", err)
+	}
+	want := &LogProbs{Content: []TokenLogprob{
+		{
+			Token:   "Hi",
+			Logprob: -0.0012,
+			Bytes:   []int{72, 105},
+			TopLogprobs: []TopLogprob{
+				{Token: "Hi", Logprob: -0.0012, Bytes: []int{72, 105}},
+				{Token: "Hello", Logprob: -6.9, Bytes: []int{72, 101, 108, 108, 111}},
+			},
+		},
+		{Token: "!", Logprob: -0.25, Bytes: []int{33}, TopLogprobs: []TopLogprob{}},
+	}}
+	if !reflect.DeepEqual(lp, want) {
+		t.Errorf("ParseLogprobs() = %+v, want %+v", lp, want)
+	}
+
+	// MeanLogProb reads the same typed view
+	if mean, ok := resp.MeanLogProb(); !ok || math.Abs(mean-(-0.1256)) > 1e-9 {
+		t.Errorf("MeanLogProb() = %v, %v, want -0.1256", mean, ok)
+	}
+
+	none := Choice{}
+	if lp, err := none.ParseLogprobs(); lp != nil || err != nil {
+		t.Errorf("ParseLogprobs() without logprobs = %v, %v, want nil, nil", lp, err)
+	}
+
+	bad := Choice{Logprobs: map[string]interface{}{"content": "oops"}}
+	if _, err := bad.ParseLogprobs(); err == nil {
+		t.Error("ParseLogprobs() error = nil for malformed logprobs")
+	}
+}
+
+func TestToolErrorResult(t *testing.T) {
+	msg := ToolErrorResult("call_123", errors.New("city not found"))
+
+	if msg.Role != "tool" {
+		t.Errorf("Role = %s, want tool", msg.Role)
+	}
+	if msg.ToolCallID != "call_123" {
+		t.Errorf("ToolCallID = %s, want call_123", msg.ToolCallID)
+	}
+	if msg.Content != "Error: city not found" {
+		t.Errorf("Content = %v, want %q", msg.Content, "Error: city not found")
+	}
+
+	if got := ToolErrorResult("call_1", nil).Content; got != "Error: tool execution failed" {
+		t.Errorf("Content for nil error = %v", got)
+	}
+}
+
+func TestChatResponse_FirstContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		resp     ChatResponse
+		wantText string
+		wantOK   bool
+	}{
+		{
+			name: "string content",
+			resp: ChatResponse{Choices: []Choice{
+				{Message: &Message{Role: "assistant", Content: "Hello!"}},
+			}},
+			wantText: "Hello!",
+			wantOK:   true,
+		},
+		{
+			name: "content parts",
+			resp: ChatResponse{Choices: []Choice{
+				{Message: &Message{Role: "assistant", Content: []ContentPart{
+					{Type: "text", Text: "Hello, "},
+					{Type: "image_url", ImageURL: &ImageURL{URL: "https://example.com/a.png"}},
+					{Type: "text", Text: "world!"},
+				}}},
+			}},
+			wantText: "Hello, world!",
+			wantOK:   true,
+		},
+		{
+			name: "no text parts",
+			resp: ChatResponse{Choices: []Choice{
+				{Message: &Message{Role: "assistant", Content: []ContentPart{
+					{Type: "image_url", ImageURL: &ImageURL{URL: "https://example.com/a.png"}},
+				}}},
+			}},
+			wantOK: false,
+		},
+		{
+			name: "nil content",
+			resp: ChatResponse{Choices: []Choice{
+				{Message: &Message{Role: "assistant"}},
+			}},
+			wantOK: false,
+		},
+		{
+			name:   "empty choices",
+			resp:   ChatResponse{},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, ok := tt.resp.FirstContent()
+			if ok != tt.wantOK {
+				t.Errorf("FirstContent() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if text != tt.wantText {
+				t.Errorf("FirstContent() = %q, want %q", text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestChatResponse_FirstContent_DecodedParts(t *testing.T) {
+	data := `{"choices":[{"index":0,"message":{"role":"assistant","content":[` +
+		`{"type":"text","text":"Hello, "},{"type":"text","text":"world!"}]}}]}`
+
+	var resp ChatResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	text, ok := resp.FirstContent()
+	if !ok || text != "Hello, world!" {
+		t.Errorf("FirstContent() = %q, %v, want %q, true", text, ok, "Hello, world!")
+	}
+}
+
+func TestChatRequest_N(t *testing.T) {
+	n := 3
+	data, err := json.Marshal(ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Name a color"}},
+		N:        &n,
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"n":3`) {
+		t.Errorf("Marshal() = %s, want n set to 3", data)
+	}
+
+	data, err = json.Marshal(ChatRequest{Model: "openai/gpt-4o"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if strings.Contains(string(data), `"n"`) {
+		t.Errorf("Marshal() = %s, want n omitted when unset", data)
+	}
+}
+
+func TestChatRequest_Seed(t *testing.T) {
+	seed := 42
+	data, err := json.Marshal(ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+		Seed:     &seed,
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"seed":42`) {
+		t.Errorf("Marshal() = %s, want seed set to 42", data)
+	}
+}
+
+func TestChatResponse_SameBackend(t *testing.T) {
+	a := &ChatResponse{SystemFingerprint: "fp_44709d6fcb"}
+
+	tests := []struct {
+		name  string
+		other *ChatResponse
+		want  bool
+	}{
+		{"same fingerprint", &ChatResponse{SystemFingerprint: "fp_44709d6fcb"}, true},
+		{"different fingerprint", &ChatResponse{SystemFingerprint: "fp_3bc1b5746c"}, false},
+		{"missing fingerprint", &ChatResponse{}, false},
+		{"nil response", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.SameBackend(tt.other); got != tt.want {
+				t.Errorf("SameBackend() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	empty := &ChatResponse{}
+	if empty.SameBackend(&ChatResponse{}) {
+		t.Error("SameBackend() = true for two responses without fingerprints")
+	}
+}
+
+func TestChatRequest_Logprobs(t *testing.T) {
+	data, err := json.Marshal(ChatRequest{
+		Model:       "openai/gpt-4o",
+		Messages:    []Message{{Role: "user", Content: "Hello"}},
+		Logprobs:    ptr(true),
+		TopLogprobs: ptr(5),
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"logprobs":true,"top_logprobs":5`) {
+		t.Errorf("Marshal() = %s, want logprobs and top_logprobs", data)
+	}
+}
+
+func TestChatResponse_Contents(t *testing.T) {
+	data := `{"choices":[` +
+		`{"index":0,"message":{"role":"assistant","content":"Red"}},` +
+		`{"index":1,"message":{"role":"assistant","content":[{"type":"text","text":"Bl"},{"type":"text","text":"ue"}]}},` +
+		`{"index":2,"message":{"role":"assistant","content":null,"tool_calls":[]}}]}`
+
+	var resp ChatResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	want := []string{"Red", "Blue", ""}
+	if got := resp.Contents(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Contents() = %q, want %q", got, want)
+	}
+
+	empty := ChatResponse{}
+	if got := empty.Contents(); len(got) != 0 {
+		t.Errorf("Contents() on empty response = %q, want none", got)
+	}
+}
+
+func TestChatResponse_FirstToolCalls(t *testing.T) {
+	calls := []ToolCall{
+		{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}},
+	}
+	resp := ChatResponse{Choices: []Choice{
+		{Message: &Message{Role: "assistant", ToolCalls: calls}},
+	}}
+
+	got := resp.FirstToolCalls()
+	if len(got) != 1 || got[0].ID != "call_1" {
+		t.Errorf("FirstToolCalls() = %+v", got)
+	}
+
+	empty := ChatResponse{}
+	if got := empty.FirstToolCalls(); got != nil {
+		t.Errorf("FirstToolCalls() on empty response = %+v, want nil", got)
+	}
+}
+
+func TestResponseFormatHelpers(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"city": map[string]interface{}{"type": "string"},
+		},
+		"required": []string{"city"},
+	}
+
+	tests := []struct {
+		name   string
+		format interface{}
+		want   string
+	}{
+		{
+			name:   "text",
+			format: ResponseFormatText(),
+			want:   `{"type":"text"}`,
+		},
+		{
+			name:   "json object",
+			format: ResponseFormatJSONObject(),
+			want:   `{"type":"json_object"}`,
+		},
+		{
+			name:   "json schema strict",
+			format: ResponseFormatJSONSchema("weather", schema, true),
+			want: `{"type":"json_schema","json_schema":{"name":"weather","schema":` +
+				`{"properties":{"city":{"type":"string"}},"required":["city"],"type":"object"},"strict":true}}`,
+		},
+		{
+			name:   "json schema non-strict",
+			format: ResponseFormatJSONSchema("weather", schema, false),
+			want: `{"type":"json_schema","json_schema":{"name":"weather","schema":` +
+				`{"properties":{"city":{"type":"string"}},"required":["city"],"type":"object"},"strict":false}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.format)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+
+	// The format serializes under response_format in the request
+	req := ChatRequest{
+		Model:          "openai/gpt-4o",
+		Messages:       []Message{{Role: "user", Content: "Hi"}},
+		ResponseFormat: ResponseFormatJSONObject(),
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	format, ok := decoded["response_format"].(map[string]interface{})
+	if !ok || format["type"] != "json_object" {
+		t.Errorf("response_format = %v, want json_object", decoded["response_format"])
+	}
+}
+
+func TestChoice_FilterResults(t *testing.T) {
+	data := `{
+		"id": "chatcmpl-1",
+		"object": "chat.completion",
+		"created": 1,
+		"model": "openai/gpt-4o",
+		"choices": [{
+			"index": 0,
+			"message": {"role": "assistant", "content": ""},
+			"finish_reason": "content_filter",
+			"content_filter_results": {
+				"hate": {"filtered": false, "severity": "safe"},
+				"violence": {"filtered": true, "severity": "high"},
+				"self_harm": {"filtered": true, "severity": "medium"},
+				"jailbreak": {"filtered": false, "detected": false}
+			}
+		}]
+	}`
+
+	var resp ChatResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	results := resp.Choices[0].FilterResults()
+	if len(results) != 4 {
+		t.Fatalf("FilterResults() len = %d, want 4", len(results))
+	}
+	if got := results["violence"]; !got.Filtered || got.Severity != "high" {
+		t.Errorf("FilterResults()[violence] = %+v, want filtered with high severity", got)
+	}
+	if got := results["jailbreak"]; got.Detected == nil || *got.Detected {
+		t.Errorf("FilterResults()[jailbreak].Detected = %v, want false", got.Detected)
+	}
+
+	filtered := results.Filtered()
+	if len(filtered) != 2 || filtered[0] != "self_harm" || filtered[1] != "violence" {
+		t.Errorf("Filtered() = %v, want [self_harm violence]", filtered)
+	}
+
+	var empty Choice
+	if got := empty.FilterResults(); got != nil {
+		t.Errorf("FilterResults() = %v, want nil", got)
+	}
+	if got := empty.FilterResults().Filtered(); got != nil {
+		t.Errorf("Filtered() = %v, want nil", got)
+	}
+}
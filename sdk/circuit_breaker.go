@@ -0,0 +1,171 @@
+// Package zaguansdk provides a client-side circuit breaker for the Zaguan SDK.
+//
+// This file implements Config.CircuitBreaker, which stops sending requests
+// for a while after repeated server or transport failures.
+package zaguansdk
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
+)
+
+// Default circuit breaker settings, used for zero CircuitBreakerConfig fields.
+const (
+	DefaultCircuitBreakerThreshold = 5
+	DefaultCircuitBreakerCooldown  = 30 * time.Second
+)
+
+// CircuitBreakerConfig configures the client's circuit breaker.
+//
+// The breaker counts consecutive failed attempts, where a failure is a
+// transport error or a 5xx response other than 501. Once FailureThreshold is
+// reached it opens, and every request fails immediately with ErrCircuitOpen.
+// After Cooldown it lets HalfOpenRequests trial attempts through: if they all
+// succeed the breaker closes, and if any fails it opens again for another
+// Cooldown. Attempts cut short by the caller's context count as neither, and
+// an aborted trial frees its slot for another.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens
+	// the breaker.
+	// If zero, DefaultCircuitBreakerThreshold is used.
+	FailureThreshold int
+
+	// Cooldown is how long the breaker stays open before allowing trial
+	// attempts.
+	// If zero, DefaultCircuitBreakerCooldown is used.
+	Cooldown time.Duration
+
+	// HalfOpenRequests is the number of trial attempts allowed, and
+	// required to succeed, before the breaker closes.
+	// If zero, one trial attempt is used.
+	HalfOpenRequests int
+}
+
+// Circuit breaker states.
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker is the internal.CircuitBreaker used by Config.CircuitBreaker.
+// It is safe for concurrent use.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	trials    int
+	clock     Clock
+
+	mu        sync.Mutex
+	state     int
+	failures  int       // consecutive failures while closed
+	openedAt  time.Time // when the breaker last opened
+	inTrial   int       // trial attempts in flight while half-open
+	succeeded int       // successful trial attempts while half-open
+	gen       int       // incremented each time the breaker opens
+}
+
+// newCircuitBreaker creates a closed circuit breaker from cfg.
+func newCircuitBreaker(cfg CircuitBreakerConfig, clock Clock) *circuitBreaker {
+	b := &circuitBreaker{
+		threshold: cfg.FailureThreshold,
+		cooldown:  cfg.Cooldown,
+		trials:    cfg.HalfOpenRequests,
+		clock:     clock,
+	}
+	if b.threshold <= 0 {
+		b.threshold = DefaultCircuitBreakerThreshold
+	}
+	if b.cooldown <= 0 {
+		b.cooldown = DefaultCircuitBreakerCooldown
+	}
+	if b.trials <= 0 {
+		b.trials = 1
+	}
+	return b
+}
+
+// Allow returns ErrCircuitOpen while the breaker is open, or while all trial
+// attempts are in use when half-open.
+func (b *circuitBreaker) Allow() (func(outcome internal.AttemptOutcome), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == circuitOpen {
+		if b.clock.Now().Sub(b.openedAt) < b.cooldown {
+			return nil, ErrCircuitOpen
+		}
+		b.state = circuitHalfOpen
+		b.inTrial = 0
+		b.succeeded = 0
+	}
+
+	if b.state == circuitHalfOpen {
+		if b.inTrial+b.succeeded >= b.trials {
+			return nil, ErrCircuitOpen
+		}
+		b.inTrial++
+		gen := b.gen
+		return func(outcome internal.AttemptOutcome) {
+			b.trialDone(gen, outcome)
+		}, nil
+	}
+
+	return b.done, nil
+}
+
+// done records the outcome of an attempt allowed while closed.
+func (b *circuitBreaker) done(outcome internal.AttemptOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != circuitClosed {
+		return
+	}
+	switch outcome {
+	case internal.AttemptAborted:
+		return
+	case internal.AttemptSucceeded:
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.open()
+	}
+}
+
+// trialDone records the outcome of a trial attempt allowed while half-open
+// in generation gen. Trials that finish after the breaker has opened again
+// are ignored, even if it is half-open once more.
+func (b *circuitBreaker) trialDone(gen int, outcome internal.AttemptOutcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != circuitHalfOpen || b.gen != gen {
+		return
+	}
+	b.inTrial--
+	switch outcome {
+	case internal.AttemptAborted:
+		return
+	case internal.AttemptFailed:
+		b.open()
+		return
+	}
+	b.succeeded++
+	if b.succeeded >= b.trials {
+		b.state = circuitClosed
+		b.failures = 0
+	}
+}
+
+// open trips the breaker. b.mu must be held.
+func (b *circuitBreaker) open() {
+	b.gen++
+	b.state = circuitOpen
+	b.openedAt = b.clock.Now()
+	b.failures = 0
+}
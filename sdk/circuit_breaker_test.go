@@ -0,0 +1,238 @@
+package zaguansdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/zaguantest"
+)
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var calls int32
+	var healthy atomic.Bool
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if !healthy.Load() {
+			testutil.ErrorHandler(http.StatusServiceUnavailable, "server_error", "unavailable")(w, r)
+			return
+		}
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	clock := zaguantest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+		Clock:   clock,
+		CircuitBreaker: &CircuitBreakerConfig{
+			FailureThreshold: 3,
+			Cooldown:         time.Minute,
+		},
+	})
+	chat := func() error {
+		_, err := client.Chat(context.Background(), ChatRequest{
+			Model:    "openai/gpt-4o",
+			Messages: []Message{{Role: "user", Content: "Hello"}},
+		}, nil)
+		return err
+	}
+
+	// Repeated 503s trip the breaker
+	for i := 0; i < 3; i++ {
+		if err := chat(); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Chat() #%d error = %v, want a server error", i+1, err)
+		}
+	}
+	if err := chat(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Chat() error = %v, want ErrCircuitOpen", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("server received %d requests, want 3", got)
+	}
+
+	// After the cooldown a failed trial opens the breaker again
+	clock.Advance(time.Minute)
+	if err := chat(); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("trial Chat() error = %v, want a server error", err)
+	}
+	if err := chat(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Chat() error = %v, want ErrCircuitOpen after a failed trial", err)
+	}
+
+	// A successful trial closes it
+	healthy.Store(true)
+	clock.Advance(time.Minute)
+	for i := 0; i < 2; i++ {
+		if err := chat(); err != nil {
+			t.Fatalf("Chat() #%d error = %v after recovery", i+1, err)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 6 {
+		t.Errorf("server received %d requests, want 6", got)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenTrials(t *testing.T) {
+	clock := zaguantest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Second, HalfOpenRequests: 2}, clock)
+
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	done(internal.AttemptFailed)
+	if _, err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() error = %v, want ErrCircuitOpen", err)
+	}
+
+	// Only HalfOpenRequests trials are let through at once
+	clock.Advance(time.Second)
+	trial1, err := b.Allow()
+	if err != nil {
+		t.Fatalf("trial Allow() error = %v", err)
+	}
+	trial2, err := b.Allow()
+	if err != nil {
+		t.Fatalf("trial Allow() error = %v", err)
+	}
+	if _, err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Allow() error = %v, want ErrCircuitOpen with all trials in flight", err)
+	}
+
+	// The breaker closes once every trial has succeeded
+	trial1(internal.AttemptSucceeded)
+	if _, err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Allow() error = %v, want ErrCircuitOpen until all trials succeed", err)
+	}
+	trial2(internal.AttemptSucceeded)
+	if _, err := b.Allow(); err != nil {
+		t.Errorf("Allow() error = %v, want nil once closed", err)
+	}
+}
+
+func TestCircuitBreaker_AbortedAttempts(t *testing.T) {
+	clock := zaguantest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Second}, clock)
+
+	// Aborted attempts do not count as failures while closed
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	done(internal.AttemptAborted)
+	done, err = b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() error = %v after an aborted attempt, want nil", err)
+	}
+	done(internal.AttemptFailed)
+
+	// An aborted trial frees its slot without closing the breaker
+	clock.Advance(time.Second)
+	trial, err := b.Allow()
+	if err != nil {
+		t.Fatalf("trial Allow() error = %v", err)
+	}
+	trial(internal.AttemptAborted)
+	if b.state != circuitHalfOpen {
+		t.Errorf("state = %d after an aborted trial, want half-open", b.state)
+	}
+	trial, err = b.Allow()
+	if err != nil {
+		t.Fatalf("trial Allow() error = %v after an aborted trial, want nil", err)
+	}
+	trial(internal.AttemptSucceeded)
+	if b.state != circuitClosed {
+		t.Errorf("state = %d after a successful trial, want closed", b.state)
+	}
+}
+
+func TestCircuitBreaker_StaleTrial(t *testing.T) {
+	clock := zaguantest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	b := newCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Second, HalfOpenRequests: 2}, clock)
+
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() error = %v", err)
+	}
+	done(internal.AttemptFailed)
+
+	// A slow trial is still in flight when another trial reopens the breaker
+	clock.Advance(time.Second)
+	slow, err := b.Allow()
+	if err != nil {
+		t.Fatalf("trial Allow() error = %v", err)
+	}
+	fast, err := b.Allow()
+	if err != nil {
+		t.Fatalf("trial Allow() error = %v", err)
+	}
+	fast(internal.AttemptFailed)
+
+	// In the next half-open window, the slow trial finishes
+	clock.Advance(time.Second)
+	trial, err := b.Allow()
+	if err != nil {
+		t.Fatalf("trial Allow() error = %v", err)
+	}
+	slow(internal.AttemptSucceeded)
+	if b.inTrial != 1 || b.succeeded != 0 {
+		t.Errorf("inTrial = %d, succeeded = %d after a stale trial, want 1 and 0", b.inTrial, b.succeeded)
+	}
+
+	trial(internal.AttemptSucceeded)
+	if b.state != circuitHalfOpen {
+		t.Errorf("state = %d after one of two trials succeeded, want half-open", b.state)
+	}
+}
+
+func TestClient_CircuitBreaker_IgnoresCallerErrors(t *testing.T) {
+	var notImplemented atomic.Bool
+	unblock := make(chan struct{})
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if notImplemented.Load() {
+			testutil.ErrorHandler(http.StatusNotImplemented, "not_implemented", "not implemented")(w, r)
+			return
+		}
+		select {
+		case <-unblock:
+		case <-r.Context().Done():
+		}
+	}))
+	defer mockServer.Close()
+	defer close(unblock)
+
+	client := NewClient(Config{
+		BaseURL:        mockServer.URL(),
+		APIKey:         "test-key",
+		CircuitBreaker: &CircuitBreakerConfig{FailureThreshold: 1},
+	})
+	chat := func(ctx context.Context) error {
+		_, err := client.Chat(ctx, ChatRequest{
+			Model:    "openai/gpt-4o",
+			Messages: []Message{{Role: "user", Content: "Hello"}},
+		}, nil)
+		return err
+	}
+
+	// The caller's own deadline does not trip the breaker
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := chat(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Chat() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	// Nor does 501 Not Implemented
+	notImplemented.Store(true)
+	for i := 0; i < 2; i++ {
+		if err := chat(context.Background()); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Chat() #%d error = %v, want a 501 error", i+1, err)
+		}
+	}
+}
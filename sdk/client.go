@@ -2,6 +2,7 @@ package zaguansdk
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"strings"
@@ -26,33 +27,253 @@ type Config struct {
 	APIKey string
 
 	// HTTPClient is the HTTP client to use for requests.
-	// If nil, http.DefaultClient will be used.
+	// If nil, the client uses its own clone of http.DefaultTransport, which
+	// Close and Shutdown release. A supplied client's connections are never
+	// closed by the SDK.
 	// Optional.
 	HTTPClient *http.Client
 
-	// Timeout is the default timeout for all requests.
-	// Individual requests can override this via RequestOptions.
+	// Timeout is the default timeout for non-streaming requests.
+	// Individual requests can override this via RequestOptions.Timeout.
 	// If zero, no timeout is applied at the client level.
 	// Optional.
 	Timeout time.Duration
 
+	// StreamTimeout is the default timeout for streaming requests
+	// (ChatStream, MessagesStream). It bounds the total duration of the
+	// stream, from sending the request until the stream is closed.
+	// Individual requests can override this via RequestOptions.Timeout.
+	// If zero, streams are not bounded at the client level; Timeout does not
+	// apply to streams.
+	// Optional.
+	StreamTimeout time.Duration
+
+	// Tracer wraps SDK operations in tracing spans and, if it implements
+	// TracePropagator, propagates trace context in request headers. See
+	// Tracer for an OpenTelemetry adapter.
+	// If nil, no tracing is performed.
+	// Optional.
+	Tracer Tracer
+
 	// Logger is an optional logger for debugging and observability.
+	// Every HTTP request is logged at LogLevelInfo with its method, path,
+	// status code, duration, and request ID; request bodies and API keys
+	// are never logged.
 	// If nil, no logging will be performed.
 	// Optional.
 	Logger Logger
+
+	// MaxErrorBodySize is the maximum number of bytes of a non-JSON error
+	// response body to capture in APIError.RawBody.
+	// If zero, 1024 bytes are captured.
+	// Optional.
+	MaxErrorBodySize int
+
+	// Organization is the organization ID used to attribute usage.
+	// When set, it is sent in the X-Zaguan-Organization header on every
+	// request. Individual requests can override it via RequestOptions.Headers.
+	// Optional.
+	Organization string
+
+	// Project is the project ID used to attribute usage.
+	// When set, it is sent in the X-Zaguan-Project header on every request.
+	// Individual requests can override it via RequestOptions.Headers.
+	// Optional.
+	Project string
+
+	// RequestIDPrefix is prepended to generated request IDs, which become
+	// "<prefix>-<uuid>", so SDK traffic is easy to find in aggregated gateway
+	// logs. IDs set with RequestOptions.RequestID or ContextWithRequestID are
+	// sent unchanged.
+	// Optional.
+	RequestIDPrefix string
+
+	// DefaultHeaders are HTTP headers sent on every request, such as tracing
+	// headers, feature flags, or tenant identifiers.
+	// RequestOptions.Headers take precedence on conflicts.
+	// Reserved headers (Authorization, User-Agent, X-Request-Id, Content-Type)
+	// are managed by the SDK and cannot be set here; NewClient panics if they are.
+	// Optional.
+	DefaultHeaders http.Header
+
+	// ForceHTTP1 disables HTTP/2 so all requests use HTTP/1.1.
+	//
+	// Some corporate proxies and middleboxes mishandle HTTP/2, which shows up
+	// as streams that stall until they time out. Forcing HTTP/1.1 avoids this
+	// at the cost of HTTP/2 multiplexing: concurrent requests each need their
+	// own connection instead of sharing one.
+	//
+	// The transport of HTTPClient is cloned rather than modified. If
+	// HTTPClient has a custom Transport, it must be an *http.Transport.
+	// Optional.
+	ForceHTTP1 bool
+
+	// MaxRequestBytes is the maximum size in bytes of a JSON request body.
+	// Requests whose marshaled body is larger fail with a
+	// RequestTooLargeError before anything is sent, catching runaway
+	// context assembly (e.g. a huge document included inline) before it
+	// wastes bandwidth or hits a server limit.
+	// Multipart uploads (audio files) are streamed and are not checked.
+	// If zero, request size is not limited.
+	// Optional.
+	MaxRequestBytes int
+
+	// EnableCompression sends Accept-Encoding: gzip and transparently
+	// decompresses gzip responses, including streams. JSON request bodies of
+	// at least CompressionThreshold bytes (such as large batch or embeddings
+	// inputs) are gzipped and sent with Content-Encoding: gzip; the server
+	// must accept compressed requests. MaxRequestBytes applies to the
+	// uncompressed size.
+	// Optional.
+	EnableCompression bool
+
+	// CompressionThreshold is the minimum size in bytes of a JSON request
+	// body that is gzipped when EnableCompression is set. Smaller bodies are
+	// sent uncompressed, since compressing them saves little.
+	// If zero, 1024 bytes is used.
+	// Optional.
+	CompressionThreshold int
+
+	// VirtualModelMode controls how ChatRequest.VirtualModelID is sent to
+	// the server, for gateways that expect virtual model aliases in
+	// different places:
+	//   - VirtualModelModeField sends it in the virtual_model_id body field.
+	//   - VirtualModelModeHeader sends it in the X-Zaguan-Virtual-Model header.
+	//   - VirtualModelModeModel sends it as the model, replacing Model.
+	// If empty, VirtualModelModeField is used.
+	// Optional.
+	VirtualModelMode string
+
+	// CheckStreamingSupport makes ChatStream look up the model's
+	// capabilities before streaming and fail with a *ValidationError if the
+	// model does not support streaming, instead of failing to parse a
+	// non-streaming response. The lookup costs an extra request; if it
+	// fails, the stream request proceeds unchecked.
+	// Optional.
+	CheckStreamingSupport bool
+
+	// CheckAudioOutputSupport makes Chat and ChatStream look up the model's
+	// capabilities before requests that ask for audio output and fail with a
+	// *ValidationError if the model does not support it. The lookup costs an
+	// extra request; if it fails, the request proceeds unchecked.
+	// Optional.
+	CheckAudioOutputSupport bool
+
+	// SplitLongSpeech makes CreateSpeech and CreateSpeechStream split input
+	// longer than MaxSpeechInputChars on sentence boundaries, synthesize each
+	// part in turn, and return the concatenated audio. Only formats that can
+	// be concatenated (mp3, opus, aac, pcm) are supported. If false, such
+	// input fails with a *ValidationError.
+	// Optional.
+	SplitLongSpeech bool
+
+	// CapabilitiesTTL is how long model capabilities fetched by
+	// GetCapabilities (and helpers such as SupportsVision) are cached.
+	// If zero, DefaultCapabilitiesTTL is used. If negative, capabilities
+	// are not cached.
+	// Optional.
+	CapabilitiesTTL time.Duration
+
+	// StrictMetadata makes requests fail with a *ValidationError when
+	// ChatRequest.Metadata or MessagesRequest.Metadata contains a non-string
+	// value. By default such values are JSON-encoded to strings; see
+	// NormalizeMetadata.
+	// Optional.
+	StrictMetadata bool
+
+	// StrictToolMessages makes Chat and ChatStream check tool messages
+	// before sending: each "tool" message must have a ToolCallID matching a
+	// tool call made by an earlier assistant message. Mismatches fail with a
+	// *ValidationError instead of a 400 from the gateway.
+	// Optional.
+	StrictToolMessages bool
+
+	// VerifyOnCreate makes NewClient call Ping before returning, so a wrong
+	// BaseURL or a rejected APIKey fails at startup instead of on the first
+	// real request. NewClient panics if the check fails. The check is
+	// bounded by Timeout, or by 10 seconds if Timeout is zero.
+	// Leave it off in tests and wherever the client is built without
+	// network access.
+	// Optional.
+	VerifyOnCreate bool
+
+	// RateLimiter, if set, is waited on before every HTTP attempt,
+	// including retries, to keep the client under a request rate. A
+	// *rate.Limiter from golang.org/x/time/rate satisfies it. A cancelled
+	// context ends the wait and fails the request.
+	// Optional.
+	RateLimiter RateLimiter
+
+	// CircuitBreaker, if set, stops sending requests after repeated server
+	// or transport failures, failing them with ErrCircuitOpen until a
+	// cooldown has passed. See CircuitBreakerConfig.
+	// Optional.
+	CircuitBreaker *CircuitBreakerConfig
+
+	// Middleware wraps every HTTP attempt, including retries, in order: the
+	// first middleware sees the request first and the response last. Use
+	// it to inject headers, capture raw traffic, or add custom policies
+	// without replacing HTTPClient. See Middleware.
+	// Optional.
+	Middleware []Middleware
+
+	// Clock provides the current time and timers for time-dependent logic
+	// such as batch polling, capabilities cache expiry,
+	// BatchResponse.EstimatedCompletion, and CreditsBalance.DaysUntilReset.
+	// Tests can set a zaguantest.FakeClock to control
+	// time deterministically.
+	// If nil, the system clock is used.
+	// Optional.
+	Clock Clock
 }
 
+// RateLimiter limits the rate of requests sent by a client; see
+// Config.RateLimiter.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent, or returns an error if ctx is
+	// done first.
+	Wait(ctx context.Context) error
+}
+
+// Virtual model modes for Config.VirtualModelMode.
+const (
+	// VirtualModelModeField sends the virtual model in the virtual_model_id
+	// body field. This is the default.
+	VirtualModelModeField = "field"
+
+	// VirtualModelModeHeader sends the virtual model in the
+	// X-Zaguan-Virtual-Model header.
+	VirtualModelModeHeader = "header"
+
+	// VirtualModelModeModel sends the virtual model in the model field.
+	VirtualModelModeModel = "model"
+)
+
+// virtualModelHeader is the header used by VirtualModelModeHeader.
+const virtualModelHeader = "X-Zaguan-Virtual-Model"
+
 // Client is the main entry point for interacting with Zaguan CoreX.
 //
 // A Client is safe for concurrent use by multiple goroutines.
 // You should create a single Client and reuse it throughout your application.
 type Client struct {
-	baseURL      string
-	apiKey       string
-	httpClient   *http.Client
-	internalHTTP *internal.HTTPClient
-	timeout      time.Duration
-	logger       Logger
+	baseURL       string
+	apiKey        string
+	httpClient    *http.Client
+	internalHTTP  *internal.HTTPClient
+	timeout       time.Duration
+	streamTimeout time.Duration
+	logger        Logger
+
+	virtualModelMode      string
+	checkStreamingSupport bool
+	checkAudioOutput      bool
+	splitLongSpeech       bool
+	strictMetadata        bool
+	strictToolMessages    bool
+	tracer                Tracer
+	capabilities          *capabilitiesCache
+	clock                 Clock
 }
 
 // NewClient creates a new Zaguan SDK client with the provided configuration.
@@ -71,10 +292,14 @@ func NewClient(cfg Config) *Client {
 		panic(fmt.Sprintf("zaguansdk: invalid configuration: %v", err))
 	}
 
-	// Use default HTTP client if none provided
+	// Use a client with its own transport if none provided
 	httpClient := cfg.HTTPClient
+	ownsTransport := cfg.ForceHTTP1
 	if httpClient == nil {
-		httpClient = http.DefaultClient
+		httpClient, ownsTransport = newDefaultHTTPClient()
+	}
+	if cfg.ForceHTTP1 {
+		httpClient = withHTTP1Only(httpClient)
 	}
 
 	// Trim trailing slash from base URL for consistency
@@ -82,15 +307,172 @@ func NewClient(cfg Config) *Client {
 
 	// Create internal HTTP client
 	internalHTTP := internal.NewHTTPClient(httpClient, baseURL, cfg.APIKey, Version)
+	internalHTTP.OwnsTransport = ownsTransport
+	internalHTTP.MaxErrorBodySize = cfg.MaxErrorBodySize
+	internalHTTP.Organization = cfg.Organization
+	internalHTTP.Project = cfg.Project
+	internalHTTP.DefaultHeaders = cfg.DefaultHeaders.Clone()
+	internalHTTP.RequestIDPrefix = cfg.RequestIDPrefix
+	internalHTTP.MaxRequestBytes = cfg.MaxRequestBytes
+	internalHTTP.EnableCompression = cfg.EnableCompression
+	internalHTTP.CompressionThreshold = cfg.CompressionThreshold
+	if len(cfg.Middleware) > 0 {
+		internalHTTP.WrapRoundTrip = chainMiddleware(append([]Middleware(nil), cfg.Middleware...))
+	}
+	if propagator, ok := cfg.Tracer.(TracePropagator); ok {
+		internalHTTP.InjectHeaders = propagator.Inject
+	}
+
+	clock := cfg.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+
+	if cfg.RateLimiter != nil {
+		internalHTTP.Wait = cfg.RateLimiter.Wait
+	}
+	if cfg.CircuitBreaker != nil {
+		internalHTTP.Breaker = newCircuitBreaker(*cfg.CircuitBreaker, clock)
+	}
+
+	capabilitiesTTL := cfg.CapabilitiesTTL
+	if capabilitiesTTL == 0 {
+		capabilitiesTTL = DefaultCapabilitiesTTL
+	}
+
+	client := &Client{
+		baseURL:       baseURL,
+		apiKey:        cfg.APIKey,
+		httpClient:    httpClient,
+		internalHTTP:  internalHTTP,
+		timeout:       cfg.Timeout,
+		streamTimeout: cfg.StreamTimeout,
+		logger:        cfg.Logger,
+
+		virtualModelMode:      cfg.VirtualModelMode,
+		checkStreamingSupport: cfg.CheckStreamingSupport,
+		checkAudioOutput:      cfg.CheckAudioOutputSupport,
+		splitLongSpeech:       cfg.SplitLongSpeech,
+		strictMetadata:        cfg.StrictMetadata,
+		strictToolMessages:    cfg.StrictToolMessages,
+		tracer:                cfg.Tracer,
+		capabilities:          &capabilitiesCache{ttl: capabilitiesTTL},
+		clock:                 clock,
+	}
+	internalHTTP.Sleep = client.sleep
+	if cfg.Tracer != nil {
+		internalHTTP.Trace = client.traceRequest
+	}
+	if cfg.Logger != nil {
+		internalHTTP.OnRequestSize = func(ctx context.Context, path string, size int) {
+			client.log(ctx, LogLevelDebug, "request body size", "path", path, "bytes", size)
+		}
+		internalHTTP.OnRequestComplete = func(ctx context.Context, info internal.RequestInfo) {
+			if info.Err != nil {
+				client.log(ctx, LogLevelWarn, "http request failed",
+					"method", info.Method,
+					"path", info.Path,
+					"duration", info.Duration,
+					"request_id", info.RequestID,
+					"error", info.Err)
+				return
+			}
+			client.log(ctx, LogLevelInfo, "http request",
+				"method", info.Method,
+				"path", info.Path,
+				"status", info.StatusCode,
+				"duration", info.Duration,
+				"request_id", info.RequestID)
+		}
+	}
+
+	if cfg.VerifyOnCreate {
+		if err := client.verify(); err != nil {
+			panic(fmt.Sprintf("zaguansdk: verifying client: %v", err))
+		}
+	}
 
-	return &Client{
-		baseURL:      baseURL,
-		apiKey:       cfg.APIKey,
-		httpClient:   httpClient,
-		internalHTTP: internalHTTP,
-		timeout:      cfg.Timeout,
-		logger:       cfg.Logger,
+	return client
+}
+
+// defaultVerifyTimeout bounds the Config.VerifyOnCreate check when
+// Config.Timeout is zero.
+const defaultVerifyTimeout = 10 * time.Second
+
+// verify pings the server for Config.VerifyOnCreate.
+func (c *Client) verify() error {
+	timeout := c.timeout
+	if timeout == 0 {
+		timeout = defaultVerifyTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return c.Ping(ctx, nil)
+}
+
+// newDefaultHTTPClient returns an HTTP client with its own clone of
+// http.DefaultTransport, so closing its idle connections does not affect other
+// users of the default transport. If http.DefaultTransport has been replaced
+// with a non-*http.Transport, http.DefaultClient is returned unowned.
+func newDefaultHTTPClient() (client *http.Client, ownsTransport bool) {
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return http.DefaultClient, false
+	}
+	return &http.Client{Transport: transport.Clone()}, true
+}
+
+// withHTTP1Only returns a copy of client whose transport never negotiates HTTP/2.
+func withHTTP1Only(client *http.Client) *http.Client {
+	base, _ := client.Transport.(*http.Transport)
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+
+	transport := base.Clone()
+	transport.ForceAttemptHTTP2 = false
+	// A non-nil, empty TLSNextProto disables HTTP/2 over TLS
+	transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	if transport.TLSClientConfig != nil {
+		transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
 	}
+
+	clone := *client
+	clone.Transport = transport
+	return &clone
+}
+
+// applyVirtualModelMode moves req.VirtualModelID to where the configured
+// VirtualModelMode expects it. It returns the value to send in the virtual
+// model header, or "" if none should be sent.
+func (c *Client) applyVirtualModelMode(req *ChatRequest) string {
+	if req.VirtualModelID == "" {
+		return ""
+	}
+
+	switch c.virtualModelMode {
+	case VirtualModelModeHeader:
+		virtualModel := req.VirtualModelID
+		req.VirtualModelID = ""
+		return virtualModel
+	case VirtualModelModeModel:
+		req.Model = req.VirtualModelID
+		req.VirtualModelID = ""
+	}
+	return ""
+}
+
+// withVirtualModelHeader returns a copy of headers carrying the virtual model
+// header. A header already set by the caller takes precedence.
+func withVirtualModelHeader(headers http.Header, virtualModel string) http.Header {
+	headers = headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	if headers.Get(virtualModelHeader) == "" {
+		headers.Set(virtualModelHeader, virtualModel)
+	}
+	return headers
 }
 
 // BaseURL returns the base URL configured for this client.
@@ -98,6 +480,93 @@ func (c *Client) BaseURL() string {
 	return c.baseURL
 }
 
+// Ping checks that the server is reachable and accepts the API key by
+// listing models. It returns nil on success, and otherwise the transport
+// error or an *APIError such as an authentication error.
+//
+// Example:
+//
+//	if err := client.Ping(ctx, nil); err != nil {
+//		log.Fatalf("zaguan unavailable: %v", err)
+//	}
+func (c *Client) Ping(ctx context.Context, opts *RequestOptions) error {
+	c.log(ctx, LogLevelDebug, "pinging server")
+
+	// Build request config
+	reqCfg := internal.RequestConfig{
+		Operation: "zaguan.Ping",
+		Method:    "GET",
+		Path:      "/v1/models",
+	}
+
+	// Apply request options
+	applyRequestOptions(&reqCfg, opts, c.timeout)
+
+	// Execute request; the model list itself is not needed
+	resp, err := c.internalHTTP.Do(ctx, reqCfg)
+	if err != nil {
+		c.log(ctx, LogLevelError, "ping failed", "error", err)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		err := c.internalHTTP.ParseErrorResponse(resp)
+		c.log(ctx, LogLevelError, "ping failed", "error", err)
+		return err
+	}
+
+	c.log(ctx, LogLevelDebug, "ping succeeded")
+	return nil
+}
+
+// Shutdown cancels all in-flight requests made by this client, including open
+// streams, and waits for them to finish or for ctx to be done, whichever comes
+// first. Once all requests have finished, idle connections are closed if the
+// client owns its transport (see Close); a caller-supplied Config.HTTPClient
+// is left untouched.
+//
+// After Shutdown, every method on the client fails with ErrClientClosed.
+// Shutdown returns ctx.Err() if ctx is done before in-flight requests finish.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	if err := client.Shutdown(ctx); err != nil {
+//		log.Printf("shutdown: %v", err)
+//	}
+func (c *Client) Shutdown(ctx context.Context) error {
+	c.log(ctx, LogLevelDebug, "shutting down client")
+
+	if err := c.internalHTTP.Shutdown(ctx); err != nil {
+		c.log(ctx, LogLevelWarn, "client shutdown did not complete", "error", err)
+		return err
+	}
+
+	c.log(ctx, LogLevelDebug, "client shutdown complete")
+	return nil
+}
+
+// Close releases the client's idle connections and marks it closed. After
+// Close, every method on the client fails with ErrClientClosed. Unlike
+// Shutdown, Close does not cancel or wait for in-flight requests.
+//
+// Idle connections are only closed if the client owns its transport: when
+// Config.HTTPClient is nil or ForceHTTP1 gave the client its own transport.
+// A caller-supplied Config.HTTPClient is left untouched. Close always returns
+// nil and may be called more than once.
+//
+// Example:
+//
+//	client := zaguansdk.NewClient(cfg)
+//	defer client.Close()
+func (c *Client) Close() error {
+	c.internalHTTP.Close()
+	c.log(context.Background(), LogLevelDebug, "client closed")
+	return nil
+}
+
 // log logs a message if a logger is configured.
 func (c *Client) log(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{}) {
 	if c.logger != nil {
@@ -158,10 +627,29 @@ func (l LogLevel) String() string {
 //		},
 //	}, nil)
 func (c *Client) Chat(ctx context.Context, req ChatRequest, opts *RequestOptions) (*ChatResponse, error) {
+	virtualModel := c.applyVirtualModelMode(&req)
+
 	// Validate request
 	if err := validateChatRequest(&req); err != nil {
 		return nil, err
 	}
+	if c.strictToolMessages {
+		if err := validateToolMessages(req.Messages); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata, err := c.prepareMetadata(req.Metadata, "metadata")
+	if err != nil {
+		return nil, err
+	}
+	req.Metadata = metadata
+
+	if c.checkAudioOutput && requestsAudioOutput(&req) {
+		if err := c.precheckAudioOutput(ctx, req.Model, opts); err != nil {
+			return nil, err
+		}
+	}
 
 	// Ensure stream is false for non-streaming
 	req.Stream = false
@@ -172,32 +660,28 @@ func (c *Client) Chat(ctx context.Context, req ChatRequest, opts *RequestOptions
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/chat/completions",
-		Body:   req,
+		Operation: "zaguan.Chat",
+		Method:    "POST",
+		Path:      "/v1/chat/completions",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
+	applyRequestOptions(&reqCfg, opts, c.timeout)
+	if virtualModel != "" {
+		reqCfg.Headers = withVirtualModelHeader(reqCfg.Headers, virtualModel)
 	}
 
 	// Execute request
+	ctx, span := c.startSpan(ctx, "zaguan.Chat", req.Model)
 	var resp ChatResponse
 	if err := c.internalHTTP.DoJSON(ctx, reqCfg, &resp); err != nil {
+		span.end(err)
 		c.log(ctx, LogLevelError, "chat completion request failed", "error", err)
 		return nil, err
 	}
+	span.setUsage(resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	span.end(nil)
 
 	c.log(ctx, LogLevelDebug, "chat completion request succeeded",
 		"response_id", resp.ID,
@@ -226,6 +710,12 @@ func (c *Client) Messages(ctx context.Context, req MessagesRequest, opts *Reques
 		return nil, err
 	}
 
+	metadata, err := c.prepareMetadata(req.Metadata, "metadata")
+	if err != nil {
+		return nil, err
+	}
+	req.Metadata = metadata
+
 	// Ensure stream is false for non-streaming
 	req.Stream = false
 
@@ -235,32 +725,25 @@ func (c *Client) Messages(ctx context.Context, req MessagesRequest, opts *Reques
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/messages",
-		Body:   req,
+		Operation: "zaguan.Messages",
+		Method:    "POST",
+		Path:      "/v1/messages",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
+	ctx, span := c.startSpan(ctx, "zaguan.Messages", req.Model)
 	var resp MessagesResponse
 	if err := c.internalHTTP.DoJSON(ctx, reqCfg, &resp); err != nil {
+		span.end(err)
 		c.log(ctx, LogLevelError, "messages request failed", "error", err)
 		return nil, err
 	}
+	span.setUsage(resp.Usage.InputTokens, resp.Usage.OutputTokens)
+	span.end(nil)
 
 	c.log(ctx, LogLevelDebug, "messages request succeeded",
 		"response_id", resp.ID,
@@ -298,25 +781,14 @@ func (c *Client) CountTokens(ctx context.Context, req CountTokensRequest, opts *
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/messages/count_tokens",
-		Body:   req,
+		Operation: "zaguan.CountTokens",
+		Method:    "POST",
+		Path:      "/v1/messages/count_tokens",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp CountTokensResponse
@@ -354,29 +826,37 @@ func (c *Client) CreateMessagesBatch(ctx context.Context, req MessagesBatchReque
 		return nil, &ValidationError{Field: "requests", Message: "at least one request is required"}
 	}
 
+	// Normalize metadata and system prompts on a copy so the caller's requests
+	// are unchanged
+	items := make([]MessagesBatchItem, len(req.Requests))
+	copy(items, req.Requests)
+	for i := range items {
+		metadata, err := c.prepareMetadata(items[i].Params.Metadata, fmt.Sprintf("requests[%d].params.metadata", i))
+		if err != nil {
+			return nil, err
+		}
+		items[i].Params.Metadata = metadata
+
+		system, err := normalizeSystem(items[i].Params.System, fmt.Sprintf("requests[%d].params.system", i))
+		if err != nil {
+			return nil, err
+		}
+		items[i].Params.System = system
+	}
+	req.Requests = items
+
 	c.log(ctx, LogLevelDebug, "creating messages batch", "count", len(req.Requests))
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/messages/batches",
-		Body:   req,
+		Operation: "zaguan.CreateMessagesBatch",
+		Method:    "POST",
+		Path:      "/v1/messages/batches",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp MessagesBatchResponse
@@ -404,24 +884,13 @@ func (c *Client) GetMessagesBatch(ctx context.Context, batchID string, opts *Req
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "GET",
-		Path:   "/v1/messages/batches/" + batchID,
+		Operation: "zaguan.GetMessagesBatch",
+		Method:    "GET",
+		Path:      "/v1/messages/batches/" + batchID,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp MessagesBatchResponse
@@ -449,24 +918,13 @@ func (c *Client) CancelMessagesBatch(ctx context.Context, batchID string, opts *
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   fmt.Sprintf("/v1/messages/batches/%s/cancel", batchID),
+		Operation: "zaguan.CancelMessagesBatch",
+		Method:    "POST",
+		Path:      fmt.Sprintf("/v1/messages/batches/%s/cancel", batchID),
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp MessagesBatchResponse
@@ -479,3 +937,43 @@ func (c *Client) CancelMessagesBatch(ctx context.Context, batchID string, opts *
 
 	return &resp, nil
 }
+
+// WaitForMessagesBatch polls a Messages batch until processing has ended.
+//
+// The batch is polled every pollInterval with the same gradual backoff as
+// WaitForBatch. If pollInterval is zero, DefaultBatchPollInterval is used.
+// Polling stops when the batch's processing_status is "ended" or ctx is done.
+//
+// Example:
+//
+//	batch, err := client.WaitForMessagesBatch(ctx, "msgbatch_abc123", 0, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println("Results:", batch.ResultsURL)
+func (c *Client) WaitForMessagesBatch(ctx context.Context, batchID string, pollInterval time.Duration, opts *RequestOptions) (*MessagesBatchResponse, error) {
+	if pollInterval <= 0 {
+		pollInterval = DefaultBatchPollInterval
+	}
+
+	interval := pollInterval
+	for {
+		batch, err := c.GetMessagesBatch(ctx, batchID, opts)
+		if err != nil {
+			return nil, err
+		}
+		if batch.IsEnded() {
+			return batch, nil
+		}
+
+		c.log(ctx, LogLevelDebug, "waiting for messages batch",
+			"batch_id", batchID,
+			"status", batch.ProcessingStatus,
+			"next_poll", interval)
+
+		if err := c.sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+		interval = nextPollInterval(interval)
+	}
+}
@@ -3,9 +3,12 @@ package zaguansdk
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestCountTokens(t *testing.T) {
@@ -275,6 +278,36 @@ func TestGetMessagesBatch(t *testing.T) {
 	}
 }
 
+func TestWaitForMessagesBatch(t *testing.T) {
+	var polls int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := "in_progress"
+		if atomic.AddInt32(&polls, 1) >= 2 {
+			status = "ended"
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(MessagesBatchResponse{ID: "msgbatch-123", ProcessingStatus: status})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	batch, err := client.WaitForMessagesBatch(context.Background(), "msgbatch-123", time.Millisecond, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if batch.ProcessingStatus != "ended" {
+		t.Errorf("Expected status ended, got %s", batch.ProcessingStatus)
+	}
+	if got := atomic.LoadInt32(&polls); got != 2 {
+		t.Errorf("Expected 2 polls, got %d", got)
+	}
+}
+
 func TestGetMessagesBatchEmptyID(t *testing.T) {
 	client := NewClient(Config{
 		BaseURL: "http://localhost",
@@ -333,3 +366,78 @@ func TestCancelMessagesBatchEmptyID(t *testing.T) {
 		t.Error("Expected error for empty batch ID, got nil")
 	}
 }
+
+func TestGetMessagesBatchResults(t *testing.T) {
+	const results = `{"custom_id":"req-1","result":{"type":"succeeded","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-5-sonnet","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn"}}}
+{"custom_id":"req-2","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens is required"}}}}
+
+{"custom_id":"req-3","result":{"type":"canceled"}}
+{"custom_id":"req-4","result":{"type":"expired"}}
+`
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-key" {
+			t.Errorf("Authorization = %q, want Bearer test-key", auth)
+		}
+		w.Header().Set("Content-Type", "application/x-jsonl")
+		w.Write([]byte(results))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	for _, resultsURL := range []string{
+		server.URL + "/v1/messages/batches/msgbatch-123/results",
+		"https://api.anthropic.com/v1/messages/batches/msgbatch-123/results",
+	} {
+		paths = nil
+		got, err := client.GetMessagesBatchResults(context.Background(), &MessagesBatchResponse{
+			ID:               "msgbatch-123",
+			ProcessingStatus: "ended",
+			ResultsURL:       resultsURL,
+		}, nil)
+		if err != nil {
+			t.Fatalf("GetMessagesBatchResults(%s) error = %v", resultsURL, err)
+		}
+		if len(paths) != 1 || paths[0] != "/v1/messages/batches/msgbatch-123/results" {
+			t.Errorf("requested paths = %v, want the batch results path", paths)
+		}
+
+		if len(got) != 4 {
+			t.Fatalf("got %d results, want 4", len(got))
+		}
+		if !got[0].IsSuccess() || got[0].CustomID != "req-1" || got[0].Message.Content[0].Text != "Hi" {
+			t.Errorf("results[0] = %+v, want a succeeded req-1 message", got[0])
+		}
+		if got[1].IsSuccess() || got[1].Type != "errored" || got[1].Error == nil ||
+			got[1].Error.Type != "invalid_request_error" || got[1].Error.Message != "max_tokens is required" {
+			t.Errorf("results[1] = %+v, want an errored result with its error", got[1])
+		}
+		for i, want := range []string{"canceled", "expired"} {
+			if r := got[i+2]; r.Type != want || r.IsSuccess() || r.Message != nil || r.Error != nil {
+				t.Errorf("results[%d] = %+v, want a bare %s result", i+2, r, want)
+			}
+		}
+	}
+}
+
+func TestGetMessagesBatchResultsNotEnded(t *testing.T) {
+	client := NewClient(Config{
+		BaseURL: "http://localhost",
+		APIKey:  "test-key",
+	})
+
+	_, err := client.GetMessagesBatchResults(context.Background(), &MessagesBatchResponse{
+		ID:               "msgbatch-123",
+		ProcessingStatus: "in_progress",
+	}, nil)
+	var valErr *ValidationError
+	if !errors.As(err, &valErr) || valErr.Field != "results_url" {
+		t.Errorf("GetMessagesBatchResults() error = %v, want a results_url ValidationError", err)
+	}
+}
@@ -2,7 +2,14 @@ package zaguansdk
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -86,6 +93,86 @@ func TestClient_BaseURL(t *testing.T) {
 	}
 }
 
+func TestClient_Ping(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     http.HandlerFunc
+		wantErr     bool
+		wantAuthErr bool
+	}{
+		{
+			name: "reachable",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/models" {
+					t.Errorf("path = %s, want /v1/models", r.URL.Path)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(`{"object":"list","data":[]}`))
+			},
+		},
+		{
+			name:        "unauthorized",
+			handler:     testutil.ErrorHandler(http.StatusUnauthorized, "authentication_error", "invalid api key"),
+			wantErr:     true,
+			wantAuthErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := testutil.NewMockServer(tt.handler)
+			defer mockServer.Close()
+
+			client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+			err := client.Ping(context.Background(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Ping() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantAuthErr {
+				apiErr, ok := err.(*APIError)
+				if !ok || !apiErr.IsAuthenticationError() {
+					t.Errorf("Ping() error = %v, want an authentication error", err)
+				}
+			}
+		})
+	}
+}
+
+func TestNewClient_VerifyOnCreate(t *testing.T) {
+	var requests int
+	status := http.StatusOK
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if status != http.StatusOK {
+			testutil.ErrorHandler(status, "authentication_error", "invalid api key")(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer mockServer.Close()
+
+	newClient := func(verify bool) (panicked interface{}) {
+		defer func() { panicked = recover() }()
+		NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key", VerifyOnCreate: verify})
+		return nil
+	}
+
+	// Off by default: no request is made
+	if r := newClient(false); r != nil || requests != 0 {
+		t.Errorf("NewClient() panic = %v, requests = %d, want no panic and no requests", r, requests)
+	}
+
+	if r := newClient(true); r != nil || requests != 1 {
+		t.Errorf("NewClient() panic = %v, requests = %d, want no panic and 1 request", r, requests)
+	}
+
+	status = http.StatusUnauthorized
+	if r := newClient(true); r == nil {
+		t.Error("NewClient() should panic when verification fails")
+	}
+}
+
 func TestClient_Chat(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -196,6 +283,522 @@ func TestClient_ChatWithTimeout(t *testing.T) {
 	}
 }
 
+func TestClient_ChatWithAPIKeyOverride(t *testing.T) {
+	var gotAuth string
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "default-key",
+	})
+
+	req := ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "Hello"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		opts     *RequestOptions
+		wantAuth string
+	}{
+		{"no options", nil, "Bearer default-key"},
+		{"empty api key", &RequestOptions{RequestID: "req-1"}, "Bearer default-key"},
+		{"override", WithAPIKey("tenant-key"), "Bearer tenant-key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.Chat(context.Background(), req, tt.opts); err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			if gotAuth != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", gotAuth, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestClient_OrganizationAndProjectHeaders(t *testing.T) {
+	var gotHeaders http.Header
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL:      mockServer.URL(),
+		APIKey:       "test-key",
+		Organization: "org-default",
+		Project:      "proj-default",
+	})
+
+	req := ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "Hello"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		opts    *RequestOptions
+		wantOrg string
+		wantPrj string
+	}{
+		{"client defaults", nil, "org-default", "proj-default"},
+		{
+			name: "per-request override",
+			opts: WithHeaders(http.Header{
+				"X-Zaguan-Organization": []string{"org-override"},
+				"X-Zaguan-Project":      []string{"proj-override"},
+			}),
+			wantOrg: "org-override",
+			wantPrj: "proj-override",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := client.Chat(context.Background(), req, tt.opts); err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+			if got := gotHeaders.Values("X-Zaguan-Organization"); len(got) != 1 || got[0] != tt.wantOrg {
+				t.Errorf("X-Zaguan-Organization = %v, want [%s]", got, tt.wantOrg)
+			}
+			if got := gotHeaders.Values("X-Zaguan-Project"); len(got) != 1 || got[0] != tt.wantPrj {
+				t.Errorf("X-Zaguan-Project = %v, want [%s]", got, tt.wantPrj)
+			}
+		})
+	}
+}
+
+func TestClient_DefaultHeaders(t *testing.T) {
+	var gotHeaders http.Header
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+		DefaultHeaders: http.Header{
+			"X-Trace-Id":     []string{"trace-default"},
+			"X-Feature-Flag": []string{"beta"},
+		},
+	})
+
+	req := ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "Hello"},
+		},
+	}
+
+	_, err := client.Chat(context.Background(), req, WithHeaders(http.Header{
+		"X-Trace-Id": []string{"trace-override"},
+	}))
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if got := gotHeaders.Values("X-Trace-Id"); len(got) != 1 || got[0] != "trace-override" {
+		t.Errorf("X-Trace-Id = %v, want [trace-override]", got)
+	}
+	if got := gotHeaders.Get("X-Feature-Flag"); got != "beta" {
+		t.Errorf("X-Feature-Flag = %q, want beta", got)
+	}
+	if got := gotHeaders.Get("Authorization"); got != "Bearer test-key" {
+		t.Errorf("Authorization = %q, want Bearer test-key", got)
+	}
+}
+
+func TestClient_ForceHTTP1(t *testing.T) {
+	tests := []struct {
+		name       string
+		forceHTTP1 bool
+		wantProto  int
+	}{
+		{"HTTP/2 negotiated by default", false, 2},
+		{"HTTP/1.1 forced", true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var protos []int
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				protos = append(protos, r.ProtoMajor)
+				var req ChatRequest
+				if r.ContentLength != 0 {
+					json.NewDecoder(r.Body).Decode(&req)
+				}
+				if req.Stream {
+					testutil.StreamingHandler([]string{
+						testutil.ChatStreamEventFixture("Hello"),
+					})(w, r)
+					return
+				}
+				testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+			}))
+			server.EnableHTTP2 = true
+			server.StartTLS()
+			defer server.Close()
+
+			client := NewClient(Config{
+				BaseURL:    server.URL,
+				APIKey:     "test-key",
+				HTTPClient: server.Client(),
+				ForceHTTP1: tt.forceHTTP1,
+			})
+
+			req := ChatRequest{
+				Model: "openai/gpt-4o",
+				Messages: []Message{
+					{Role: "user", Content: "Hello"},
+				},
+			}
+
+			if _, err := client.Chat(context.Background(), req, nil); err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+
+			stream, err := client.ChatStream(context.Background(), req, nil)
+			if err != nil {
+				t.Fatalf("ChatStream() error = %v", err)
+			}
+			defer stream.Close()
+			var events int
+			for {
+				_, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Recv() error = %v", err)
+				}
+				events++
+			}
+			if events != 1 {
+				t.Errorf("received %d stream events, want 1", events)
+			}
+
+			for i, proto := range protos {
+				if proto != tt.wantProto {
+					t.Errorf("request %d used HTTP/%d, want HTTP/%d", i, proto, tt.wantProto)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_VirtualModelMode(t *testing.T) {
+	tests := []struct {
+		mode       string
+		wantModel  string
+		wantField  interface{}
+		wantHeader string
+	}{
+		{mode: "", wantModel: "openai/gpt-4o", wantField: "my-alias"},
+		{mode: VirtualModelModeField, wantModel: "openai/gpt-4o", wantField: "my-alias"},
+		{mode: VirtualModelModeHeader, wantModel: "openai/gpt-4o", wantHeader: "my-alias"},
+		{mode: VirtualModelModeModel, wantModel: "my-alias"},
+	}
+
+	for _, tt := range tests {
+		t.Run("mode "+tt.mode, func(t *testing.T) {
+			var gotBody map[string]interface{}
+			var gotHeader string
+			mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotHeader = r.Header.Get("X-Zaguan-Virtual-Model")
+				json.NewDecoder(r.Body).Decode(&gotBody)
+				testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+			}))
+			defer mockServer.Close()
+
+			client := NewClient(Config{
+				BaseURL:          mockServer.URL(),
+				APIKey:           "test-key",
+				VirtualModelMode: tt.mode,
+			})
+
+			req := ChatRequest{
+				Model:          "openai/gpt-4o",
+				Messages:       []Message{{Role: "user", Content: "Hello"}},
+				VirtualModelID: "my-alias",
+			}
+			if _, err := client.Chat(context.Background(), req, nil); err != nil {
+				t.Fatalf("Chat() error = %v", err)
+			}
+
+			if gotBody["model"] != tt.wantModel {
+				t.Errorf("model = %v, want %s", gotBody["model"], tt.wantModel)
+			}
+			if gotBody["virtual_model_id"] != tt.wantField {
+				t.Errorf("virtual_model_id = %v, want %v", gotBody["virtual_model_id"], tt.wantField)
+			}
+			if gotHeader != tt.wantHeader {
+				t.Errorf("X-Zaguan-Virtual-Model = %q, want %q", gotHeader, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestClient_VirtualModelMode_Model_EmptyModel(t *testing.T) {
+	var gotModel interface{}
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotModel = body["model"]
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL:          mockServer.URL(),
+		APIKey:           "test-key",
+		VirtualModelMode: VirtualModelModeModel,
+	})
+
+	// The virtual model stands in for Model, which may be omitted
+	req := ChatRequest{
+		Messages:       []Message{{Role: "user", Content: "Hello"}},
+		VirtualModelID: "my-alias",
+	}
+	if _, err := client.Chat(context.Background(), req, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if gotModel != "my-alias" {
+		t.Errorf("model = %v, want my-alias", gotModel)
+	}
+}
+
+// recordingLogger records the messages and key-value pairs it is given.
+type recordingLogger struct {
+	mu      sync.Mutex
+	entries []map[string]interface{}
+}
+
+func (l *recordingLogger) Log(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{}) {
+	entry := map[string]interface{}{"msg": msg, "level": level}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		entry[keysAndValues[i].(string)] = keysAndValues[i+1]
+	}
+	l.mu.Lock()
+	l.entries = append(l.entries, entry)
+	l.mu.Unlock()
+}
+
+func TestClient_MaxRequestBytes(t *testing.T) {
+	requests := 0
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	logger := &recordingLogger{}
+	client := NewClient(Config{
+		BaseURL:         mockServer.URL(),
+		APIKey:          "test-key",
+		Logger:          logger,
+		MaxRequestBytes: 512,
+	})
+
+	small := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+	if _, err := client.Chat(context.Background(), small, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	large := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: strings.Repeat("x", 1024)}},
+	}
+	_, err := client.Chat(context.Background(), large, nil)
+	var tooLarge *RequestTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("Chat() error = %v, want RequestTooLargeError", err)
+	}
+	if tooLarge.Limit != 512 || tooLarge.Size <= 1024 || tooLarge.Path != "/v1/chat/completions" {
+		t.Errorf("RequestTooLargeError = %+v", tooLarge)
+	}
+	if requests != 1 {
+		t.Errorf("server received %d requests, want 1", requests)
+	}
+
+	var sizes []interface{}
+	for _, entry := range logger.entries {
+		if entry["msg"] == "request body size" {
+			sizes = append(sizes, entry["bytes"])
+		}
+	}
+	if len(sizes) != 2 || sizes[1] != tooLarge.Size {
+		t.Errorf("logged request sizes = %v, want 2 entries ending with %d", sizes, tooLarge.Size)
+	}
+}
+
+// intervalLimiter is a RateLimiter that allows one request per interval.
+type intervalLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *intervalLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(at.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestClient_RateLimiter(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	failures := 1
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		fail := failures > 0
+		failures--
+		mu.Unlock()
+		if fail {
+			testutil.ErrorHandler(http.StatusServiceUnavailable, "server_error", "try again")(w, r)
+			return
+		}
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	const interval = 50 * time.Millisecond
+	client := NewClient(Config{
+		BaseURL:     mockServer.URL(),
+		APIKey:      "test-key",
+		RateLimiter: &intervalLimiter{interval: interval},
+	})
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+
+	// The first call retries once, so 4 attempts are rate limited
+	start := time.Now()
+	if _, err := client.Chat(context.Background(), req, WithRetries(1, time.Millisecond)); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := client.Chat(context.Background(), req, nil); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 3*interval {
+		t.Errorf("4 attempts took %v, want at least %v", elapsed, 3*interval)
+	}
+	if requests != 4 {
+		t.Errorf("server received %d requests, want 4", requests)
+	}
+
+	// A cancelled context ends the wait without sending
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Chat(ctx, req, nil); !errors.Is(err, context.Canceled) {
+		t.Errorf("Chat() error = %v, want context.Canceled", err)
+	}
+	if requests != 4 {
+		t.Errorf("server received %d requests, want 4", requests)
+	}
+}
+
+func TestClient_LogsHTTPRequests(t *testing.T) {
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Fail") != "" {
+			testutil.ErrorHandler(http.StatusBadGateway, "server_error", "upstream failed")(w, r)
+			return
+		}
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	logger := &recordingLogger{}
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "secret-key",
+		Logger:  logger,
+	})
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "private prompt"}},
+	}
+
+	if _, err := client.Chat(context.Background(), req, WithRequestID("req-ok")); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	failOpts := &RequestOptions{RequestID: "req-fail", Headers: http.Header{"X-Fail": []string{"1"}}}
+	if _, err := client.Chat(context.Background(), req, failOpts); err == nil {
+		t.Fatal("Chat() error = nil, want error")
+	}
+
+	var logged []map[string]interface{}
+	for _, entry := range logger.entries {
+		if entry["msg"] == "http request" {
+			logged = append(logged, entry)
+		}
+	}
+	if len(logged) != 2 {
+		t.Fatalf("logged %d http request entries, want 2", len(logged))
+	}
+
+	want := []struct {
+		requestID string
+		status    int
+	}{
+		{"req-ok", http.StatusOK},
+		{"req-fail", http.StatusBadGateway},
+	}
+	for i, entry := range logged {
+		if entry["level"] != LogLevelInfo {
+			t.Errorf("entry %d level = %v, want info", i, entry["level"])
+		}
+		if entry["method"] != "POST" || entry["path"] != "/v1/chat/completions" {
+			t.Errorf("entry %d = %s %s, want POST /v1/chat/completions", i, entry["method"], entry["path"])
+		}
+		if entry["status"] != want[i].status || entry["request_id"] != want[i].requestID {
+			t.Errorf("entry %d status = %v, request_id = %v; want %d, %s", i, entry["status"], entry["request_id"], want[i].status, want[i].requestID)
+		}
+		if _, ok := entry["duration"].(time.Duration); !ok {
+			t.Errorf("entry %d duration = %v, want a time.Duration", i, entry["duration"])
+		}
+		for _, v := range entry {
+			if s, ok := v.(string); ok && (strings.Contains(s, "secret-key") || strings.Contains(s, "private prompt")) {
+				t.Errorf("entry %d leaks sensitive data: %v", i, entry)
+			}
+		}
+	}
+}
+
 func TestClient_Messages(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -338,3 +941,123 @@ func TestClient_ErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+// idleCountingTransport counts CloseIdleConnections calls.
+type idleCountingTransport struct {
+	http.RoundTripper
+	closes int
+}
+
+func (t *idleCountingTransport) CloseIdleConnections() {
+	t.closes++
+}
+
+func TestClient_Close(t *testing.T) {
+	connClosed := make(chan struct{}, 1)
+	server := httptest.NewUnstartedServer(testutil.ChatCompletionHandler(testutil.ChatCompletionFixture()))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			select {
+			case connClosed <- struct{}{}:
+			default:
+			}
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+
+	t.Run("owned HTTP client", func(t *testing.T) {
+		client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"})
+		if client.httpClient.Transport == nil || client.httpClient.Transport == http.DefaultTransport {
+			t.Fatal("client without Config.HTTPClient should have its own transport")
+		}
+		if _, err := client.Chat(context.Background(), req, nil); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+
+		if err := client.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		select {
+		case <-connClosed:
+		case <-time.After(time.Second):
+			t.Error("idle connection was not closed")
+		}
+
+		if _, err := client.Chat(context.Background(), req, nil); !errors.Is(err, ErrClientClosed) {
+			t.Errorf("Chat() after Close error = %v, want ErrClientClosed", err)
+		}
+		if err := client.Close(); err != nil {
+			t.Errorf("second Close() error = %v", err)
+		}
+	})
+
+	t.Run("cached capabilities", func(t *testing.T) {
+		capsServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"models": [{"model_id": "openai/gpt-4o"}]}`))
+		}))
+		defer capsServer.Close()
+
+		client := NewClient(Config{BaseURL: capsServer.URL(), APIKey: "test-key"})
+		if _, err := client.GetCapabilities(context.Background(), nil); err != nil {
+			t.Fatalf("GetCapabilities() error = %v", err)
+		}
+
+		client.Close()
+		if _, err := client.GetCapabilities(context.Background(), nil); !errors.Is(err, ErrClientClosed) {
+			t.Errorf("GetCapabilities() after Close error = %v, want ErrClientClosed", err)
+		}
+	})
+
+	t.Run("caller HTTP client", func(t *testing.T) {
+		transport := &idleCountingTransport{RoundTripper: http.DefaultTransport}
+		client := NewClient(Config{
+			BaseURL:    server.URL,
+			APIKey:     "test-key",
+			HTTPClient: &http.Client{Transport: transport},
+		})
+		if _, err := client.Chat(context.Background(), req, nil); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+
+		client.Close()
+		if transport.closes != 0 {
+			t.Errorf("CloseIdleConnections called %d times on a caller-supplied client", transport.closes)
+		}
+		if _, err := client.ListModels(context.Background(), nil, nil); !errors.Is(err, ErrClientClosed) {
+			t.Errorf("ListModels() after Close error = %v, want ErrClientClosed", err)
+		}
+	})
+}
+
+func TestClient_TimeoutWithRequestOptions(t *testing.T) {
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+		Timeout: 50 * time.Millisecond,
+	})
+
+	// Options that don't set a Timeout keep the client default
+	_, err := client.Chat(context.Background(), ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}, WithRequestID("req-123"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Chat() error = %v, want context.DeadlineExceeded", err)
+	}
+}
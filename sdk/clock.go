@@ -0,0 +1,34 @@
+// Package zaguansdk provides a clock abstraction for the Zaguan SDK.
+//
+// This file implements the Clock used by time-dependent logic such as batch
+// polling, capabilities cache expiry, and credit reset calculations. Tests can
+// inject a zaguantest.FakeClock via Config.Clock to control time
+// deterministically.
+package zaguansdk
+
+import "time"
+
+// Clock provides the current time and timers.
+//
+// The default implementation uses the time package. Set Config.Clock to a
+// zaguantest.FakeClock to make time-dependent behavior deterministic in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel that receives the current time once d has elapsed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+// Now returns time.Now().
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After returns time.After(d).
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,403 @@
+// Package zaguansdk provides legacy completions functionality for the Zaguan SDK.
+//
+// This file implements the legacy Completions API (/v1/completions), which
+// generates text from a plain prompt rather than a list of chat messages.
+package zaguansdk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
+)
+
+// CompletionRequest represents a request to the legacy completions endpoint.
+//
+// New code should prefer ChatRequest; this endpoint exists for models and
+// workloads that still use plain text prompts.
+type CompletionRequest struct {
+	// Model is the model identifier to use.
+	// Example: "openai/gpt-3.5-turbo-instruct"
+	// Required.
+	Model string `json:"model"`
+
+	// Prompt is the text or array of texts to complete.
+	// Can be a string or []string.
+	// A []interface{} of strings is converted to []string before sending.
+	// Required.
+	Prompt interface{} `json:"prompt"`
+
+	// Suffix is text that comes after the completion.
+	// Optional.
+	Suffix string `json:"suffix,omitempty"`
+
+	// MaxTokens is the maximum number of tokens to generate.
+	// Optional.
+	MaxTokens *int `json:"max_tokens,omitempty"`
+
+	// Temperature controls randomness (0.0 - 2.0).
+	// Optional.
+	Temperature *float64 `json:"temperature,omitempty"`
+
+	// TopP controls nucleus sampling (0.0 - 1.0).
+	// Optional.
+	TopP *float64 `json:"top_p,omitempty"`
+
+	// N is the number of completions to generate for each prompt.
+	// Optional.
+	N *int `json:"n,omitempty"`
+
+	// Stream enables streaming responses.
+	// Use CompletionStream() method instead of CreateCompletion() when this is true.
+	// Optional.
+	Stream bool `json:"stream,omitempty"`
+
+	// Logprobs is the number of most likely tokens to return log
+	// probabilities for at each position (0 - 5).
+	// Optional.
+	Logprobs *int `json:"logprobs,omitempty"`
+
+	// Echo includes the prompt in the returned text.
+	// Optional.
+	Echo bool `json:"echo,omitempty"`
+
+	// Stop is a string or array of strings where generation stops.
+	// Optional.
+	Stop interface{} `json:"stop,omitempty"`
+
+	// PresencePenalty penalizes new tokens based on presence (-2.0 - 2.0).
+	// Optional.
+	PresencePenalty *float64 `json:"presence_penalty,omitempty"`
+
+	// FrequencyPenalty penalizes new tokens based on frequency (-2.0 - 2.0).
+	// Optional.
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+
+	// Seed for deterministic sampling.
+	// Optional.
+	Seed *int `json:"seed,omitempty"`
+
+	// User is a unique identifier for the end-user.
+	// Optional.
+	User string `json:"user,omitempty"`
+}
+
+// CompletionResponse represents a response from the legacy completions endpoint.
+type CompletionResponse struct {
+	// ID is the unique identifier for this completion.
+	ID string `json:"id"`
+
+	// Object is the object type (always "text_completion").
+	Object string `json:"object"`
+
+	// Created is the Unix timestamp of when the completion was created.
+	Created int64 `json:"created"`
+
+	// Model is the model used for the completion.
+	Model string `json:"model"`
+
+	// Choices is the list of completion choices.
+	Choices []CompletionChoice `json:"choices"`
+
+	// Usage contains token usage information.
+	// In a CompletionStream it is only sent in the final event, if at all.
+	Usage *Usage `json:"usage,omitempty"`
+
+	// SystemFingerprint identifies the backend configuration.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+}
+
+// CompletionChoice represents a single completion choice.
+type CompletionChoice struct {
+	// Index is the index of this choice.
+	Index int `json:"index"`
+
+	// Text is the generated text. In a CompletionStream it holds the
+	// incremental text of the event.
+	Text string `json:"text"`
+
+	// Logprobs contains log probabilities (if requested).
+	Logprobs interface{} `json:"logprobs,omitempty"`
+
+	// FinishReason indicates why the generation stopped.
+	// Values: "stop", "length", "content_filter"
+	FinishReason string `json:"finish_reason,omitempty"`
+}
+
+// CreateCompletion sends a request to the legacy completions endpoint.
+//
+// Example:
+//
+//	maxTokens := 64
+//	resp, err := client.CreateCompletion(ctx, zaguansdk.CompletionRequest{
+//		Model:     "openai/gpt-3.5-turbo-instruct",
+//		Prompt:    "Write a haiku about Go:",
+//		MaxTokens: &maxTokens,
+//	}, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(resp.Choices[0].Text)
+func (c *Client) CreateCompletion(ctx context.Context, req CompletionRequest, opts *RequestOptions) (*CompletionResponse, error) {
+	// Validate request
+	if err := validateCompletionRequest(&req); err != nil {
+		return nil, err
+	}
+	req.Stream = false
+
+	c.log(ctx, LogLevelDebug, "sending completion request", "model", req.Model)
+
+	// Build request config
+	reqCfg := internal.RequestConfig{
+		Operation: "zaguan.CreateCompletion",
+		Method:    "POST",
+		Path:      "/v1/completions",
+		Body:      req,
+	}
+
+	// Apply request options
+	applyRequestOptions(&reqCfg, opts, c.timeout)
+
+	// Execute request
+	ctx, span := c.startSpan(ctx, "zaguan.CreateCompletion", req.Model)
+	var resp CompletionResponse
+	if err := c.internalHTTP.DoJSON(ctx, reqCfg, &resp); err != nil {
+		span.end(err)
+		c.log(ctx, LogLevelError, "completion request failed", "error", err)
+		return nil, err
+	}
+	if resp.Usage != nil {
+		span.setUsage(resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	}
+	span.end(nil)
+
+	c.log(ctx, LogLevelDebug, "completion request succeeded",
+		"model", resp.Model,
+		"choices", len(resp.Choices))
+
+	return &resp, nil
+}
+
+// CompletionStream represents a streaming legacy completion response.
+//
+// Use Recv() to read events from the stream and Close() to clean up resources.
+type CompletionStream struct {
+	reader *bufio.Reader
+	resp   *http.Response
+	ctx    context.Context
+	closed bool
+	eof    bool
+	usage  *Usage
+	span   *operationSpan
+	err    error // terminal error, returned by Err
+	event  *CompletionResponse
+}
+
+// completionStreamChunk is a CompletionResponse as decoded from the wire,
+// where the gateway may send an error object in place of a chunk.
+type completionStreamChunk struct {
+	CompletionResponse
+	Error json.RawMessage `json:"error"`
+}
+
+// CompletionStream sends a streaming request to the legacy completions
+// endpoint.
+//
+// Example:
+//
+//	stream, err := client.CompletionStream(ctx, zaguansdk.CompletionRequest{
+//		Model:  "openai/gpt-3.5-turbo-instruct",
+//		Prompt: "Once upon a time",
+//	}, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer stream.Close()
+//
+//	for stream.Next() {
+//		if event := stream.Current(); len(event.Choices) > 0 {
+//			fmt.Print(event.Choices[0].Text)
+//		}
+//	}
+//	if err := stream.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+func (c *Client) CompletionStream(ctx context.Context, req CompletionRequest, opts *RequestOptions) (*CompletionStream, error) {
+	// Validate request
+	if err := validateCompletionRequest(&req); err != nil {
+		return nil, err
+	}
+
+	if c.checkStreamingSupport {
+		if err := c.precheckStreaming(ctx, req.Model, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	// Ensure stream is true
+	req.Stream = true
+
+	c.log(ctx, LogLevelDebug, "sending streaming completion request", "model", req.Model)
+
+	// Build request config
+	reqCfg := internal.RequestConfig{
+		Operation: "zaguan.CompletionStream",
+		Method:    "POST",
+		Path:      "/v1/completions",
+		Body:      req,
+	}
+
+	// Apply request options
+	applyRequestOptions(&reqCfg, opts, c.streamTimeout)
+
+	// Execute request
+	ctx, span := c.startSpan(ctx, "zaguan.CompletionStream", req.Model)
+	resp, err := c.internalHTTP.Do(ctx, reqCfg)
+	if err != nil {
+		span.end(err)
+		c.log(ctx, LogLevelError, "streaming completion request failed", "error", err)
+		return nil, err
+	}
+
+	// Check for error status codes
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		err := c.internalHTTP.ParseErrorResponse(resp)
+		span.end(err)
+		return nil, err
+	}
+
+	c.log(ctx, LogLevelDebug, "streaming completion request started")
+
+	return &CompletionStream{
+		reader: bufio.NewReader(resp.Body),
+		resp:   resp,
+		ctx:    ctx,
+		span:   span,
+	}, nil
+}
+
+// Recv reads the next event from the completion stream.
+//
+// Returns io.EOF when the stream is complete. Errors other than io.EOF end
+// the stream: later calls return the same error, which is also reported by
+// Err.
+func (s *CompletionStream) Recv() (*CompletionResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.closed {
+		return nil, errors.New("stream is closed")
+	}
+
+	// Check context
+	if err := s.ctx.Err(); err != nil {
+		return nil, s.fail(err)
+	}
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				s.eof = true
+				_ = s.Close() // Explicitly ignore error in cleanup
+				return nil, err
+			}
+			return nil, s.fail(err)
+		}
+
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+
+		// Check for stream end
+		if data == "[DONE]" {
+			s.eof = true
+			_ = s.Close() // Explicitly ignore error in cleanup
+			return nil, io.EOF
+		}
+
+		// Parse JSON event, checking for an error emitted mid-stream
+		var chunk completionStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, s.fail(fmt.Errorf("failed to parse stream event: %w", err))
+		}
+		if err := internal.ParseStreamError(chunk.Error, s.resp); err != nil {
+			return nil, s.fail(err)
+		}
+		event := chunk.CompletionResponse
+
+		if event.Usage != nil {
+			s.usage = event.Usage
+		}
+
+		return &event, nil
+	}
+}
+
+// Next advances the stream to the next event, which is then available from
+// Current. It returns false when the stream ends or fails; Err distinguishes
+// the two.
+func (s *CompletionStream) Next() bool {
+	event, err := s.Recv()
+	if err != nil {
+		s.event = nil
+		return false
+	}
+	s.event = event
+	return true
+}
+
+// Current returns the event read by the last successful call to Next.
+func (s *CompletionStream) Current() *CompletionResponse {
+	return s.event
+}
+
+// Err returns the error that ended the stream, or nil if the stream
+// completed cleanly or is still open.
+func (s *CompletionStream) Err() error {
+	return s.err
+}
+
+// fail records err as the stream's terminal error, closes the stream, and
+// returns err.
+func (s *CompletionStream) fail(err error) error {
+	s.err = err
+	_ = s.Close() // Explicitly ignore error in cleanup
+	return err
+}
+
+// Usage returns the token usage reported by the stream.
+//
+// Usage is only sent in the final event, so this returns nil until Recv has
+// returned io.EOF. It also returns nil if the provider did not report usage.
+func (s *CompletionStream) Usage() *Usage {
+	if !s.eof {
+		return nil
+	}
+	return s.usage
+}
+
+// Close closes the stream and releases resources.
+func (s *CompletionStream) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.usage != nil {
+		s.span.setUsage(s.usage.PromptTokens, s.usage.CompletionTokens)
+	}
+	s.span.end(s.err)
+	if s.resp != nil && s.resp.Body != nil {
+		return s.resp.Body.Close()
+	}
+	return nil
+}
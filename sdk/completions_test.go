@@ -0,0 +1,246 @@
+package zaguansdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
+)
+
+func TestCreateCompletion(t *testing.T) {
+	maxTokens := 16
+	badTemp := 3.0
+	badLogprobs := 6
+
+	tests := []struct {
+		name           string
+		request        CompletionRequest
+		mockStatusCode int
+		wantErr        bool
+		wantField      string
+	}{
+		{
+			name: "string prompt",
+			request: CompletionRequest{
+				Model:     "openai/gpt-3.5-turbo-instruct",
+				Prompt:    "Say hello",
+				MaxTokens: &maxTokens,
+			},
+			mockStatusCode: http.StatusOK,
+		},
+		{
+			name: "array prompt from decoded JSON",
+			request: CompletionRequest{
+				Model:  "openai/gpt-3.5-turbo-instruct",
+				Prompt: []interface{}{"one", "two"},
+			},
+			mockStatusCode: http.StatusOK,
+		},
+		{
+			name:      "missing model",
+			request:   CompletionRequest{Prompt: "Say hello"},
+			wantErr:   true,
+			wantField: "model",
+		},
+		{
+			name:      "missing prompt",
+			request:   CompletionRequest{Model: "openai/gpt-3.5-turbo-instruct"},
+			wantErr:   true,
+			wantField: "prompt",
+		},
+		{
+			name: "non-string prompt element",
+			request: CompletionRequest{
+				Model:  "openai/gpt-3.5-turbo-instruct",
+				Prompt: []interface{}{"one", 2},
+			},
+			wantErr:   true,
+			wantField: "prompt",
+		},
+		{
+			name: "temperature out of range",
+			request: CompletionRequest{
+				Model:       "openai/gpt-3.5-turbo-instruct",
+				Prompt:      "Say hello",
+				Temperature: &badTemp,
+			},
+			wantErr:   true,
+			wantField: "temperature",
+		},
+		{
+			name: "logprobs out of range",
+			request: CompletionRequest{
+				Model:    "openai/gpt-3.5-turbo-instruct",
+				Prompt:   "Say hello",
+				Logprobs: &badLogprobs,
+			},
+			wantErr:   true,
+			wantField: "logprobs",
+		},
+		{
+			name: "API error",
+			request: CompletionRequest{
+				Model:  "openai/gpt-3.5-turbo-instruct",
+				Prompt: "Say hello",
+			},
+			mockStatusCode: http.StatusBadRequest,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/completions" {
+					t.Errorf("Expected path /v1/completions, got %s", r.URL.Path)
+				}
+
+				var body map[string]interface{}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Fatalf("failed to decode request: %v", err)
+				}
+				if _, ok := body["stream"]; ok {
+					t.Errorf("stream = %v, want omitted", body["stream"])
+				}
+
+				w.WriteHeader(tt.mockStatusCode)
+				if tt.mockStatusCode == http.StatusOK {
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"id":      "cmpl-1",
+						"object":  "text_completion",
+						"model":   "gpt-3.5-turbo-instruct",
+						"choices": []map[string]interface{}{{"index": 0, "text": "Hello!", "finish_reason": "stop"}},
+						"usage":   map[string]interface{}{"prompt_tokens": 2, "completion_tokens": 2, "total_tokens": 4},
+					})
+				} else {
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"error": map[string]interface{}{
+							"message": "Bad request",
+							"type":    "invalid_request_error",
+						},
+					})
+				}
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"})
+			resp, err := client.CreateCompletion(context.Background(), tt.request, nil)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Expected error, got nil")
+				}
+				if tt.wantField != "" {
+					ve, ok := err.(*ValidationError)
+					if !ok {
+						t.Fatalf("CreateCompletion() error = %v, want *ValidationError", err)
+					}
+					if ve.Field != tt.wantField {
+						t.Errorf("ValidationError.Field = %q, want %q", ve.Field, tt.wantField)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(resp.Choices) != 1 || resp.Choices[0].Text != "Hello!" {
+				t.Errorf("Choices = %+v, want one choice with text %q", resp.Choices, "Hello!")
+			}
+			if resp.Usage == nil || resp.Usage.TotalTokens != 4 {
+				t.Errorf("Usage = %+v, want TotalTokens 4", resp.Usage)
+			}
+		})
+	}
+}
+
+func TestCompletionStream(t *testing.T) {
+	server := testutil.NewMockServer(testutil.StreamingHandler([]string{
+		`{"id":"cmpl-1","object":"text_completion","choices":[{"index":0,"text":"Hel"}]}`,
+		`{"id":"cmpl-1","object":"text_completion","choices":[{"index":0,"text":"lo","finish_reason":"stop"}],"usage":{"prompt_tokens":2,"completion_tokens":2,"total_tokens":4}}`,
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL(), APIKey: "test-key"})
+	stream, err := client.CompletionStream(context.Background(), CompletionRequest{
+		Model:  "openai/gpt-3.5-turbo-instruct",
+		Prompt: "Say hello",
+	}, nil)
+	if err != nil {
+		t.Fatalf("CompletionStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var text string
+	for stream.Next() {
+		if event := stream.Current(); len(event.Choices) > 0 {
+			text += event.Choices[0].Text
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+	if text != "Hello" {
+		t.Errorf("streamed text = %q, want %q", text, "Hello")
+	}
+	if usage := stream.Usage(); usage == nil || usage.TotalTokens != 4 {
+		t.Errorf("Usage() = %+v, want TotalTokens 4", usage)
+	}
+}
+
+func TestCompletionStream_MidStreamError(t *testing.T) {
+	server := testutil.NewMockServer(testutil.StreamingHandler([]string{
+		`{"error":{"type":"rate_limit_exceeded","message":"slow down"}}`,
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL(), APIKey: "test-key"})
+	stream, err := client.CompletionStream(context.Background(), CompletionRequest{
+		Model:  "openai/gpt-3.5-turbo-instruct",
+		Prompt: "Say hello",
+	}, nil)
+	if err != nil {
+		t.Fatalf("CompletionStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if stream.Next() {
+		t.Fatal("Next() = true, want false")
+	}
+	if _, ok := stream.Err().(*RateLimitError); !ok {
+		t.Errorf("Err() = %T, want *RateLimitError", stream.Err())
+	}
+}
+
+func TestCompletionStream_StreamTimeout(t *testing.T) {
+	event := `{"id":"cmpl-1","object":"text_completion","choices":[{"index":0,"text":"a"}]}`
+	server := testutil.NewMockServer(slowStreamingHandler([]string{event, event, event, event}, 20*time.Millisecond))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:       server.URL(),
+		APIKey:        "test-key",
+		StreamTimeout: 50 * time.Millisecond,
+	})
+
+	// Options without a Timeout keep Config.StreamTimeout
+	stream, err := client.CompletionStream(context.Background(), CompletionRequest{
+		Model:  "openai/gpt-3.5-turbo-instruct",
+		Prompt: "Say hello",
+	}, WithRequestID("req-123"))
+	if err != nil {
+		t.Fatalf("CompletionStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+	if stream.Err() == nil {
+		t.Error("Err() = nil, want an error once StreamTimeout elapses")
+	}
+}
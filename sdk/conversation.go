@@ -0,0 +1,322 @@
+// Package zaguansdk provides a portable conversation format for the Zaguan SDK.
+//
+// This file implements Conversation, a provider-independent representation of
+// a chat session that can be saved as versioned JSON and converted to and from
+// both OpenAI-style []Message and Anthropic-style []AnthropicMessage.
+package zaguansdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ConversationFormatVersion is the version written by Conversation.MarshalJSON.
+// UnmarshalJSON rejects data with a newer version.
+const ConversationFormatVersion = 1
+
+// Conversation is a provider-independent chat session.
+//
+// Its JSON form is stable and versioned, so sessions can be persisted and
+// reloaded across SDK upgrades and provider switches. Only text, tool calls,
+// and tool results are kept; images and other provider-specific content are
+// dropped.
+//
+// Example:
+//
+//	conv := zaguansdk.ConversationFromMessages(req.Messages)
+//	data, err := json.Marshal(conv)
+//	// ... later ...
+//	var restored zaguansdk.Conversation
+//	if err := json.Unmarshal(data, &restored); err != nil {
+//		log.Fatal(err)
+//	}
+//	system, messages := restored.AnthropicMessages()
+type Conversation struct {
+	// System is the system prompt. System and developer messages are merged
+	// into it, separated by blank lines.
+	System string
+
+	// Turns are the conversation turns in order.
+	Turns []ConversationTurn
+}
+
+// ConversationTurn is a single turn of a Conversation.
+type ConversationTurn struct {
+	// Role is the turn role.
+	// Values: "user", "assistant", "tool"
+	Role string `json:"role"`
+
+	// Text is the text content of the turn.
+	Text string `json:"text,omitempty"`
+
+	// ToolCalls are the tool calls made by an assistant turn.
+	ToolCalls []ConversationToolCall `json:"tool_calls,omitempty"`
+
+	// ToolCallID is the tool call a tool turn answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// IsError marks a tool turn as a failed tool execution.
+	IsError bool `json:"is_error,omitempty"`
+}
+
+// ConversationToolCall is a tool call made by an assistant turn.
+type ConversationToolCall struct {
+	// ID is the tool call identifier.
+	ID string `json:"id"`
+
+	// Name is the tool name.
+	Name string `json:"name"`
+
+	// Arguments is the JSON-encoded tool input.
+	Arguments string `json:"arguments"`
+}
+
+// conversationJSON is the serialized form of a Conversation.
+type conversationJSON struct {
+	Version int                `json:"version"`
+	System  string             `json:"system,omitempty"`
+	Turns   []ConversationTurn `json:"turns"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (c Conversation) MarshalJSON() ([]byte, error) {
+	turns := c.Turns
+	if turns == nil {
+		turns = []ConversationTurn{}
+	}
+	return json.Marshal(conversationJSON{
+		Version: ConversationFormatVersion,
+		System:  c.System,
+		Turns:   turns,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *Conversation) UnmarshalJSON(data []byte) error {
+	var raw conversationJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Version < 1 || raw.Version > ConversationFormatVersion {
+		return fmt.Errorf("unsupported conversation format version %d", raw.Version)
+	}
+	c.System = raw.System
+	c.Turns = raw.Turns
+	return nil
+}
+
+// ConversationFromMessages builds a Conversation from chat completion messages.
+func ConversationFromMessages(messages []Message) Conversation {
+	var conv Conversation
+	var system []string
+
+	for _, msg := range messages {
+		text, _ := contentText(msg.Content)
+
+		switch msg.Role {
+		case "system", "developer":
+			system = append(system, text)
+		case "tool":
+			conv.Turns = append(conv.Turns, ConversationTurn{
+				Role:       "tool",
+				Text:       text,
+				ToolCallID: msg.ToolCallID,
+			})
+		default:
+			turn := ConversationTurn{Role: msg.Role, Text: text}
+			for _, tc := range msg.ToolCalls {
+				turn.ToolCalls = append(turn.ToolCalls, ConversationToolCall{
+					ID:        tc.ID,
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				})
+			}
+			conv.Turns = append(conv.Turns, turn)
+		}
+	}
+
+	conv.System = strings.Join(system, "\n\n")
+	return conv
+}
+
+// ConversationFromAnthropic builds a Conversation from a Messages API system
+// prompt and messages.
+//
+// Tool results in user messages become separate tool turns.
+func ConversationFromAnthropic(system string, messages []AnthropicMessage) Conversation {
+	conv := Conversation{System: system}
+
+	for _, msg := range messages {
+		blocks := anthropicBlocks(msg.Content)
+
+		turn := ConversationTurn{Role: msg.Role}
+		var toolTurns []ConversationTurn
+		var text strings.Builder
+		for _, block := range blocks {
+			switch block.Type {
+			case "text":
+				text.WriteString(block.Text)
+			case "tool_use":
+				args, err := json.Marshal(block.Input)
+				if err != nil || block.Input == nil {
+					args = []byte("{}")
+				}
+				turn.ToolCalls = append(turn.ToolCalls, ConversationToolCall{
+					ID:        block.ID,
+					Name:      block.Name,
+					Arguments: string(args),
+				})
+			case "tool_result":
+				result, _ := anthropicToolResultText(block.Content)
+				toolTurns = append(toolTurns, ConversationTurn{
+					Role:       "tool",
+					Text:       result,
+					ToolCallID: block.ToolUseID,
+					IsError:    block.IsError,
+				})
+			}
+		}
+		turn.Text = text.String()
+
+		conv.Turns = append(conv.Turns, toolTurns...)
+		if turn.Text != "" || len(turn.ToolCalls) > 0 || len(toolTurns) == 0 {
+			conv.Turns = append(conv.Turns, turn)
+		}
+	}
+
+	return conv
+}
+
+// Messages converts the conversation to chat completion messages. A non-empty
+// System becomes a leading system message.
+func (c Conversation) Messages() []Message {
+	messages := make([]Message, 0, len(c.Turns)+1)
+	if c.System != "" {
+		messages = append(messages, Message{Role: "system", Content: c.System})
+	}
+
+	for _, turn := range c.Turns {
+		msg := Message{Role: turn.Role, ToolCallID: turn.ToolCallID}
+		if turn.Text != "" || len(turn.ToolCalls) == 0 {
+			msg.Content = turn.Text
+		}
+		for _, tc := range turn.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+				ID:   tc.ID,
+				Type: "function",
+				Function: FunctionCall{
+					Name:      tc.Name,
+					Arguments: tc.Arguments,
+				},
+			})
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages
+}
+
+// AnthropicMessages converts the conversation to a Messages API system prompt
+// and messages.
+//
+// Tool turns become tool_result blocks in a user message; consecutive tool
+// turns share one message, as the Messages API requires.
+func (c Conversation) AnthropicMessages() (string, []AnthropicMessage) {
+	var messages []AnthropicMessage
+
+	for _, turn := range c.Turns {
+		switch {
+		case turn.Role == "tool":
+			block := AnthropicContentBlock{
+				Type:      "tool_result",
+				ToolUseID: turn.ToolCallID,
+				Content:   turn.Text,
+				IsError:   turn.IsError,
+			}
+			// Merge into a preceding message of tool results
+			if n := len(messages); n > 0 && messages[n-1].Role == "user" {
+				if blocks, ok := messages[n-1].Content.([]AnthropicContentBlock); ok && isToolResultBlocks(blocks) {
+					messages[n-1].Content = append(blocks, block)
+					continue
+				}
+			}
+			messages = append(messages, AnthropicMessage{
+				Role:    "user",
+				Content: []AnthropicContentBlock{block},
+			})
+		case len(turn.ToolCalls) > 0:
+			var blocks []AnthropicContentBlock
+			if turn.Text != "" {
+				blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: turn.Text})
+			}
+			for _, tc := range turn.ToolCalls {
+				var input interface{} = map[string]interface{}{}
+				if json.Valid([]byte(tc.Arguments)) {
+					input = json.RawMessage(tc.Arguments)
+				}
+				blocks = append(blocks, AnthropicContentBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Name,
+					Input: input,
+				})
+			}
+			messages = append(messages, AnthropicMessage{Role: turn.Role, Content: blocks})
+		default:
+			messages = append(messages, AnthropicMessage{Role: turn.Role, Content: turn.Text})
+		}
+	}
+
+	return c.System, messages
+}
+
+// anthropicBlocks returns the content blocks of Messages API content, which
+// may be a string, []AnthropicContentBlock, or blocks decoded from JSON.
+func anthropicBlocks(content interface{}) []AnthropicContentBlock {
+	switch content := content.(type) {
+	case nil:
+		return nil
+	case string:
+		return []AnthropicContentBlock{{Type: "text", Text: content}}
+	case []AnthropicContentBlock:
+		return content
+	}
+
+	// Decoded or custom content; round-trip through JSON
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil
+	}
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return nil
+	}
+	return blocks
+}
+
+// anthropicToolResultText extracts the text of a tool_result block's content.
+func anthropicToolResultText(content interface{}) (string, bool) {
+	if text, ok := content.(string); ok {
+		return text, true
+	}
+	var sb strings.Builder
+	found := false
+	for _, block := range anthropicBlocks(content) {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+			found = true
+		}
+	}
+	return sb.String(), found
+}
+
+// isToolResultBlocks reports whether blocks contains only tool results.
+func isToolResultBlocks(blocks []AnthropicContentBlock) bool {
+	for _, block := range blocks {
+		if block.Type != "tool_result" {
+			return false
+		}
+	}
+	return len(blocks) > 0
+}
@@ -0,0 +1,132 @@
+package zaguansdk
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testConversation() Conversation {
+	return Conversation{
+		System: "You are a weather bot.",
+		Turns: []ConversationTurn{
+			{Role: "user", Text: "Weather in Paris and Rome?"},
+			{Role: "assistant", Text: "Checking.", ToolCalls: []ConversationToolCall{
+				{ID: "call_1", Name: "get_weather", Arguments: `{"city":"Paris"}`},
+				{ID: "call_2", Name: "get_weather", Arguments: `{"city":"Rome"}`},
+			}},
+			{Role: "tool", Text: "18C", ToolCallID: "call_1"},
+			{Role: "tool", Text: "service unavailable", ToolCallID: "call_2", IsError: true},
+			{Role: "assistant", Text: "Paris is 18C; Rome is unknown."},
+		},
+	}
+}
+
+func TestConversation_JSONRoundTrip(t *testing.T) {
+	conv := testConversation()
+
+	data, err := json.Marshal(conv)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if !strings.Contains(string(data), `"version":1`) {
+		t.Errorf("Marshal() = %s, want version 1", data)
+	}
+
+	var got Conversation
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, conv) {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, conv)
+	}
+}
+
+func TestConversation_UnmarshalVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{name: "current", data: `{"version":1,"turns":[]}`, wantErr: false},
+		{name: "missing", data: `{"turns":[]}`, wantErr: true},
+		{name: "newer", data: `{"version":2,"turns":[]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conv Conversation
+			err := json.Unmarshal([]byte(tt.data), &conv)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConversation_Messages(t *testing.T) {
+	messages := testConversation().Messages()
+
+	if len(messages) != 6 {
+		t.Fatalf("Messages() len = %d, want 6", len(messages))
+	}
+	if messages[0].Role != "system" || messages[0].Content != "You are a weather bot." {
+		t.Errorf("Messages()[0] = %+v, want system prompt", messages[0])
+	}
+	if len(messages[2].ToolCalls) != 2 || messages[2].ToolCalls[1].Function.Arguments != `{"city":"Rome"}` {
+		t.Errorf("Messages()[2].ToolCalls = %+v, want two tool calls", messages[2].ToolCalls)
+	}
+	if messages[3].Role != "tool" || messages[3].ToolCallID != "call_1" {
+		t.Errorf("Messages()[3] = %+v, want tool result for call_1", messages[3])
+	}
+
+	// Converting back keeps everything except the tool error flag
+	want := testConversation()
+	want.Turns[3].IsError = false
+	if got := ConversationFromMessages(messages); !reflect.DeepEqual(got, want) {
+		t.Errorf("ConversationFromMessages() = %+v, want %+v", got, want)
+	}
+}
+
+func TestConversation_AnthropicMessages(t *testing.T) {
+	system, messages := testConversation().AnthropicMessages()
+
+	if system != "You are a weather bot." {
+		t.Errorf("system = %q, want the system prompt", system)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("AnthropicMessages() len = %d, want 4", len(messages))
+	}
+
+	toolUse, ok := messages[1].Content.([]AnthropicContentBlock)
+	if !ok || len(toolUse) != 3 || toolUse[1].Type != "tool_use" || toolUse[2].ID != "call_2" {
+		t.Errorf("AnthropicMessages()[1].Content = %+v, want text and two tool_use blocks", messages[1].Content)
+	}
+
+	// Consecutive tool results share one user message
+	results, ok := messages[2].Content.([]AnthropicContentBlock)
+	if messages[2].Role != "user" || !ok || len(results) != 2 || !results[1].IsError {
+		t.Errorf("AnthropicMessages()[2] = %+v, want user message with two tool results", messages[2])
+	}
+
+	if got := ConversationFromAnthropic(system, messages); !reflect.DeepEqual(got, testConversation()) {
+		t.Errorf("ConversationFromAnthropic() = %+v, want %+v", got, testConversation())
+	}
+}
+
+func TestConversationFromAnthropic_DecodedContent(t *testing.T) {
+	data := `[
+		{"role": "user", "content": "Hi"},
+		{"role": "assistant", "content": [{"type": "text", "text": "Hello!"}]}
+	]`
+	var messages []AnthropicMessage
+	if err := json.Unmarshal([]byte(data), &messages); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	conv := ConversationFromAnthropic("", messages)
+	if len(conv.Turns) != 2 || conv.Turns[1].Text != "Hello!" {
+		t.Errorf("ConversationFromAnthropic() = %+v, want two text turns", conv)
+	}
+}
@@ -3,6 +3,7 @@ package zaguansdk
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -39,6 +40,9 @@ type CreditsBalance struct {
 
 	// Warning is an optional warning message (e.g., low credits).
 	Warning string `json:"warning,omitempty"`
+
+	// clock is the clock of the client that fetched the balance.
+	clock Clock
 }
 
 // GetCreditsBalance retrieves the current credit balance and tier information.
@@ -59,24 +63,13 @@ func (c *Client) GetCreditsBalance(ctx context.Context, opts *RequestOptions) (*
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "GET",
-		Path:   "/v1/credits/balance",
+		Operation: "zaguan.GetCreditsBalance",
+		Method:    "GET",
+		Path:      "/v1/credits/balance",
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var balance CreditsBalance
@@ -89,6 +82,7 @@ func (c *Client) GetCreditsBalance(ctx context.Context, opts *RequestOptions) (*
 		"remaining", balance.CreditsRemaining,
 		"tier", balance.Tier)
 
+	balance.clock = c.clock
 	return &balance, nil
 }
 
@@ -205,6 +199,7 @@ func (c *Client) GetCreditsHistory(ctx context.Context, historyOpts *CreditsHist
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
+		Operation:   "zaguan.GetCreditsHistory",
 		Method:      "GET",
 		Path:        "/v1/credits/history",
 		QueryParams: make(map[string]string),
@@ -239,19 +234,7 @@ func (c *Client) GetCreditsHistory(ctx context.Context, historyOpts *CreditsHist
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var history CreditsHistoryResponse
@@ -267,6 +250,92 @@ func (c *Client) GetCreditsHistory(ctx context.Context, historyOpts *CreditsHist
 	return &history, nil
 }
 
+// CreditsHistoryIterator iterates over credit history entries, fetching
+// pages with GetCreditsHistory as needed. Create one with
+// Client.CreditsHistoryIterator.
+type CreditsHistoryIterator struct {
+	client *Client
+	ctx    context.Context
+	opts   CreditsHistoryOptions
+
+	page  []CreditsHistoryEntry
+	index int
+	entry CreditsHistoryEntry
+	done  bool
+	err   error
+}
+
+// CreditsHistoryIterator returns an iterator over all credit history entries
+// matching historyOpts, following NextCursor across pages. historyOpts may
+// be nil; its filters apply to every page, and its Cursor, if set, is where
+// iteration starts.
+//
+// No request is made until the first call to Next.
+//
+// Example:
+//
+//	it := client.CreditsHistoryIterator(ctx, &zaguansdk.CreditsHistoryOptions{
+//		Model: "openai/gpt-4o",
+//	})
+//	for it.Next() {
+//		entry := it.Entry()
+//		fmt.Printf("%s: %d credits\n", entry.Timestamp, entry.CreditsDebited)
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+func (c *Client) CreditsHistoryIterator(ctx context.Context, historyOpts *CreditsHistoryOptions) *CreditsHistoryIterator {
+	it := &CreditsHistoryIterator{client: c, ctx: ctx}
+	if historyOpts != nil {
+		it.opts = *historyOpts
+	}
+	return it
+}
+
+// Next advances to the next entry, fetching the next page if needed. It
+// returns false when all entries have been read or an error occurs; Err
+// distinguishes the two.
+func (it *CreditsHistoryIterator) Next() bool {
+	for it.index >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		history, err := it.client.GetCreditsHistory(it.ctx, &it.opts, nil)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.page = history.Entries
+		it.index = 0
+
+		// Stop when the server reports no more pages or repeats the cursor
+		if !history.HasMore || history.NextCursor == "" || history.NextCursor == it.opts.Cursor {
+			it.done = true
+		}
+		it.opts.Cursor = history.NextCursor
+	}
+
+	it.entry = it.page[it.index]
+	it.index++
+	return true
+}
+
+// Entry returns the entry read by the last successful call to Next.
+func (it *CreditsHistoryIterator) Entry() CreditsHistoryEntry {
+	return it.entry
+}
+
+// Err returns the error that stopped iteration, or nil if all entries were
+// read.
+func (it *CreditsHistoryIterator) Err() error {
+	return it.err
+}
+
 // CreditsStats represents aggregated credit statistics.
 type CreditsStats struct {
 	// Period is the time period for these stats.
@@ -413,6 +482,7 @@ func (c *Client) GetCreditsStats(ctx context.Context, statsOpts *CreditsStatsOpt
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
+		Operation:   "zaguan.GetCreditsStats",
 		Method:      "GET",
 		Path:        "/v1/credits/stats",
 		QueryParams: make(map[string]string),
@@ -435,19 +505,7 @@ func (c *Client) GetCreditsStats(ctx context.Context, statsOpts *CreditsStatsOpt
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var stats CreditsStats
@@ -480,7 +538,11 @@ func (b *CreditsBalance) DaysUntilReset() (int, error) {
 	if resetTime.IsZero() {
 		return 0, nil
 	}
-	duration := time.Until(resetTime)
+	var clock Clock = realClock{}
+	if b.clock != nil {
+		clock = b.clock
+	}
+	duration := resetTime.Sub(clock.Now())
 	return int(duration.Hours() / 24), nil
 }
 
@@ -488,3 +550,72 @@ func (b *CreditsBalance) DaysUntilReset() (int, error) {
 func (b *CreditsBalance) IsLowCredits() bool {
 	return b.CreditsPercent < 10
 }
+
+// ParsePeriod parses StartDate and EndDate into time.Time values. Each may be
+// an RFC 3339 timestamp or a date (2006-01-02); an empty date parses as the
+// zero time.
+func (s *CreditsStats) ParsePeriod() (start, end time.Time, err error) {
+	if start, err = parseStatsDate(s.StartDate); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if end, err = parseStatsDate(s.EndDate); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
+
+// ParseDate parses Date into a time.Time at midnight UTC. RFC 3339
+// timestamps are also accepted. An empty Date parses as the zero time.
+func (d *DailyStats) ParseDate() (time.Time, error) {
+	return parseStatsDate(d.Date)
+}
+
+// parseStatsDate parses an ISO 8601 date or RFC 3339 timestamp.
+func parseStatsDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// DailySorted returns a copy of ByDay sorted chronologically.
+//
+// Dates are ISO 8601 date strings, so lexical order matches chronological order.
+func (s *CreditsStats) DailySorted() []DailyStats {
+	if len(s.ByDay) == 0 {
+		return nil
+	}
+	days := make([]DailyStats, len(s.ByDay))
+	copy(days, s.ByDay)
+	sort.SliceStable(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+	return days
+}
+
+// ProvidersSorted returns the ByProvider stats as a slice sorted by credits used
+// (descending), with ties broken by provider name.
+//
+// If an entry has no Provider set, the map key is used.
+func (s *CreditsStats) ProvidersSorted() []ProviderStats {
+	if len(s.ByProvider) == 0 {
+		return nil
+	}
+	providers := make([]ProviderStats, 0, len(s.ByProvider))
+	for name, ps := range s.ByProvider {
+		if ps.Provider == "" {
+			ps.Provider = name
+		}
+		providers = append(providers, ps)
+	}
+	sort.Slice(providers, func(i, j int) bool {
+		if providers[i].CreditsUsed != providers[j].CreditsUsed {
+			return providers[i].CreditsUsed > providers[j].CreditsUsed
+		}
+		return providers[i].Provider < providers[j].Provider
+	})
+	return providers
+}
@@ -2,11 +2,14 @@ package zaguansdk
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/zaguantest"
 )
 
 func TestClient_GetCreditsBalance(t *testing.T) {
@@ -128,6 +131,89 @@ func TestClient_GetCreditsHistory(t *testing.T) {
 	}
 }
 
+func TestClient_CreditsHistoryIterator(t *testing.T) {
+	requests := 0
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			query := r.URL.Query()
+			if query.Get("model") != "openai/gpt-4o" {
+				t.Errorf("model = %s, want openai/gpt-4o", query.Get("model"))
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			switch query.Get("cursor") {
+			case "":
+				w.Write([]byte(`{
+					"entries": [{"id": "entry_1"}, {"id": "entry_2"}],
+					"has_more": true,
+					"next_cursor": "a+b/c=="
+				}`))
+			case "a+b/c==": // An opaque base64 cursor must arrive intact
+				w.Write([]byte(`{
+					"entries": [{"id": "entry_3"}],
+					"has_more": false
+				}`))
+			default:
+				t.Errorf("unexpected cursor: %s", query.Get("cursor"))
+			}
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	it := client.CreditsHistoryIterator(context.Background(), &CreditsHistoryOptions{
+		Model: "openai/gpt-4o",
+	})
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Entry().ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	want := []string{"entry_1", "entry_2", "entry_3"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("entries = %v, want %v", ids, want)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+}
+
+func TestClient_CreditsHistoryIterator_ContextCancelled(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"entries": [{"id": "entry_1"}], "has_more": true, "next_cursor": "next"}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := client.CreditsHistoryIterator(ctx, nil)
+	if !it.Next() {
+		t.Fatalf("Next() = false, want true (Err() = %v)", it.Err())
+	}
+	cancel()
+	if it.Next() {
+		t.Error("Next() = true after cancel, want false")
+	}
+	if !errors.Is(it.Err(), context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", it.Err())
+	}
+}
+
 func TestClient_GetCreditsStats(t *testing.T) {
 	mockServer := testutil.NewMockServer(
 		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -218,6 +304,87 @@ func TestCreditsBalance_ParseResetDate(t *testing.T) {
 	}
 }
 
+func TestDailyStats_ParseDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		date    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"date", "2025-11-19", time.Date(2025, 11, 19, 0, 0, 0, 0, time.UTC), false},
+		{"RFC 3339", "2025-11-19T12:30:00Z", time.Date(2025, 11, 19, 12, 30, 0, 0, time.UTC), false},
+		{"empty", "", time.Time{}, false},
+		{"malformed", "19/11/2025", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := DailyStats{Date: tt.date}
+			got, err := day.ParseDate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreditsStats_ParsePeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		startDate string
+		endDate   string
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   bool
+	}{
+		{
+			name:      "dates",
+			startDate: "2025-11-01",
+			endDate:   "2025-11-30",
+			wantStart: time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 11, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:      "RFC 3339",
+			startDate: "2025-11-01T00:00:00Z",
+			endDate:   "2025-11-30T23:59:59Z",
+			wantStart: time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2025, 11, 30, 23, 59, 59, 0, time.UTC),
+		},
+		{
+			name: "empty",
+		},
+		{
+			name:      "malformed start",
+			startDate: "November 1",
+			endDate:   "2025-11-30",
+			wantErr:   true,
+		},
+		{
+			name:      "malformed end",
+			startDate: "2025-11-01",
+			endDate:   "2025-13-45",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := CreditsStats{StartDate: tt.startDate, EndDate: tt.endDate}
+			start, end, err := stats.ParsePeriod()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePeriod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !start.Equal(tt.wantStart) || !end.Equal(tt.wantEnd) {
+				t.Errorf("ParsePeriod() = %v, %v, want %v, %v", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
 func TestCreditsBalance_DaysUntilReset(t *testing.T) {
 	// Set reset date to 30 days from now
 	futureDate := time.Now().Add(30 * 24 * time.Hour)
@@ -236,6 +403,39 @@ func TestCreditsBalance_DaysUntilReset(t *testing.T) {
 	}
 }
 
+func TestClient_GetCreditsBalance_DaysUntilResetUsesClock(t *testing.T) {
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"credits_remaining": 100, "tier": "pro", "bands": ["A"], "reset_date": "2025-12-01T00:00:00Z"}`))
+	}))
+	defer mockServer.Close()
+
+	clock := zaguantest.NewFakeClock(time.Date(2025, 11, 21, 0, 0, 0, 0, time.UTC))
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+		Clock:   clock,
+	})
+
+	balance, err := client.GetCreditsBalance(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("GetCreditsBalance() error = %v", err)
+	}
+
+	days, err := balance.DaysUntilReset()
+	if err != nil {
+		t.Fatalf("DaysUntilReset() error = %v", err)
+	}
+	if days != 10 {
+		t.Errorf("DaysUntilReset() = %d, want 10", days)
+	}
+
+	clock.Advance(72 * time.Hour)
+	if days, _ := balance.DaysUntilReset(); days != 7 {
+		t.Errorf("DaysUntilReset() after 3 days = %d, want 7", days)
+	}
+}
+
 func TestCreditsBalance_IsLowCredits(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -312,3 +512,52 @@ func TestCreditsStatsOptions(t *testing.T) {
 		t.Errorf("GroupBy length = %d, want 3", len(opts.GroupBy))
 	}
 }
+
+func TestCreditsStats_DailySorted(t *testing.T) {
+	stats := &CreditsStats{
+		ByDay: []DailyStats{
+			{Date: "2025-01-03", CreditsUsed: 30},
+			{Date: "2025-01-01", CreditsUsed: 10},
+			{Date: "2025-01-02", CreditsUsed: 20},
+		},
+	}
+
+	days := stats.DailySorted()
+	want := []string{"2025-01-01", "2025-01-02", "2025-01-03"}
+	if len(days) != len(want) {
+		t.Fatalf("DailySorted() length = %d, want %d", len(days), len(want))
+	}
+	for i, d := range days {
+		if d.Date != want[i] {
+			t.Errorf("days[%d].Date = %s, want %s", i, d.Date, want[i])
+		}
+	}
+	if stats.ByDay[0].Date != "2025-01-03" {
+		t.Error("DailySorted() should not modify ByDay")
+	}
+
+	if (&CreditsStats{}).DailySorted() != nil {
+		t.Error("DailySorted() on empty stats should return nil")
+	}
+}
+
+func TestCreditsStats_ProvidersSorted(t *testing.T) {
+	stats := &CreditsStats{
+		ByProvider: map[string]ProviderStats{
+			"openai":    {Provider: "openai", CreditsUsed: 100},
+			"anthropic": {Provider: "anthropic", CreditsUsed: 300},
+			"google":    {CreditsUsed: 100},
+		},
+	}
+
+	providers := stats.ProvidersSorted()
+	want := []string{"anthropic", "google", "openai"}
+	if len(providers) != len(want) {
+		t.Fatalf("ProvidersSorted() length = %d, want %d", len(providers), len(want))
+	}
+	for i, p := range providers {
+		if p.Provider != want[i] {
+			t.Errorf("providers[%d].Provider = %s, want %s", i, p.Provider, want[i])
+		}
+	}
+}
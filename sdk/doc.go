@@ -135,7 +135,7 @@
 //
 // Discover available models and their capabilities:
 //
-//	models, err := client.ListModels(ctx, nil)
+//	models, err := client.ListModels(ctx, nil, nil)
 //	caps, err := client.GetCapabilities(ctx, nil)
 //	supportsVision := client.SupportsVision(ctx, "openai/gpt-4o", nil)
 //
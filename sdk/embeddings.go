@@ -7,6 +7,12 @@ package zaguansdk
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
 
 	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
 )
@@ -23,6 +29,7 @@ type EmbeddingsRequest struct {
 
 	// Input is the text or array of texts to embed.
 	// Can be a string or []string.
+	// A []interface{} of strings is converted to []string before sending.
 	// Required.
 	Input interface{} `json:"input"`
 
@@ -114,32 +121,25 @@ func (c *Client) CreateEmbeddings(ctx context.Context, req EmbeddingsRequest, op
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/embeddings",
-		Body:   req,
+		Operation: "zaguan.CreateEmbeddings",
+		Method:    "POST",
+		Path:      "/v1/embeddings",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
+	ctx, span := c.startSpan(ctx, "zaguan.CreateEmbeddings", req.Model)
 	var resp EmbeddingsResponse
 	if err := c.internalHTTP.DoJSON(ctx, reqCfg, &resp); err != nil {
+		span.end(err)
 		c.log(ctx, LogLevelError, "create embeddings request failed", "error", err)
 		return nil, err
 	}
+	span.setUsage(resp.Usage.PromptTokens, 0)
+	span.end(nil)
 
 	c.log(ctx, LogLevelDebug, "create embeddings request succeeded",
 		"model", resp.Model,
@@ -148,10 +148,189 @@ func (c *Client) CreateEmbeddings(ctx context.Context, req EmbeddingsRequest, op
 	return &resp, nil
 }
 
+// CreateEmbeddingsBatched creates embeddings for a large number of inputs by
+// splitting them into chunks of batchSize and issuing one CreateEmbeddings
+// call per chunk, sequentially.
+//
+// The results are merged into a single response: Data is in input order with
+// each Index referring to the position in inputs, and Usage is summed across
+// all calls. If any call fails, the error is returned and no partial response
+// is produced.
+//
+// Example:
+//
+//	resp, err := client.CreateEmbeddingsBatched(ctx, "openai/text-embedding-3-small", docs, 100, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Printf("%d embeddings, %d tokens\n", len(resp.Data), resp.Usage.TotalTokens)
+func (c *Client) CreateEmbeddingsBatched(ctx context.Context, model string, inputs []string, batchSize int, opts *RequestOptions) (*EmbeddingsResponse, error) {
+	if len(inputs) == 0 {
+		return nil, &ValidationError{Field: "inputs", Message: "inputs cannot be empty"}
+	}
+	if batchSize <= 0 {
+		return nil, &ValidationError{Field: "batch_size", Message: "batch_size must be positive"}
+	}
+
+	c.log(ctx, LogLevelDebug, "creating batched embeddings",
+		"model", model,
+		"count", len(inputs),
+		"batch_size", batchSize)
+
+	merged := &EmbeddingsResponse{
+		Object: "list",
+		Data:   make([]Embedding, 0, len(inputs)),
+	}
+
+	for start := 0; start < len(inputs); start += batchSize {
+		end := start + batchSize
+		if end > len(inputs) {
+			end = len(inputs)
+		}
+
+		resp, err := c.CreateEmbeddings(ctx, EmbeddingsRequest{
+			Model: model,
+			Input: inputs[start:end],
+		}, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Shift indexes from the chunk to the full input slice
+		for _, emb := range resp.Data {
+			emb.Index += start
+			merged.Data = append(merged.Data, emb)
+		}
+		merged.Model = resp.Model
+		merged.Usage.PromptTokens += resp.Usage.PromptTokens
+		merged.Usage.TotalTokens += resp.Usage.TotalTokens
+	}
+
+	// Providers may return a chunk's embeddings out of order
+	sort.SliceStable(merged.Data, func(i, j int) bool {
+		return merged.Data[i].Index < merged.Data[j].Index
+	})
+
+	return merged, nil
+}
+
+// CreateEmbeddingsConcurrent creates embeddings for a large number of inputs
+// by issuing one CreateEmbeddings call per input, with up to concurrency
+// calls in flight at once.
+//
+// The results are merged as in CreateEmbeddingsBatched: Data is in input
+// order with each Index referring to the position in inputs, and Usage is
+// summed across all calls. If any call fails, the calls still in flight are
+// cancelled and the first error is returned, with no partial response.
+//
+// Example:
+//
+//	resp, err := client.CreateEmbeddingsConcurrent(ctx, "openai/text-embedding-3-small", docs, 8, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Printf("%d embeddings, %d tokens\n", len(resp.Data), resp.Usage.TotalTokens)
+func (c *Client) CreateEmbeddingsConcurrent(ctx context.Context, model string, inputs []string, concurrency int, opts *RequestOptions) (*EmbeddingsResponse, error) {
+	if len(inputs) == 0 {
+		return nil, &ValidationError{Field: "inputs", Message: "inputs cannot be empty"}
+	}
+	if concurrency <= 0 {
+		return nil, &ValidationError{Field: "concurrency", Message: "concurrency must be positive"}
+	}
+	if concurrency > len(inputs) {
+		concurrency = len(inputs)
+	}
+
+	c.log(ctx, LogLevelDebug, "creating concurrent embeddings",
+		"model", model,
+		"count", len(inputs),
+		"concurrency", concurrency)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*EmbeddingsResponse, len(inputs))
+	indexes := make(chan int)
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if ctx.Err() != nil {
+					continue
+				}
+				resp, err := c.CreateEmbeddings(ctx, EmbeddingsRequest{
+					Model: model,
+					Input: inputs[i],
+				}, opts)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					mu.Unlock()
+					continue
+				}
+				results[i] = resp
+			}
+		}()
+	}
+
+feed:
+	for i := range inputs {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	merged := &EmbeddingsResponse{
+		Object: "list",
+		Data:   make([]Embedding, 0, len(inputs)),
+	}
+	for i, resp := range results {
+		for _, emb := range resp.Data {
+			emb.Index = i
+			merged.Data = append(merged.Data, emb)
+		}
+		merged.Model = resp.Model
+		merged.Usage.PromptTokens += resp.Usage.PromptTokens
+		merged.Usage.TotalTokens += resp.Usage.TotalTokens
+	}
+
+	return merged, nil
+}
+
 // GetEmbeddingVector is a helper that extracts the float64 vector from an Embedding.
 //
-// Returns an error if the embedding is not in float format.
+// Both encoding formats are supported: float embeddings are converted directly,
+// and base64 embeddings are decoded with DecodeBase64.
+// Returns an error if the embedding is in neither format.
 func (e *Embedding) GetEmbeddingVector() ([]float64, error) {
+	if _, ok := e.Embedding.(string); ok {
+		return e.DecodeBase64()
+	}
+	if vec, ok := e.Embedding.([]float64); ok {
+		return vec, nil
+	}
+
 	vec, ok := e.Embedding.([]interface{})
 	if !ok {
 		return nil, &APIError{
@@ -177,6 +356,47 @@ func (e *Embedding) GetEmbeddingVector() ([]float64, error) {
 	return result, nil
 }
 
+// DecodeBase64 decodes an embedding returned with EncodingFormat "base64".
+//
+// The decoded bytes are interpreted as little-endian float32 values (the
+// OpenAI convention) and converted to float64.
+// Returns an error if the embedding is not a base64 string or its decoded
+// length is not a multiple of 4 bytes.
+func (e *Embedding) DecodeBase64() ([]float64, error) {
+	encoded, ok := e.Embedding.(string)
+	if !ok {
+		return nil, &APIError{
+			StatusCode: 0,
+			Message:    "embedding is not in base64 format",
+			Type:       "invalid_format",
+		}
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, &APIError{
+			StatusCode: 0,
+			Message:    "embedding is not valid base64: " + err.Error(),
+			Type:       "invalid_format",
+		}
+	}
+	if len(raw)%4 != 0 {
+		return nil, &APIError{
+			StatusCode: 0,
+			Message:    "base64 embedding length is not a multiple of 4 bytes",
+			Type:       "invalid_format",
+		}
+	}
+
+	result := make([]float64, len(raw)/4)
+	for i := range result {
+		bits := binary.LittleEndian.Uint32(raw[i*4:])
+		result[i] = float64(math.Float32frombits(bits))
+	}
+
+	return result, nil
+}
+
 // CosineSimilarity calculates the cosine similarity between two embedding vectors.
 //
 // Returns a value between -1 and 1, where 1 means identical, 0 means orthogonal,
@@ -205,5 +425,230 @@ func CosineSimilarity(a, b []float64) (float64, error) {
 		}
 	}
 
-	return dotProduct / (normA * normB), nil
+	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
+
+// WeightedCosineSimilarity calculates the cosine similarity between two
+// embedding vectors with each dimension scaled by a weight.
+//
+// Weights must be non-negative and have the same length as the vectors.
+// With all weights equal it matches CosineSimilarity.
+func WeightedCosineSimilarity(a, b, weights []float64) (float64, error) {
+	if len(a) != len(b) || len(weights) != len(a) {
+		return 0, &APIError{
+			StatusCode: 0,
+			Message:    "vectors and weights must have the same length",
+			Type:       "invalid_input",
+		}
+	}
+
+	var dotProduct, normA, normB float64
+	for i := range a {
+		w := weights[i]
+		if w < 0 {
+			return 0, &APIError{
+				StatusCode: 0,
+				Message:    fmt.Sprintf("weights[%d] is negative", i),
+				Type:       "invalid_input",
+			}
+		}
+		dotProduct += w * a[i] * b[i]
+		normA += w * a[i] * a[i]
+		normB += w * b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0, &APIError{
+			StatusCode: 0,
+			Message:    "cannot compute similarity with zero vector",
+			Type:       "invalid_input",
+		}
+	}
+
+	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
+
+// Metric selects how NearestNeighborsWithMetric compares vectors.
+type Metric int
+
+const (
+	// MetricCosine ranks by CosineSimilarity, highest first.
+	MetricCosine Metric = iota
+
+	// MetricDotProduct ranks by DotProduct, highest first. Use it for
+	// normalized vectors or models trained for dot-product similarity.
+	MetricDotProduct
+
+	// MetricEuclidean ranks by EuclideanDistance, lowest first.
+	MetricEuclidean
+)
+
+// String returns the name of the metric.
+func (m Metric) String() string {
+	switch m {
+	case MetricCosine:
+		return "cosine"
+	case MetricDotProduct:
+		return "dot_product"
+	case MetricEuclidean:
+		return "euclidean"
+	default:
+		return fmt.Sprintf("Metric(%d)", int(m))
+	}
+}
+
+// score computes the metric between a and b. m must be a known metric.
+func (m Metric) score(a, b []float64) (float64, error) {
+	switch m {
+	case MetricCosine:
+		return CosineSimilarity(a, b)
+	case MetricDotProduct:
+		return DotProduct(a, b)
+	default:
+		return EuclideanDistance(a, b)
+	}
+}
+
+// Neighbor is a corpus vector ranked by NearestNeighbors.
+type Neighbor struct {
+	// Index is the position of the vector in the corpus.
+	Index int
+
+	// Score is the metric value between the vector and the query: the
+	// cosine similarity for NearestNeighbors.
+	Score float64
+}
+
+// NearestNeighbors returns the k corpus vectors most similar to query, ranked
+// by cosine similarity in descending order. Ties keep corpus order.
+//
+// If k is larger than the corpus, all vectors are returned. Every corpus
+// vector must have the same dimensions as the query.
+//
+// Example:
+//
+//	neighbors, err := zaguansdk.NearestNeighbors(queryVec, docVecs, 5)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, n := range neighbors {
+//		fmt.Printf("%s (%.3f)\n", docs[n.Index], n.Score)
+//	}
+func NearestNeighbors(query []float64, corpus [][]float64, k int) ([]Neighbor, error) {
+	return NearestNeighborsWithMetric(query, corpus, k, MetricCosine)
+}
+
+// NearestNeighborsWithMetric is like NearestNeighbors but compares vectors
+// with metric. Neighbors are ranked most similar first: descending for
+// MetricCosine and MetricDotProduct, ascending for MetricEuclidean.
+func NearestNeighborsWithMetric(query []float64, corpus [][]float64, k int, metric Metric) ([]Neighbor, error) {
+	if k <= 0 {
+		return nil, &APIError{
+			StatusCode: 0,
+			Message:    "k must be positive",
+			Type:       "invalid_input",
+		}
+	}
+	if metric < MetricCosine || metric > MetricEuclidean {
+		return nil, &APIError{
+			StatusCode: 0,
+			Message:    fmt.Sprintf("unknown metric %s", metric),
+			Type:       "invalid_input",
+		}
+	}
+
+	neighbors := make([]Neighbor, 0, len(corpus))
+	for i, vec := range corpus {
+		if len(vec) != len(query) {
+			return nil, &APIError{
+				StatusCode: 0,
+				Message:    fmt.Sprintf("corpus[%d] has %d dimensions, query has %d", i, len(vec), len(query)),
+				Type:       "invalid_input",
+			}
+		}
+		score, err := metric.score(query, vec)
+		if err != nil {
+			return nil, err
+		}
+		neighbors = append(neighbors, Neighbor{Index: i, Score: score})
+	}
+
+	sort.SliceStable(neighbors, func(i, j int) bool {
+		if metric == MetricEuclidean {
+			return neighbors[i].Score < neighbors[j].Score
+		}
+		return neighbors[i].Score > neighbors[j].Score
+	})
+
+	if k < len(neighbors) {
+		neighbors = neighbors[:k]
+	}
+	return neighbors, nil
+}
+
+// DotProduct calculates the dot product of two embedding vectors.
+//
+// For vectors that have been normalized with Normalize, the dot product equals
+// the cosine similarity and is cheaper to compute.
+func DotProduct(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, &APIError{
+			StatusCode: 0,
+			Message:    "vectors must have the same length",
+			Type:       "invalid_input",
+		}
+	}
+
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+
+	return sum, nil
+}
+
+// EuclideanDistance calculates the Euclidean (L2) distance between two
+// embedding vectors.
+//
+// Returns 0 for identical vectors; larger values mean less similar vectors.
+func EuclideanDistance(a, b []float64) (float64, error) {
+	if len(a) != len(b) {
+		return 0, &APIError{
+			StatusCode: 0,
+			Message:    "vectors must have the same length",
+			Type:       "invalid_input",
+		}
+	}
+
+	var sum float64
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+
+	return math.Sqrt(sum), nil
+}
+
+// Normalize returns a copy of v scaled to unit length.
+//
+// Pre-normalizing vectors lets DotProduct be used in place of
+// CosineSimilarity. A zero vector is returned unchanged (as a copy).
+func Normalize(v []float64) []float64 {
+	result := make([]float64, len(v))
+
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	if norm == 0 {
+		copy(result, v)
+		return result
+	}
+
+	norm = math.Sqrt(norm)
+	for i, x := range v {
+		result[i] = x / norm
+	}
+
+	return result
 }
@@ -3,9 +3,13 @@ package zaguansdk
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestCreateEmbeddings(t *testing.T) {
@@ -190,6 +194,181 @@ func TestCreateEmbeddings(t *testing.T) {
 	}
 }
 
+func TestCreateEmbeddingsBatched(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		// Return the chunk in reverse order; each vector holds the input text length
+		resp := EmbeddingsResponse{Object: "list", Model: "text-embedding-3-small"}
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, Embedding{
+				Object:    "embedding",
+				Embedding: []interface{}{float64(len(req.Input[i]))},
+				Index:     i,
+			})
+		}
+		resp.Usage = EmbeddingsUsage{PromptTokens: len(req.Input), TotalTokens: len(req.Input)}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL: server.URL,
+		APIKey:  "test-key",
+	})
+
+	inputs := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	resp, err := client.CreateEmbeddingsBatched(context.Background(), "openai/text-embedding-3-small", inputs, 2, nil)
+	if err != nil {
+		t.Fatalf("CreateEmbeddingsBatched() error = %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("server calls = %d, want 3", calls)
+	}
+	if len(resp.Data) != len(inputs) {
+		t.Fatalf("Data length = %d, want %d", len(resp.Data), len(inputs))
+	}
+	for i, emb := range resp.Data {
+		if emb.Index != i {
+			t.Errorf("Data[%d].Index = %d, want %d", i, emb.Index, i)
+		}
+		vec, err := emb.GetEmbeddingVector()
+		if err != nil {
+			t.Fatalf("GetEmbeddingVector() error = %v", err)
+		}
+		if int(vec[0]) != len(inputs[i]) {
+			t.Errorf("Data[%d] belongs to input of length %d, want %d", i, int(vec[0]), len(inputs[i]))
+		}
+	}
+	if resp.Usage.PromptTokens != 5 || resp.Usage.TotalTokens != 5 {
+		t.Errorf("Usage = %+v, want 5 prompt and 5 total tokens", resp.Usage)
+	}
+
+	if _, err := client.CreateEmbeddingsBatched(context.Background(), "openai/text-embedding-3-small", inputs, 0, nil); err == nil {
+		t.Error("CreateEmbeddingsBatched() with batchSize 0 should fail")
+	}
+}
+
+func TestCreateEmbeddingsConcurrent(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+
+		var req struct {
+			Input string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		value, _ := strconv.Atoi(req.Input)
+
+		// Later inputs finish first, so responses arrive out of order
+		time.Sleep(time.Duration(10-value) * 2 * time.Millisecond)
+
+		json.NewEncoder(w).Encode(EmbeddingsResponse{
+			Object: "list",
+			Model:  "text-embedding-3-small",
+			Data:   []Embedding{{Object: "embedding", Embedding: []float64{float64(value)}}},
+			Usage:  EmbeddingsUsage{PromptTokens: 1, TotalTokens: 1},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	inputs := make([]string, 10)
+	for i := range inputs {
+		inputs[i] = strconv.Itoa(i)
+	}
+
+	resp, err := client.CreateEmbeddingsConcurrent(context.Background(), "openai/text-embedding-3-small", inputs, 4, nil)
+	if err != nil {
+		t.Fatalf("CreateEmbeddingsConcurrent() error = %v", err)
+	}
+
+	if len(resp.Data) != len(inputs) {
+		t.Fatalf("len(Data) = %d, want %d", len(resp.Data), len(inputs))
+	}
+	for i, emb := range resp.Data {
+		vec, err := emb.GetEmbeddingVector()
+		if err != nil {
+			t.Fatalf("GetEmbeddingVector() error = %v", err)
+		}
+		if emb.Index != i || vec[0] != float64(i) {
+			t.Errorf("Data[%d] = index %d, vector %v, want index %d, vector [%d]", i, emb.Index, vec, i, i)
+		}
+	}
+	if resp.Usage.TotalTokens != len(inputs) {
+		t.Errorf("Usage.TotalTokens = %d, want %d", resp.Usage.TotalTokens, len(inputs))
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got > 4 || got < 2 {
+		t.Errorf("max concurrent requests = %d, want between 2 and 4", got)
+	}
+}
+
+func TestCreateEmbeddingsConcurrent_Error(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		var req struct {
+			Input string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Input == "bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": map[string]interface{}{"message": "bad input", "type": "invalid_request_error"},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(EmbeddingsResponse{
+			Object: "list",
+			Data:   []Embedding{{Object: "embedding", Embedding: []float64{1}}},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"})
+
+	inputs := []string{"bad"}
+	for i := 0; i < 50; i++ {
+		inputs = append(inputs, "ok")
+	}
+
+	_, err := client.CreateEmbeddingsConcurrent(context.Background(), "openai/text-embedding-3-small", inputs, 1, nil)
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("CreateEmbeddingsConcurrent() error = %v, want the 400 APIError", err)
+	}
+	// Remaining inputs are not sent once a call fails
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("server received %d requests, want 1", got)
+	}
+
+	if _, err := client.CreateEmbeddingsConcurrent(context.Background(), "openai/text-embedding-3-small", inputs, 0, nil); err == nil {
+		t.Error("CreateEmbeddingsConcurrent() should reject zero concurrency")
+	}
+}
+
 func TestGetEmbeddingVector(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -205,6 +384,14 @@ func TestGetEmbeddingVector(t *testing.T) {
 			wantLen: 5,
 			wantErr: false,
 		},
+		{
+			name: "base64 vector",
+			embedding: Embedding{
+				Embedding: "AACAPwAAIMAAAAA/",
+			},
+			wantLen: 3,
+			wantErr: false,
+		},
 		{
 			name: "invalid type",
 			embedding: Embedding{
@@ -244,6 +431,62 @@ func TestGetEmbeddingVector(t *testing.T) {
 	}
 }
 
+func TestEmbedding_DecodeBase64(t *testing.T) {
+	tests := []struct {
+		name      string
+		embedding interface{}
+		want      []float64
+		wantErr   bool
+	}{
+		{
+			name:      "little-endian float32 values",
+			embedding: "AACAPwAAIMAAAAA/",
+			want:      []float64{1.0, -2.5, 0.5},
+		},
+		{
+			name:      "empty string",
+			embedding: "",
+			want:      []float64{},
+		},
+		{
+			name:      "invalid base64",
+			embedding: "!!!",
+			wantErr:   true,
+		},
+		{
+			name:      "length not a multiple of 4",
+			embedding: "AAAAAAA=",
+			wantErr:   true,
+		},
+		{
+			name:      "float format",
+			embedding: []interface{}{0.1, 0.2},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Embedding{Embedding: tt.embedding}
+			got, err := e.DecodeBase64()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeBase64() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("DecodeBase64() length = %d, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("DecodeBase64()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
 func TestCosineSimilarity(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -270,6 +513,12 @@ func TestCosineSimilarity(t *testing.T) {
 			b:    []float64{-1.0, 0.0},
 			want: -1.0,
 		},
+		{
+			name: "non-unit vectors",
+			a:    []float64{3.0, 4.0},
+			b:    []float64{6.0, 8.0},
+			want: 1.0,
+		},
 		{
 			name:    "different lengths",
 			a:       []float64{1.0, 0.0},
@@ -308,6 +557,206 @@ func TestCosineSimilarity(t *testing.T) {
 	}
 }
 
+func TestDotProduct(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       []float64
+		b       []float64
+		want    float64
+		wantErr bool
+	}{
+		{"basic", []float64{1, 2, 3}, []float64{4, 5, 6}, 32, false},
+		{"orthogonal", []float64{1, 0}, []float64{0, 1}, 0, false},
+		{"empty", []float64{}, []float64{}, 0, false},
+		{"different lengths", []float64{1, 2}, []float64{1}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DotProduct(tt.a, tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DotProduct() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if apiErr, ok := err.(*APIError); !ok || apiErr.Type != "invalid_input" {
+					t.Errorf("DotProduct() error = %v, want *APIError with type invalid_input", err)
+				}
+				return
+			}
+			if abs(got-tt.want) > 0.0001 {
+				t.Errorf("DotProduct() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEuclideanDistance(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       []float64
+		b       []float64
+		want    float64
+		wantErr bool
+	}{
+		{"identical", []float64{1, 2, 3}, []float64{1, 2, 3}, 0, false},
+		{"3-4-5 triangle", []float64{0, 0}, []float64{3, 4}, 5, false},
+		{"different lengths", []float64{1, 2}, []float64{1, 2, 3}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EuclideanDistance(tt.a, tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EuclideanDistance() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if abs(got-tt.want) > 0.0001 {
+				t.Errorf("EuclideanDistance() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	v := []float64{3, 4}
+	got := Normalize(v)
+
+	if abs(got[0]-0.6) > 0.0001 || abs(got[1]-0.8) > 0.0001 {
+		t.Errorf("Normalize() = %v, want [0.6 0.8]", got)
+	}
+	if v[0] != 3 || v[1] != 4 {
+		t.Error("Normalize() should not modify its input")
+	}
+
+	// Dot product of normalized vectors matches cosine similarity
+	a, b := []float64{1, 2, 3}, []float64{4, -5, 6}
+	cos, _ := CosineSimilarity(a, b)
+	dot, _ := DotProduct(Normalize(a), Normalize(b))
+	if abs(cos-dot) > 0.0001 {
+		t.Errorf("DotProduct(Normalize) = %f, want CosineSimilarity %f", dot, cos)
+	}
+
+	zero := Normalize([]float64{0, 0})
+	if zero[0] != 0 || zero[1] != 0 {
+		t.Errorf("Normalize(zero) = %v, want [0 0]", zero)
+	}
+}
+
+func TestNearestNeighbors(t *testing.T) {
+	corpus := [][]float64{
+		{0, 1},  // orthogonal
+		{1, 0},  // identical
+		{-1, 0}, // opposite
+		{1, 1},  // 45 degrees
+	}
+	query := []float64{2, 0}
+
+	tests := []struct {
+		name        string
+		k           int
+		wantIndexes []int
+	}{
+		{"top 2", 2, []int{1, 3}},
+		{"k larger than corpus", 10, []int{1, 3, 0, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NearestNeighbors(query, corpus, tt.k)
+			if err != nil {
+				t.Fatalf("NearestNeighbors() error = %v", err)
+			}
+			if len(got) != len(tt.wantIndexes) {
+				t.Fatalf("NearestNeighbors() returned %d results, want %d", len(got), len(tt.wantIndexes))
+			}
+			for i, n := range got {
+				if n.Index != tt.wantIndexes[i] {
+					t.Errorf("result[%d].Index = %d, want %d", i, n.Index, tt.wantIndexes[i])
+				}
+			}
+			if abs(got[0].Score-1.0) > 0.0001 {
+				t.Errorf("top Score = %f, want 1.0", got[0].Score)
+			}
+		})
+	}
+
+	if _, err := NearestNeighbors(query, [][]float64{{1, 0}, {1, 0, 0}}, 1); err == nil {
+		t.Error("NearestNeighbors() with mismatched dimensions should fail")
+	}
+	if _, err := NearestNeighbors(query, corpus, 0); err == nil {
+		t.Error("NearestNeighbors() with k=0 should fail")
+	}
+	if got, err := NearestNeighbors(query, nil, 3); err != nil || len(got) != 0 {
+		t.Errorf("NearestNeighbors() on empty corpus = %v, %v; want empty result", got, err)
+	}
+}
+
+func TestWeightedCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    []float64
+		weights []float64
+		want    float64
+		wantErr bool
+	}{
+		{name: "equal weights match cosine", a: []float64{1, 1}, b: []float64{1, 0}, weights: []float64{2, 2}, want: 1 / math.Sqrt2},
+		{name: "zero weight ignores dimension", a: []float64{1, 5}, b: []float64{1, -3}, weights: []float64{1, 0}, want: 1},
+		{name: "weights length mismatch", a: []float64{1, 0}, b: []float64{1, 0}, weights: []float64{1}, wantErr: true},
+		{name: "negative weight", a: []float64{1, 0}, b: []float64{1, 0}, weights: []float64{1, -1}, wantErr: true},
+		{name: "zero weighted norm", a: []float64{0, 1}, b: []float64{1, 0}, weights: []float64{1, 0}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := WeightedCosineSimilarity(tt.a, tt.b, tt.weights)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("WeightedCosineSimilarity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && abs(got-tt.want) > 0.0001 {
+				t.Errorf("WeightedCosineSimilarity() = %f, want %f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNearestNeighborsWithMetric(t *testing.T) {
+	corpus := [][]float64{
+		{1, 0},  // same direction, close
+		{10, 0}, // same direction, far
+		{0, 1},  // orthogonal, close
+	}
+	query := []float64{1, 0}
+
+	tests := []struct {
+		metric      Metric
+		wantIndexes []int
+	}{
+		{MetricCosine, []int{0, 1, 2}},
+		{MetricDotProduct, []int{1, 0, 2}},
+		{MetricEuclidean, []int{0, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.metric.String(), func(t *testing.T) {
+			got, err := NearestNeighborsWithMetric(query, corpus, 3, tt.metric)
+			if err != nil {
+				t.Fatalf("NearestNeighborsWithMetric() error = %v", err)
+			}
+			for i, n := range got {
+				if n.Index != tt.wantIndexes[i] {
+					t.Errorf("result[%d].Index = %d, want %d", i, n.Index, tt.wantIndexes[i])
+				}
+			}
+		})
+	}
+
+	if _, err := NearestNeighborsWithMetric(query, corpus, 1, Metric(99)); err == nil {
+		t.Error("NearestNeighborsWithMetric() with unknown metric should fail")
+	}
+}
+
 func abs(x float64) float64 {
 	if x < 0 {
 		return -x
@@ -367,6 +816,30 @@ func TestValidateEmbeddingsRequest(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "interface array input",
+			req: EmbeddingsRequest{
+				Model: "test-model",
+				Input: []interface{}{"Hello", "World"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "interface array with non-string",
+			req: EmbeddingsRequest{
+				Model: "test-model",
+				Input: []interface{}{"Hello", 42},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported input type",
+			req: EmbeddingsRequest{
+				Model: "test-model",
+				Input: map[string]string{"text": "Hello"},
+			},
+			wantErr: true,
+		},
 		{
 			name: "invalid encoding format",
 			req: EmbeddingsRequest{
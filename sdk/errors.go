@@ -1,125 +1,175 @@
 package zaguansdk
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"strings"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
 )
 
-// APIError represents an error returned by the Zaguan CoreX API.
-//
-// It includes the HTTP status code, error message, request ID for debugging,
-// and an optional error type/code for programmatic handling.
-type APIError struct {
-	// StatusCode is the HTTP status code returned by the API.
-	StatusCode int
+// ErrClientClosed is returned by client methods called after Shutdown or Close.
+var ErrClientClosed = internal.ErrClientClosed
 
-	// Message is the human-readable error message.
-	Message string
+// ErrCircuitOpen is returned without sending a request while the circuit
+// breaker configured by Config.CircuitBreaker is open.
+var ErrCircuitOpen = internal.ErrCircuitOpen
 
-	// RequestID is the unique identifier for this request.
-	// Include this when reporting issues to Zaguan support.
-	RequestID string
+// Sentinel errors for classifying API errors with errors.Is, regardless of
+// the concrete error type returned.
+//
+// Example:
+//
+//	if errors.Is(err, zaguansdk.ErrRateLimited) {
+//		time.Sleep(time.Second)
+//	}
+var (
+	// ErrInsufficientCredits matches errors caused by insufficient credits.
+	ErrInsufficientCredits = internal.ErrInsufficientCredits
 
-	// Type is the error type/code for programmatic handling.
-	// Examples: "insufficient_credits", "band_access_denied", "rate_limit_exceeded"
-	Type string
+	// ErrBandAccessDenied matches errors caused by band access restrictions.
+	ErrBandAccessDenied = internal.ErrBandAccessDenied
 
-	// Code is an optional error code (may be the same as Type).
-	Code string
+	// ErrRateLimited matches rate limit errors.
+	ErrRateLimited = internal.ErrRateLimited
 
-	// Param is the parameter that caused the error, if applicable.
-	Param string
+	// ErrAuthentication matches authentication failures.
+	ErrAuthentication = internal.ErrAuthentication
 
-	// Details contains additional error details from the API.
-	Details map[string]interface{}
-}
+	// ErrNotFound matches errors for resources that were not found.
+	ErrNotFound = internal.ErrNotFound
+)
 
-// Error implements the error interface.
-func (e *APIError) Error() string {
-	if e.RequestID != "" {
-		return fmt.Sprintf("zaguan API error (%d) [%s]: %s", e.StatusCode, e.RequestID, e.Message)
+// IsRetryable reports whether a request that failed with err may succeed if
+// sent again. It is the classification used by the built-in retries
+// (RequestOptions.MaxRetries), for callers writing their own retry loops.
+//
+// Rate limit errors, overloaded errors (status 529 or type
+// "overloaded_error"), statuses 408, 500, 502, 503, and 504, network errors,
+// and attempts that timed out before the caller's context was done are
+// retryable. Validation, authentication, permission, credit, and other 4xx
+// errors are not, nor are other server errors such as 501, a canceled or
+// expired context, or a closed client.
+//
+// Example:
+//
+//	for attempt := 0; attempt < 3; attempt++ {
+//		resp, err = client.Chat(ctx, req, nil)
+//		if err == nil || !zaguansdk.IsRetryable(err) {
+//			break
+//		}
+//		time.Sleep(time.Duration(attempt+1) * time.Second)
+//	}
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrClientClosed) {
+		return false
 	}
-	return fmt.Sprintf("zaguan API error (%d): %s", e.StatusCode, e.Message)
-}
-
-// IsInsufficientCredits returns true if this error is due to insufficient credits.
-func (e *APIError) IsInsufficientCredits() bool {
-	return e.Type == "insufficient_credits" || e.Code == "insufficient_credits"
-}
-
-// IsBandAccessDenied returns true if this error is due to band access restrictions.
-func (e *APIError) IsBandAccessDenied() bool {
-	return e.Type == "band_access_denied" || e.Code == "band_access_denied"
-}
-
-// IsRateLimitExceeded returns true if this error is due to rate limiting.
-func (e *APIError) IsRateLimitExceeded() bool {
-	return e.Type == "rate_limit_exceeded" || e.Code == "rate_limit_exceeded"
-}
 
-// IsAuthenticationError returns true if this error is due to authentication failure.
-func (e *APIError) IsAuthenticationError() bool {
-	return e.StatusCode == 401 || e.Type == "authentication_error"
-}
+	var rateLimitErr *RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Type == "overloaded_error" || apiErr.StatusCode == internal.StatusOverloaded {
+			return true
+		}
+		return internal.RetryableStatus(apiErr.StatusCode)
+	}
 
-// IsPermissionError returns true if this error is due to insufficient permissions.
-func (e *APIError) IsPermissionError() bool {
-	return e.StatusCode == 403 || e.Type == "permission_error"
+	if errors.Is(err, context.DeadlineExceeded) {
+		return internal.IsAttemptTimeout(err)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
 }
 
-// IsNotFoundError returns true if the requested resource was not found.
-func (e *APIError) IsNotFoundError() bool {
-	return e.StatusCode == 404
-}
+// RequestTooLargeError is returned when a request body exceeds
+// Config.MaxRequestBytes. The request is not sent.
+//
+// Example:
+//
+//	var tooLarge *zaguansdk.RequestTooLargeError
+//	if errors.As(err, &tooLarge) {
+//		log.Printf("request is %d bytes, limit is %d", tooLarge.Size, tooLarge.Limit)
+//	}
+type RequestTooLargeError = internal.RequestTooLargeError
 
-// IsServerError returns true if this is a server-side error (5xx).
-func (e *APIError) IsServerError() bool {
-	return e.StatusCode >= 500 && e.StatusCode < 600
-}
+// APIError represents an error returned by the Zaguan CoreX API.
+//
+// It includes the HTTP status code, error message, request ID for debugging,
+// and an optional error type/code for programmatic handling.
+//
+// Example:
+//
+//	var apiErr *zaguansdk.APIError
+//	if errors.As(err, &apiErr) {
+//		log.Printf("request %s failed: %s", apiErr.RequestID, apiErr.Message)
+//	}
+type APIError = internal.APIError
 
 // InsufficientCreditsError represents an error when the user has insufficient credits.
 //
 // This is a specialized error type that includes credit balance information.
-type InsufficientCreditsError struct {
-	APIError
-	CreditsRequired  int
-	CreditsRemaining int
-	ResetDate        string
-}
-
-// Error implements the error interface.
-func (e *InsufficientCreditsError) Error() string {
-	return fmt.Sprintf("insufficient credits: required %d, remaining %d (resets on %s)",
-		e.CreditsRequired, e.CreditsRemaining, e.ResetDate)
-}
+type InsufficientCreditsError = internal.InsufficientCreditsError
 
 // BandAccessError represents an error when the user's tier doesn't have access to a band.
 //
 // This is a specialized error type that includes tier and band information.
-type BandAccessError struct {
-	APIError
-	Band         string
-	RequiredTier string
-	CurrentTier  string
+type BandAccessError = internal.BandAccessError
+
+// RateLimitError represents a rate limit error.
+//
+// This is a specialized error type that includes retry-after information.
+type RateLimitError = internal.RateLimitError
+
+// ContentBlockedError is returned when content is blocked by a moderation
+// check before it reaches the model.
+type ContentBlockedError struct {
+	// Categories lists the moderation categories that caused the block.
+	Categories []string
+
+	// Result is the moderation result for the first blocked input.
+	Result ModerationResult
 }
 
 // Error implements the error interface.
-func (e *BandAccessError) Error() string {
-	return fmt.Sprintf("band access denied: %s tier does not have access to band %s (requires %s tier)",
-		e.CurrentTier, e.Band, e.RequiredTier)
+func (e *ContentBlockedError) Error() string {
+	if len(e.Categories) == 0 {
+		return "content blocked by moderation"
+	}
+	return fmt.Sprintf("content blocked by moderation: %s", strings.Join(e.Categories, ", "))
 }
 
-// RateLimitError represents a rate limit error.
+// StreamToolCallError reports tool calls that arrived corrupt in a stream.
 //
-// This is a specialized error type that includes retry-after information.
-type RateLimitError struct {
-	APIError
-	RetryAfter int // Seconds to wait before retrying
+// It is returned by ChatStreamAccumulator.Validate when tool-call indices are
+// not contiguous (a call was skipped or a frame was dropped) or when a call's
+// arguments are not complete JSON at the end of the stream.
+// MessagesStreamAccumulator.Validate returns it with the content block indices
+// of tool_use blocks whose input is not complete JSON.
+type StreamToolCallError struct {
+	// MissingIndices are tool-call indices that never appeared in the stream
+	// although a higher index did.
+	MissingIndices []int
+
+	// InvalidIndices are tool-call indices whose arguments are not valid JSON.
+	InvalidIndices []int
 }
 
 // Error implements the error interface.
-func (e *RateLimitError) Error() string {
-	if e.RetryAfter > 0 {
-		return fmt.Sprintf("rate limit exceeded: retry after %d seconds", e.RetryAfter)
+func (e *StreamToolCallError) Error() string {
+	var parts []string
+	if len(e.MissingIndices) > 0 {
+		parts = append(parts, fmt.Sprintf("missing tool call indices %v", e.MissingIndices))
+	}
+	if len(e.InvalidIndices) > 0 {
+		parts = append(parts, fmt.Sprintf("unterminated or invalid arguments at indices %v", e.InvalidIndices))
 	}
-	return "rate limit exceeded"
+	return "stream tool calls corrupted: " + strings.Join(parts, "; ")
 }
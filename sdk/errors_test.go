@@ -1,7 +1,14 @@
 package zaguansdk
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
 )
 
 func TestAPIError_Error(t *testing.T) {
@@ -347,3 +354,229 @@ func TestValidationError_Error(t *testing.T) {
 		t.Errorf("ValidationError.Error() = %v, want %v", got, expected)
 	}
 }
+
+func TestAPIError_Is(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{"credits by type", &APIError{StatusCode: 400, Type: "insufficient_credits"}, ErrInsufficientCredits, true},
+		{"credits by status", &APIError{StatusCode: 402}, ErrInsufficientCredits, true},
+		{"band by code", &APIError{StatusCode: 403, Code: "band_access_denied"}, ErrBandAccessDenied, true},
+		{"rate limited by status", &APIError{StatusCode: 429}, ErrRateLimited, true},
+		{"rate limited by type", &APIError{StatusCode: 400, Type: "rate_limit_exceeded"}, ErrRateLimited, true},
+		{"authentication", &APIError{StatusCode: 401}, ErrAuthentication, true},
+		{"not found", &APIError{StatusCode: 404}, ErrNotFound, true},
+		{"server error is not rate limited", &APIError{StatusCode: 500}, ErrRateLimited, false},
+		{"not found is not authentication", &APIError{StatusCode: 404}, ErrAuthentication, false},
+		{"specialized rate limit error", &RateLimitError{APIError: APIError{StatusCode: 429}, RetryAfter: 5}, ErrRateLimited, true},
+		{"specialized credits error", &InsufficientCreditsError{APIError: APIError{Type: "insufficient_credits"}}, ErrInsufficientCredits, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpecializedErrors_Unwrap(t *testing.T) {
+	var err error = &BandAccessError{APIError: APIError{StatusCode: 403, Message: "denied"}, Band: "premium"}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatal("errors.As() should find the embedded APIError")
+	}
+	if apiErr.Message != "denied" {
+		t.Errorf("Message = %q, want denied", apiErr.Message)
+	}
+}
+
+func TestClient_ErrorSentinels(t *testing.T) {
+	tests := []struct {
+		status  int
+		errType string
+		target  error
+	}{
+		{http.StatusTooManyRequests, "rate_limit_exceeded", ErrRateLimited},
+		{http.StatusPaymentRequired, "insufficient_credits", ErrInsufficientCredits},
+		{http.StatusForbidden, "band_access_denied", ErrBandAccessDenied},
+		{http.StatusUnauthorized, "authentication_error", ErrAuthentication},
+		{http.StatusNotFound, "not_found", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.errType, func(t *testing.T) {
+			mockServer := testutil.NewMockServer(testutil.ErrorHandler(tt.status, tt.errType, "failed"))
+			defer mockServer.Close()
+
+			client := NewClient(Config{
+				BaseURL: mockServer.URL(),
+				APIKey:  "test-key",
+			})
+
+			_, err := client.Chat(context.Background(), ChatRequest{
+				Model:    "openai/gpt-4o",
+				Messages: []Message{{Role: "user", Content: "Hello"}},
+			}, nil)
+			if !errors.Is(err, tt.target) {
+				t.Errorf("errors.Is(%v, %v) = false, want true", err, tt.target)
+			}
+		})
+	}
+}
+
+func TestClient_ErrorTypes(t *testing.T) {
+	tests := []struct {
+		status  int
+		errType string
+		check   func(t *testing.T, err error)
+	}{
+		{http.StatusPaymentRequired, "insufficient_credits", func(t *testing.T, err error) {
+			if _, ok := err.(*InsufficientCreditsError); !ok {
+				t.Errorf("error = %T, want *InsufficientCreditsError", err)
+			}
+		}},
+		{http.StatusForbidden, "band_access_denied", func(t *testing.T, err error) {
+			if _, ok := err.(*BandAccessError); !ok {
+				t.Errorf("error = %T, want *BandAccessError", err)
+			}
+		}},
+		{http.StatusTooManyRequests, "rate_limit_exceeded", func(t *testing.T, err error) {
+			rateErr, ok := err.(*RateLimitError)
+			if !ok {
+				t.Fatalf("error = %T, want *RateLimitError", err)
+			}
+			if rateErr.StatusCode != http.StatusTooManyRequests || !rateErr.IsRateLimitExceeded() {
+				t.Errorf("RateLimitError = %+v", rateErr)
+			}
+		}},
+		{http.StatusBadRequest, "invalid_request_error", func(t *testing.T, err error) {
+			apiErr, ok := err.(*APIError)
+			if !ok {
+				t.Fatalf("error = %T, want *APIError", err)
+			}
+			if apiErr.Message != "failed" || apiErr.Type != "invalid_request_error" {
+				t.Errorf("APIError = %+v", apiErr)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.errType, func(t *testing.T) {
+			mockServer := testutil.NewMockServer(testutil.ErrorHandler(tt.status, tt.errType, "failed"))
+			defer mockServer.Close()
+
+			client := NewClient(Config{
+				BaseURL: mockServer.URL(),
+				APIKey:  "test-key",
+			})
+
+			_, err := client.Chat(context.Background(), ChatRequest{
+				Model:    "openai/gpt-4o",
+				Messages: []Message{{Role: "user", Content: "Hello"}},
+			}, nil)
+			tt.check(t, err)
+
+			// Every class is reachable as the public APIError
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("errors.As(*APIError) failed for %v", err)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"rate limit", &RateLimitError{APIError: APIError{StatusCode: 429}}, true},
+		{"overloaded status", &APIError{StatusCode: 529}, true},
+		{"overloaded type", &APIError{Type: "overloaded_error"}, true},
+		{"server error", &APIError{StatusCode: 500}, true},
+		{"bad gateway", &APIError{StatusCode: 502}, true},
+		{"not implemented", &APIError{StatusCode: 501}, false},
+		{"request timeout", &APIError{StatusCode: 408}, true},
+		{"bad request", &APIError{StatusCode: 400}, false},
+		{"unauthorized", &APIError{StatusCode: 401, Type: "authentication_error"}, false},
+		{"insufficient credits", &InsufficientCreditsError{APIError: APIError{StatusCode: 402}}, false},
+		{"validation", &ValidationError{Field: "model", Message: "model is required"}, false},
+		{"gateway timeout", &APIError{StatusCode: 504}, true},
+		{"http version not supported", &APIError{StatusCode: 505}, false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+		{"canceled", context.Canceled, false},
+		{"client closed", ErrClientClosed, false},
+		{"wrapped server error", fmt.Errorf("chat: %w", &APIError{StatusCode: 503}), true},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryable_ClientErrors(t *testing.T) {
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+
+	// A server error returned by the client
+	mockServer := testutil.NewMockServer(testutil.ErrorHandler(http.StatusServiceUnavailable, "server_error", "try again"))
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+	_, err := client.Chat(context.Background(), req, nil)
+	if !IsRetryable(err) {
+		t.Errorf("IsRetryable(%v) = false, want true for a 503", err)
+	}
+
+	// A network error once the server is gone
+	mockServer.Close()
+	_, err = client.Chat(context.Background(), req, nil)
+	if err == nil || !IsRetryable(err) {
+		t.Errorf("IsRetryable(%v) = false, want true for a network error", err)
+	}
+}
+
+func TestIsRetryable_Timeouts(t *testing.T) {
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+	unblock := make(chan struct{})
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-unblock:
+		case <-r.Context().Done():
+		}
+	}))
+	defer mockServer.Close()
+	defer close(unblock)
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+
+	// The request timeout elapses while the caller's context is live, so
+	// the built-in retries would try again.
+	_, err := client.Chat(context.Background(), req, &RequestOptions{Timeout: 20 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) || !IsRetryable(err) {
+		t.Errorf("IsRetryable(%v) = %v, want true for a request timeout", err, IsRetryable(err))
+	}
+
+	// The caller's own deadline passes, which the built-in retries give up on.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.Chat(ctx, req, nil)
+	if !errors.Is(err, context.DeadlineExceeded) || IsRetryable(err) {
+		t.Errorf("IsRetryable(%v) = %v, want false for the caller's deadline", err, IsRetryable(err))
+	}
+}
@@ -191,25 +191,14 @@ func (c *Client) CreateImage(ctx context.Context, req ImageGenerationRequest, op
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/images/generations",
-		Body:   req,
+		Operation: "zaguan.CreateImage",
+		Method:    "POST",
+		Path:      "/v1/images/generations",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp ImageResponse
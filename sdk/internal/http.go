@@ -2,34 +2,334 @@ package internal
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
+// DefaultMaxErrorBodySize is the default number of bytes of a non-JSON error
+// body that are captured into APIError.RawBody.
+const DefaultMaxErrorBodySize = 1024
+
+// maxErrorResponseSize caps how much of an error response body is read, so a
+// misbehaving server or proxy cannot make the client buffer an unbounded body.
+const maxErrorResponseSize = 1 << 20
+
+// DefaultCompressionThreshold is the default minimum size in bytes of a JSON
+// request body that is gzipped when compression is enabled.
+const DefaultCompressionThreshold = 1024
+
+// DefaultRetryDelay is the delay before the first retry when
+// RequestConfig.RetryDelay is not set.
+const DefaultRetryDelay = time.Second
+
+// MaxRetryDelay caps the exponential backoff between retries. A response
+// whose Retry-After asks for a longer wait is returned instead of retried.
+const MaxRetryDelay = 30 * time.Second
+
+// maxErrorSnippetSize caps the portion of a raw error body included in the
+// error message.
+const maxErrorSnippetSize = 200
+
+// ErrClientClosed is returned for requests issued after Shutdown or Close.
+var ErrClientClosed = errors.New("client is shut down")
+
+// ErrCircuitOpen is returned without sending a request while the client's
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker decides whether request attempts may be sent.
+type CircuitBreaker interface {
+	// Allow returns ErrCircuitOpen if an attempt must not be sent. Otherwise
+	// the returned done function must be called once with the outcome of
+	// the attempt.
+	Allow() (done func(outcome AttemptOutcome), err error)
+}
+
+// AttemptOutcome is the outcome of an attempt as reported to a
+// CircuitBreaker.
+type AttemptOutcome int
+
+// Attempt outcomes.
+const (
+	// AttemptSucceeded means the server responded with anything other
+	// than a server error.
+	AttemptSucceeded AttemptOutcome = iota
+
+	// AttemptFailed means the attempt failed with a transport error or a
+	// server error.
+	AttemptFailed
+
+	// AttemptAborted means the attempt ended because the caller's context
+	// was done or the client was closed, which says nothing about the
+	// server's health.
+	AttemptAborted
+)
+
+// Sentinel errors matched by APIError.Is to classify API errors.
+var (
+	ErrInsufficientCredits = errors.New("insufficient credits")
+	ErrBandAccessDenied    = errors.New("band access denied")
+	ErrRateLimited         = errors.New("rate limit exceeded")
+	ErrAuthentication      = errors.New("authentication failed")
+	ErrNotFound            = errors.New("not found")
+)
+
+// matchesErrorClass reports whether an API error with the given status code,
+// type and code belongs to the class identified by the sentinel target.
+func matchesErrorClass(statusCode int, errType, code string, target error) bool {
+	switch target {
+	case ErrInsufficientCredits:
+		return statusCode == http.StatusPaymentRequired ||
+			errType == "insufficient_credits" || code == "insufficient_credits"
+	case ErrBandAccessDenied:
+		return errType == "band_access_denied" || code == "band_access_denied"
+	case ErrRateLimited:
+		return statusCode == http.StatusTooManyRequests ||
+			errType == "rate_limit_exceeded" || code == "rate_limit_exceeded"
+	case ErrAuthentication:
+		return statusCode == http.StatusUnauthorized || errType == "authentication_error"
+	case ErrNotFound:
+		return statusCode == http.StatusNotFound
+	}
+	return false
+}
+
+// RequestTooLargeError is returned when a marshaled request body exceeds
+// MaxRequestBytes. The request is not sent.
+type RequestTooLargeError struct {
+	// Path is the API path of the rejected request.
+	Path string
+
+	// Size is the size of the marshaled request body in bytes.
+	Size int
+
+	// Limit is the configured maximum request size in bytes.
+	Limit int
+}
+
+// Error implements the error interface.
+func (e *RequestTooLargeError) Error() string {
+	return fmt.Sprintf("request body too large for %s: %d bytes exceeds limit of %d bytes", e.Path, e.Size, e.Limit)
+}
+
+// RequestInfo describes a completed request for logging. It never includes
+// the request body or API key.
+type RequestInfo struct {
+	// Method is the HTTP method.
+	Method string
+
+	// Path is the API path, without query parameters.
+	Path string
+
+	// StatusCode is the response status code, or 0 if the request failed
+	// before a response arrived.
+	StatusCode int
+
+	// Duration is the time from sending the request until the response
+	// headers arrived or the request failed.
+	Duration time.Duration
+
+	// RequestID is the X-Request-Id sent with the request.
+	RequestID string
+
+	// Err is the transport error, if the request failed.
+	Err error
+}
+
 // HTTPClient is an internal wrapper around http.Client with Zaguan-specific functionality.
 type HTTPClient struct {
 	client    *http.Client
 	baseURL   string
 	apiKey    string
 	userAgent string
+
+	// MaxErrorBodySize is the number of bytes of a non-JSON error body to capture.
+	// If zero, DefaultMaxErrorBodySize is used.
+	MaxErrorBodySize int
+
+	// Organization is sent in the X-Zaguan-Organization header when non-empty.
+	Organization string
+
+	// Project is sent in the X-Zaguan-Project header when non-empty.
+	Project string
+
+	// DefaultHeaders are sent on every request. SDK-managed headers and
+	// per-request headers take precedence.
+	DefaultHeaders http.Header
+
+	// RequestIDPrefix is prepended, followed by a hyphen, to generated
+	// request IDs. Explicit and context request IDs are sent unchanged.
+	RequestIDPrefix string
+
+	// MaxRequestBytes is the maximum size of a marshaled JSON request body.
+	// Larger requests fail with a RequestTooLargeError before being sent.
+	// If zero, request size is not limited.
+	MaxRequestBytes int
+
+	// OnRequestSize, if set, is called with the size of every marshaled
+	// JSON request body before the size limit is checked.
+	OnRequestSize func(ctx context.Context, path string, size int)
+
+	// InjectHeaders, if set, is called with the headers of every outgoing
+	// request so that trace context can be propagated.
+	InjectHeaders func(ctx context.Context, header http.Header)
+
+	// Trace, if set, is called once per Do before anything is sent. The
+	// returned context is used for the request, so headers injected by
+	// InjectHeaders carry its trace context, and finish is called with
+	// the final response or error once all attempts are done.
+	Trace func(ctx context.Context, cfg RequestConfig) (_ context.Context, finish func(*http.Response, error))
+
+	// Sleep, if set, is used to wait between retries. It must return early
+	// with ctx.Err() if ctx is done.
+	Sleep func(ctx context.Context, d time.Duration) error
+
+	// OnRequestComplete, if set, is called once per request when the
+	// response headers arrive or the request fails.
+	OnRequestComplete func(ctx context.Context, info RequestInfo)
+
+	// Wait, if set, is called before each attempt and blocks until the
+	// attempt may be sent. It must return early with an error if ctx is
+	// done.
+	Wait func(ctx context.Context) error
+
+	// Breaker, if set, is consulted before each attempt and told its
+	// outcome.
+	Breaker CircuitBreaker
+
+	// WrapRoundTrip, if set, wraps the function that sends each attempt,
+	// letting callers observe or modify every request and response,
+	// including retries.
+	WrapRoundTrip func(next func(*http.Request) (*http.Response, error)) func(*http.Request) (*http.Response, error)
+
+	// EnableCompression requests gzip-encoded responses and decompresses
+	// them, and gzips JSON request bodies of at least CompressionThreshold
+	// bytes.
+	EnableCompression bool
+
+	// CompressionThreshold is the minimum JSON request body size in bytes
+	// that is gzipped when EnableCompression is set.
+	// If zero, DefaultCompressionThreshold is used.
+	CompressionThreshold int
+
+	// OwnsTransport reports whether the SDK created the http.Client's
+	// transport. Shutdown and Close only close idle connections when it is
+	// set, so connections pooled by a caller's client are left alone.
+	OwnsTransport bool
+
+	// baseCtx is cancelled by Shutdown to abort all in-flight requests.
+	baseCtx    context.Context
+	cancelBase context.CancelFunc
+
+	// mu guards closed; inflight tracks requests whose bodies are still open.
+	mu       sync.Mutex
+	closed   bool
+	inflight sync.WaitGroup
 }
 
 // NewHTTPClient creates a new internal HTTP client.
 func NewHTTPClient(client *http.Client, baseURL, apiKey, sdkVersion string) *HTTPClient {
+	baseCtx, cancelBase := context.WithCancel(context.Background())
 	return &HTTPClient{
-		client:    client,
-		baseURL:   baseURL,
-		apiKey:    apiKey,
-		userAgent: fmt.Sprintf("zaguan-go-sdk/%s", sdkVersion),
+		client:     client,
+		baseURL:    baseURL,
+		apiKey:     apiKey,
+		userAgent:  fmt.Sprintf("zaguan-go-sdk/%s", sdkVersion),
+		baseCtx:    baseCtx,
+		cancelBase: cancelBase,
+	}
+}
+
+// Shutdown cancels all in-flight requests and waits for them to finish,
+// bounded by ctx. Requests issued after Shutdown fail with ErrClientClosed.
+// If OwnsTransport is set, idle connections are closed once in-flight
+// requests have finished.
+func (c *HTTPClient) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	c.cancelBase()
+
+	done := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		c.closeIdleConnections()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close marks the client closed without waiting for in-flight requests.
+// Requests issued after Close fail with ErrClientClosed. If OwnsTransport is
+// set, idle connections are closed.
+func (c *HTTPClient) Close() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+
+	c.closeIdleConnections()
+}
+
+// Closed reports whether Shutdown or Close has been called.
+func (c *HTTPClient) Closed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// closeIdleConnections closes idle connections if the SDK owns the transport.
+func (c *HTTPClient) closeIdleConnections() {
+	if c.OwnsTransport {
+		c.client.CloseIdleConnections()
 	}
 }
 
+// track registers an in-flight request. It returns false if the client has
+// been shut down.
+func (c *HTTPClient) track() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return false
+	}
+	c.inflight.Add(1)
+	return true
+}
+
+// requestIDKey is the context key for request IDs.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying requestID.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	requestID, ok := ctx.Value(requestIDKey{}).(string)
+	return requestID, ok && requestID != ""
+}
+
 // RequestConfig holds configuration for an HTTP request.
 type RequestConfig struct {
 	Method      string
@@ -39,78 +339,487 @@ type RequestConfig struct {
 	RequestID   string
 	Timeout     time.Duration
 	QueryParams map[string]string
+
+	// APIKey overrides the client's API key for this request when non-empty.
+	APIKey string
+
+	// ResponseHeaders, if non-nil, receives a copy of the response headers
+	// once a response arrives, including error responses.
+	ResponseHeaders *http.Header
+
+	// IdempotencyKey is sent in the Idempotency-Key header of non-GET
+	// requests. If empty and retries are enabled, one is generated and
+	// reused across attempts.
+	IdempotencyKey string
+
+	// MaxRetries is the number of times a failed request is retried.
+	// If zero or negative, requests are not retried.
+	MaxRetries int
+
+	// RetryDelay is the delay before the first retry, doubled for each
+	// further retry up to MaxRetryDelay. Each delay is jittered. If zero,
+	// DefaultRetryDelay is used.
+	RetryDelay time.Duration
+
+	// Operation names the SDK operation making the request, such as
+	// "zaguan.ListModels". It is passed to Trace.
+	Operation string
 }
 
 // Do executes an HTTP request and returns the response.
+//
+// If cfg.MaxRetries is positive, requests that fail with a transport error
+// or a retryable status (see RetryableStatus) are retried with
+// exponential backoff. Requests with a caller-supplied io.Reader body are
+// never retried, since the body cannot be replayed.
 func (c *HTTPClient) Do(ctx context.Context, cfg RequestConfig) (*http.Response, error) {
-	// Build URL
-	url := c.baseURL + cfg.Path
+	if c.Trace == nil {
+		return c.do(ctx, cfg)
+	}
+	ctx, finish := c.Trace(ctx, cfg)
+	resp, err := c.do(ctx, cfg)
+	finish(resp, err)
+	return resp, err
+}
+
+// do implements Do.
+func (c *HTTPClient) do(ctx context.Context, cfg RequestConfig) (*http.Response, error) {
+	// Build URL, escaping query values such as opaque pagination cursors
+	endpoint := c.baseURL + cfg.Path
 	if len(cfg.QueryParams) > 0 {
-		url += "?"
-		first := true
+		query := url.Values{}
 		for k, v := range cfg.QueryParams {
-			if !first {
-				url += "&"
-			}
-			url += fmt.Sprintf("%s=%s", k, v)
-			first = false
+			query.Set(k, v)
 		}
+		endpoint += "?" + query.Encode()
 	}
 
-	// Marshal body if present
+	// Use reader bodies (e.g. multipart forms) as-is; marshal anything else
 	var bodyReader io.Reader
-	if cfg.Body != nil {
-		bodyBytes, err := json.Marshal(cfg.Body)
+	var bodyBytes []byte
+	compressed := false
+	if r, ok := cfg.Body.(io.Reader); ok {
+		bodyReader = r
+	} else if cfg.Body != nil {
+		var err error
+		bodyBytes, err = json.Marshal(cfg.Body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(bodyBytes)
+		if c.OnRequestSize != nil {
+			c.OnRequestSize(ctx, cfg.Path, len(bodyBytes))
+		}
+		if c.MaxRequestBytes > 0 && len(bodyBytes) > c.MaxRequestBytes {
+			return nil, &RequestTooLargeError{Path: cfg.Path, Size: len(bodyBytes), Limit: c.MaxRequestBytes}
+		}
+		if c.shouldCompress(len(bodyBytes)) {
+			if bodyBytes, err = gzipBytes(bodyBytes); err != nil {
+				return nil, fmt.Errorf("failed to compress request body: %w", err)
+			}
+			compressed = true
+		}
 	}
 
-	// Create request
-	req, err := http.NewRequestWithContext(ctx, cfg.Method, url, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	// Set default headers first so SDK-managed headers always win
+	header := make(http.Header)
+	for k, v := range c.DefaultHeaders {
+		for _, vv := range v {
+			header.Add(k, vv)
+		}
 	}
 
 	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
-	req.Header.Set("User-Agent", c.userAgent)
+	header.Set("Content-Type", "application/json")
+	apiKey := c.apiKey
+	if cfg.APIKey != "" {
+		apiKey = cfg.APIKey
+	}
+	header.Set("Authorization", "Bearer "+apiKey)
+	header.Set("User-Agent", c.userAgent)
+	if compressed {
+		header.Set("Content-Encoding", "gzip")
+	}
+	if c.EnableCompression {
+		// Setting Accept-Encoding explicitly turns off the transport's own
+		// decompression, so responses are only decompressed once, below
+		header.Set("Accept-Encoding", "gzip")
+	}
 
-	// Set request ID
+	// Set request ID: explicit option, then context, then a generated UUID
 	requestID := cfg.RequestID
+	if requestID == "" {
+		requestID, _ = RequestIDFromContext(ctx)
+	}
 	if requestID == "" {
 		requestID = uuid.New().String()
+		if c.RequestIDPrefix != "" {
+			requestID = c.RequestIDPrefix + "-" + requestID
+		}
+	}
+	header.Set("X-Request-Id", requestID)
+
+	// Set the idempotency key, generating one when retries may replay the
+	// request so the server can deduplicate attempts. GETs are idempotent.
+	retryable := cfg.MaxRetries > 0 && bodyReader == nil
+	if cfg.Method != http.MethodGet {
+		idempotencyKey := cfg.IdempotencyKey
+		if idempotencyKey == "" && retryable {
+			idempotencyKey = uuid.New().String()
+		}
+		if idempotencyKey != "" {
+			header.Set("Idempotency-Key", idempotencyKey)
+		}
 	}
-	req.Header.Set("X-Request-Id", requestID)
 
-	// Merge custom headers
+	// Set organization and project
+	if c.Organization != "" {
+		header.Set("X-Zaguan-Organization", c.Organization)
+	}
+	if c.Project != "" {
+		header.Set("X-Zaguan-Project", c.Project)
+	}
+
+	// Merge custom headers, replacing any defaults with the same name
 	if cfg.Headers != nil {
 		for k, v := range cfg.Headers {
+			header.Del(k)
 			for _, vv := range v {
-				req.Header.Add(k, vv)
+				header.Add(k, vv)
 			}
 		}
 	}
 
-	// Apply timeout if specified
+	if c.InjectHeaders != nil {
+		c.InjectHeaders(ctx, header)
+	}
+
+	delay := cfg.RetryDelay
+	if delay <= 0 {
+		delay = DefaultRetryDelay
+	}
+	if delay > MaxRetryDelay {
+		delay = MaxRetryDelay
+	}
+	for attempt := 0; ; attempt++ {
+		body := bodyReader
+		if bodyBytes != nil {
+			body = bytes.NewReader(bodyBytes)
+		}
+
+		if c.Wait != nil {
+			if err := c.Wait(ctx); err != nil {
+				closeBody(body)
+				return nil, err
+			}
+		}
+
+		var done func(outcome AttemptOutcome)
+		if c.Breaker != nil {
+			var err error
+			if done, err = c.Breaker.Allow(); err != nil {
+				closeBody(body)
+				return nil, err
+			}
+		}
+
+		resp, err := c.send(ctx, cfg, endpoint, body, header.Clone(), requestID)
+		if done != nil {
+			done(attemptOutcome(ctx, resp, err))
+		}
+		if !retryable || attempt >= cfg.MaxRetries || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		wait := jitter(delay)
+		if resp != nil {
+			retryAfter := retryAfterDelay(resp)
+			if retryAfter > MaxRetryDelay {
+				// Rather than block for that long, let the caller decide
+				return resp, err
+			}
+			if retryAfter > wait {
+				wait = retryAfter
+			}
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		if err := c.sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+		delay *= 2
+		if delay > MaxRetryDelay {
+			delay = MaxRetryDelay
+		}
+	}
+}
+
+// send performs a single attempt of a request prepared by Do.
+func (c *HTTPClient) send(ctx context.Context, cfg RequestConfig, endpoint string, bodyReader io.Reader, header http.Header, requestID string) (*http.Response, error) {
+	// Create request
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, endpoint, bodyReader)
+	if err != nil {
+		closeBody(bodyReader)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = header
+
+	// Register the request so Shutdown can cancel it and wait for it
+	if !c.track() {
+		closeBody(bodyReader)
+		return nil, ErrClientClosed
+	}
+
+	// Derive the request context from the client's base context and apply
+	// the timeout if specified. Both cover reading the body, so the context
+	// is only released once the body is closed.
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(c.baseCtx, cancel)
 	if cfg.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
-		defer cancel()
-		req = req.WithContext(ctx)
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, cfg.Timeout)
+		cancelParent := cancel
+		cancel = func() {
+			cancelTimeout()
+			cancelParent()
+		}
+	}
+	req = req.WithContext(ctx)
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			stop()
+			cancel()
+			c.inflight.Done()
+		})
 	}
 
 	// Execute request
-	resp, err := c.client.Do(req)
+	roundTrip := c.client.Do
+	if c.WrapRoundTrip != nil {
+		roundTrip = c.WrapRoundTrip(roundTrip)
+	}
+	start := time.Now()
+	resp, err := roundTrip(req)
+	if c.OnRequestComplete != nil {
+		info := RequestInfo{
+			Method:    cfg.Method,
+			Path:      cfg.Path,
+			Duration:  time.Since(start),
+			RequestID: requestID,
+			Err:       err,
+		}
+		if resp != nil {
+			info.StatusCode = resp.StatusCode
+		}
+		c.OnRequestComplete(ctx, info)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		release()
+		err = fmt.Errorf("request failed: %w", err)
+		if parent.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			err = &attemptTimeoutError{err: err}
+		}
+		return nil, err
+	}
+
+	if c.EnableCompression && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		resp.Body = &gzipResponseBody{body: resp.Body}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
 	}
 
+	if cfg.ResponseHeaders != nil {
+		*cfg.ResponseHeaders = resp.Header.Clone()
+	}
+
+	resp.Body = &releaseOnCloseBody{ReadCloser: resp.Body, release: release}
+
 	return resp, nil
 }
 
+// attemptOutcome classifies an attempt for the circuit breaker. Transport
+// errors and server errors other than 501 Not Implemented are failures.
+// Attempts cut short by the caller's context or by closing the client are
+// aborted, since they say nothing about the server.
+func attemptOutcome(ctx context.Context, resp *http.Response, err error) AttemptOutcome {
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, ErrClientClosed) || errors.Is(err, context.Canceled) {
+			return AttemptAborted
+		}
+		return AttemptFailed
+	}
+	if resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented {
+		return AttemptFailed
+	}
+	return AttemptSucceeded
+}
+
+// shouldRetry reports whether an attempt that returned resp and err should be
+// retried.
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, ErrClientClosed) && !errors.Is(err, context.Canceled)
+	}
+	return RetryableStatus(resp.StatusCode)
+}
+
+// StatusOverloaded is the non-standard status some providers return when
+// they are temporarily overloaded.
+const StatusOverloaded = 529
+
+// RetryableStatus reports whether a response with the given status code is
+// worth retrying: 408, 429, 500, 502, 503, 504, and StatusOverloaded.
+func RetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+		StatusOverloaded:
+		return true
+	}
+	return false
+}
+
+// attemptTimeoutError marks an attempt that hit a deadline while the
+// caller's context was still live, such as RequestConfig.Timeout or the
+// http.Client's own Timeout. Unlike the caller's deadline, such an attempt
+// is worth retrying.
+type attemptTimeoutError struct {
+	err error
+}
+
+func (e *attemptTimeoutError) Error() string { return e.err.Error() }
+func (e *attemptTimeoutError) Unwrap() error { return e.err }
+
+// IsAttemptTimeout reports whether err is from an attempt that timed out
+// before the caller's context was done.
+func IsAttemptTimeout(err error) bool {
+	var timeoutErr *attemptTimeoutError
+	return errors.As(err, &timeoutErr)
+}
+
+// jitter returns a random duration between d/2 and d, so that clients that
+// failed together do not retry in lockstep.
+func jitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// retryAfterDelay returns the delay requested by a Retry-After header in
+// seconds, or 0 if there is none.
+func retryAfterDelay(resp *http.Response) time.Duration {
+	var seconds int
+	if _, err := fmt.Sscanf(resp.Header.Get("Retry-After"), "%d", &seconds); err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// sleep waits for d or until ctx is done, using the Sleep hook if set.
+func (c *HTTPClient) sleep(ctx context.Context, d time.Duration) error {
+	if c.Sleep != nil {
+		return c.Sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// shouldCompress reports whether a JSON request body of size bytes should be
+// gzipped.
+func (c *HTTPClient) shouldCompress(size int) bool {
+	if !c.EnableCompression {
+		return false
+	}
+	threshold := c.CompressionThreshold
+	if threshold <= 0 {
+		threshold = DefaultCompressionThreshold
+	}
+	return size >= threshold
+}
+
+// gzipBytes returns data compressed with gzip.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gzipResponseBody decompresses a gzip-encoded response body.
+//
+// The gzip reader is created on the first Read rather than up front, so that
+// opening a streaming response does not block waiting for the first bytes.
+type gzipResponseBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+// Read reads decompressed data.
+func (b *gzipResponseBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.zr == nil {
+		zr, err := gzip.NewReader(b.body)
+		if err != nil {
+			b.err = err
+			return 0, err
+		}
+		b.zr = zr
+	}
+	return b.zr.Read(p)
+}
+
+// Close closes the underlying response body.
+func (b *gzipResponseBody) Close() error {
+	return b.body.Close()
+}
+
+// closeBody closes a request body that will never be sent, so that producers
+// writing into it (such as a pipe) are released.
+func closeBody(body io.Reader) {
+	if closer, ok := body.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// releaseOnCloseBody releases a request's context and in-flight registration
+// when the response body is closed.
+type releaseOnCloseBody struct {
+	io.ReadCloser
+	release func()
+}
+
+// Close closes the underlying body and releases the request.
+func (b *releaseOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
 // DoJSON executes an HTTP request and unmarshals the JSON response.
 func (c *HTTPClient) DoJSON(ctx context.Context, cfg RequestConfig, result interface{}) error {
 	resp, err := c.Do(ctx, cfg)
@@ -121,7 +830,7 @@ func (c *HTTPClient) DoJSON(ctx context.Context, cfg RequestConfig, result inter
 
 	// Check for error status codes
 	if resp.StatusCode >= 400 {
-		return ParseErrorResponse(resp)
+		return c.ParseErrorResponse(resp)
 	}
 
 	// Decode response
@@ -143,21 +852,83 @@ type ErrorResponse struct {
 	} `json:"error"`
 }
 
+// ParseErrorResponse parses an error response from the API using the
+// client's configured error body limit.
+func (c *HTTPClient) ParseErrorResponse(resp *http.Response) error {
+	return parseErrorResponse(resp, c.MaxErrorBodySize)
+}
+
 // ParseErrorResponse parses an error response from the API.
 func ParseErrorResponse(resp *http.Response) error {
+	return parseErrorResponse(resp, DefaultMaxErrorBodySize)
+}
+
+// parseErrorResponse parses an error response, capturing up to maxBodySize
+// bytes of the body when it is not a structured JSON error.
+func parseErrorResponse(resp *http.Response, maxBodySize int) error {
 	requestID := resp.Header.Get("X-Request-Id")
 
+	if maxBodySize <= 0 {
+		maxBodySize = DefaultMaxErrorBodySize
+	}
+
+	limit := int64(maxErrorResponseSize)
+	if int64(maxBodySize) > limit {
+		limit = int64(maxBodySize)
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, limit)) // A partial body is still useful for diagnostics
+
 	// Try to parse as structured error
 	var errResp ErrorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-		// If we can't parse the error, return a generic one
+	if err := json.Unmarshal(body, &errResp); err != nil {
+		// Not JSON (e.g. an HTML page from a proxy); keep a snippet of the body
+		raw := body
+		if len(raw) > maxBodySize {
+			raw = raw[:maxBodySize]
+		}
 		return &APIError{
 			StatusCode: resp.StatusCode,
-			Message:    fmt.Sprintf("HTTP %d: %s", resp.StatusCode, resp.Status),
+			Message:    nonJSONErrorMessage(resp, raw),
 			RequestID:  requestID,
+			RawBody:    string(raw),
 		}
 	}
 
+	return newAPIError(&errResp, resp, requestID)
+}
+
+// ParseStreamError converts the "error" field of a streamed SSE event, which
+// the caller has already decoded alongside the rest of the event, into a
+// typed error. Gateways send either an error object or a plain string.
+//
+// Returns nil if the field is absent or null.
+func ParseStreamError(errField json.RawMessage, resp *http.Response) error {
+	if len(errField) == 0 || string(errField) == "null" {
+		return nil
+	}
+
+	requestID := resp.Header.Get("X-Request-Id")
+
+	var errResp ErrorResponse
+	if err := json.Unmarshal(errField, &errResp.Error); err != nil {
+		// Some gateways send the error as a plain string
+		var msg string
+		if err := json.Unmarshal(errField, &msg); err != nil {
+			msg = string(errField)
+		}
+		return &APIError{
+			StatusCode: resp.StatusCode,
+			Message:    msg,
+			RequestID:  requestID,
+		}
+	}
+
+	return newAPIError(&errResp, resp, requestID)
+}
+
+// newAPIError builds an APIError from a decoded error response, returning a
+// specialized error type when the error type or code is recognized.
+func newAPIError(errResp *ErrorResponse, resp *http.Response, requestID string) error {
 	// Create base API error
 	apiErr := &APIError{
 		StatusCode: resp.StatusCode,
@@ -185,17 +956,37 @@ func ParseErrorResponse(resp *http.Response) error {
 	return apiErr
 }
 
-// APIError represents a generic API error.
+// APIError represents an error returned by the Zaguan CoreX API.
 type APIError struct {
+	// StatusCode is the HTTP status code returned by the API.
 	StatusCode int
-	Message    string
-	RequestID  string
-	Type       string
-	Code       string
-	Param      string
-	Details    map[string]interface{}
+
+	// Message is the human-readable error message.
+	Message string
+
+	// RequestID is the unique identifier for this request.
+	// Include this when reporting issues to Zaguan support.
+	RequestID string
+
+	// Type is the error type/code for programmatic handling.
+	// Examples: "insufficient_credits", "band_access_denied", "rate_limit_exceeded"
+	Type string
+
+	// Code is an optional error code (may be the same as Type).
+	Code string
+
+	// Param is the parameter that caused the error, if applicable.
+	Param string
+
+	// Details contains additional error details from the API.
+	Details map[string]interface{}
+
+	// RawBody contains the first bytes of the response body when it could not
+	// be parsed as a JSON error (e.g. an HTML page from a gateway or WAF).
+	RawBody string
 }
 
+// Error implements the error interface.
 func (e *APIError) Error() string {
 	if e.RequestID != "" {
 		return fmt.Sprintf("zaguan API error (%d) [%s]: %s", e.StatusCode, e.RequestID, e.Message)
@@ -203,6 +994,67 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("zaguan API error (%d): %s", e.StatusCode, e.Message)
 }
 
+// Is reports whether the error belongs to the class of a sentinel error such
+// as ErrRateLimited.
+func (e *APIError) Is(target error) bool {
+	return matchesErrorClass(e.StatusCode, e.Type, e.Code, target)
+}
+
+// IsInsufficientCredits returns true if this error is due to insufficient credits.
+func (e *APIError) IsInsufficientCredits() bool {
+	return e.Type == "insufficient_credits" || e.Code == "insufficient_credits"
+}
+
+// IsBandAccessDenied returns true if this error is due to band access restrictions.
+func (e *APIError) IsBandAccessDenied() bool {
+	return e.Type == "band_access_denied" || e.Code == "band_access_denied"
+}
+
+// IsRateLimitExceeded returns true if this error is due to rate limiting.
+func (e *APIError) IsRateLimitExceeded() bool {
+	return e.Type == "rate_limit_exceeded" || e.Code == "rate_limit_exceeded"
+}
+
+// IsAuthenticationError returns true if this error is due to authentication failure.
+func (e *APIError) IsAuthenticationError() bool {
+	return e.StatusCode == 401 || e.Type == "authentication_error"
+}
+
+// IsPermissionError returns true if this error is due to insufficient permissions.
+func (e *APIError) IsPermissionError() bool {
+	return e.StatusCode == 403 || e.Type == "permission_error"
+}
+
+// IsNotFoundError returns true if the requested resource was not found.
+func (e *APIError) IsNotFoundError() bool {
+	return e.StatusCode == 404
+}
+
+// IsServerError returns true if this is a server-side error (5xx).
+func (e *APIError) IsServerError() bool {
+	return e.StatusCode >= 500 && e.StatusCode < 600
+}
+
+// nonJSONErrorMessage builds an error message for a response whose body could
+// not be parsed as a structured error.
+func nonJSONErrorMessage(resp *http.Response, raw []byte) string {
+	msg := fmt.Sprintf("HTTP %d: %s", resp.StatusCode, resp.Status)
+
+	snippet := strings.Join(strings.Fields(string(raw)), " ")
+	if snippet == "" {
+		return msg
+	}
+	if len(snippet) > maxErrorSnippetSize {
+		// Cut on a rune boundary so the message stays valid UTF-8
+		end := maxErrorSnippetSize
+		for end > 0 && !utf8.RuneStart(snippet[end]) {
+			end--
+		}
+		snippet = snippet[:end] + "..."
+	}
+	return msg + ": " + snippet
+}
+
 func parseInsufficientCreditsError(base *APIError) error {
 	err := &InsufficientCreditsError{APIError: *base}
 
@@ -257,7 +1109,7 @@ func parseRateLimitError(base *APIError, resp *http.Response) error {
 	return err
 }
 
-// InsufficientCreditsError represents an insufficient credits error.
+// InsufficientCreditsError represents an error when the user has insufficient credits.
 type InsufficientCreditsError struct {
 	APIError
 	CreditsRequired  int
@@ -265,12 +1117,18 @@ type InsufficientCreditsError struct {
 	ResetDate        string
 }
 
+// Error implements the error interface.
 func (e *InsufficientCreditsError) Error() string {
 	return fmt.Sprintf("insufficient credits: required %d, remaining %d (resets on %s)",
 		e.CreditsRequired, e.CreditsRemaining, e.ResetDate)
 }
 
-// BandAccessError represents a band access denied error.
+// Unwrap returns the underlying APIError.
+func (e *InsufficientCreditsError) Unwrap() error {
+	return &e.APIError
+}
+
+// BandAccessError represents an error when the user's tier doesn't have access to a band.
 type BandAccessError struct {
 	APIError
 	Band         string
@@ -278,20 +1136,32 @@ type BandAccessError struct {
 	CurrentTier  string
 }
 
+// Error implements the error interface.
 func (e *BandAccessError) Error() string {
 	return fmt.Sprintf("band access denied: %s tier does not have access to band %s (requires %s tier)",
 		e.CurrentTier, e.Band, e.RequiredTier)
 }
 
+// Unwrap returns the underlying APIError.
+func (e *BandAccessError) Unwrap() error {
+	return &e.APIError
+}
+
 // RateLimitError represents a rate limit error.
 type RateLimitError struct {
 	APIError
-	RetryAfter int
+	RetryAfter int // Seconds to wait before retrying
 }
 
+// Error implements the error interface.
 func (e *RateLimitError) Error() string {
 	if e.RetryAfter > 0 {
 		return fmt.Sprintf("rate limit exceeded: retry after %d seconds", e.RetryAfter)
 	}
 	return "rate limit exceeded"
 }
+
+// Unwrap returns the underlying APIError.
+func (e *RateLimitError) Unwrap() error {
+	return &e.APIError
+}
@@ -2,11 +2,17 @@ package internal
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 )
 
 func TestParseErrorResponse(t *testing.T) {
@@ -91,6 +97,180 @@ func TestParseErrorResponse_InvalidJSON(t *testing.T) {
 	}
 }
 
+func TestParseErrorResponse_NonJSONBody(t *testing.T) {
+	body := "<html>\n<head><title>502 Bad Gateway</title></head>\n<body>nginx</body>\n</html>"
+	resp := &http.Response{
+		StatusCode: 502,
+		Status:     "502 Bad Gateway",
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+		Header:     http.Header{},
+	}
+
+	err := ParseErrorResponse(resp)
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("error type = %T, want *APIError", err)
+	}
+
+	if apiErr.RawBody != body {
+		t.Errorf("RawBody = %q, want %q", apiErr.RawBody, body)
+	}
+	if !strings.Contains(apiErr.Message, "502 Bad Gateway") {
+		t.Errorf("Message = %q, want status text", apiErr.Message)
+	}
+	if !strings.Contains(apiErr.Message, "<body>nginx</body>") {
+		t.Errorf("Message = %q, want body snippet", apiErr.Message)
+	}
+}
+
+// countingReader serves n bytes of 'x' and counts how many were read.
+type countingReader struct {
+	n    int64
+	read int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	if r.read >= r.n {
+		return 0, io.EOF
+	}
+	if remaining := r.n - r.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	for i := range p {
+		p[i] = 'x'
+	}
+	r.read += int64(len(p))
+	return len(p), nil
+}
+
+func TestParseErrorResponse_LargeBody(t *testing.T) {
+	body := &countingReader{n: 64 << 20}
+	resp := &http.Response{
+		StatusCode: 502,
+		Status:     "502 Bad Gateway",
+		Body:       io.NopCloser(body),
+		Header:     http.Header{},
+	}
+
+	err := ParseErrorResponse(resp)
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("error type = %T, want *APIError", err)
+	}
+	if body.read > maxErrorResponseSize {
+		t.Errorf("read %d bytes, want at most %d", body.read, maxErrorResponseSize)
+	}
+	if len(apiErr.RawBody) != DefaultMaxErrorBodySize {
+		t.Errorf("len(RawBody) = %d, want %d", len(apiErr.RawBody), DefaultMaxErrorBodySize)
+	}
+}
+
+func TestParseErrorResponse_SnippetRuneBoundary(t *testing.T) {
+	// "é" is two bytes, so a byte cut at maxErrorSnippetSize splits one
+	body := "x" + strings.Repeat("é", maxErrorSnippetSize)
+	resp := &http.Response{
+		StatusCode: 502,
+		Status:     "502 Bad Gateway",
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+		Header:     http.Header{},
+	}
+	client := NewHTTPClient(&http.Client{}, "http://example.com", "test-key", "test-version")
+	client.MaxErrorBodySize = len(body)
+
+	apiErr, ok := client.ParseErrorResponse(resp).(*APIError)
+	if !ok {
+		t.Fatalf("error type = %T, want *APIError", client.ParseErrorResponse(resp))
+	}
+	if !utf8.ValidString(apiErr.Message) {
+		t.Errorf("Message = %q, want valid UTF-8", apiErr.Message)
+	}
+	if !strings.HasSuffix(apiErr.Message, "é...") {
+		t.Errorf("Message = %q, want the snippet cut after a whole rune", apiErr.Message)
+	}
+}
+
+func TestHTTPClient_ParseErrorResponse_MaxErrorBodySize(t *testing.T) {
+	body := strings.Repeat("x", 100)
+	resp := &http.Response{
+		StatusCode: 503,
+		Status:     "503 Service Unavailable",
+		Body:       io.NopCloser(bytes.NewBufferString(body)),
+		Header:     http.Header{},
+	}
+
+	client := NewHTTPClient(&http.Client{}, "http://example.com", "test-key", "test-version")
+	client.MaxErrorBodySize = 10
+
+	err := client.ParseErrorResponse(resp)
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("error type = %T, want *APIError", err)
+	}
+
+	if apiErr.RawBody != strings.Repeat("x", 10) {
+		t.Errorf("RawBody = %q, want 10 bytes", apiErr.RawBody)
+	}
+}
+
+func TestParseStreamError(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 200,
+		Header:     http.Header{"X-Request-Id": []string{"req_123"}},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+		wantMsg string
+	}{
+		{
+			name:    "no error field",
+			data:    ``,
+			wantErr: false,
+		},
+		{
+			name:    "null error",
+			data:    `null`,
+			wantErr: false,
+		},
+		{
+			name:    "structured error",
+			data:    `{"type":"server_error","message":"Upstream failed"}`,
+			wantErr: true,
+			wantMsg: "Upstream failed",
+		},
+		{
+			name:    "string error",
+			data:    `"upstream timeout"`,
+			wantErr: true,
+			wantMsg: "upstream timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseStreamError(json.RawMessage(tt.data), resp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStreamError() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr {
+				return
+			}
+			apiErr, ok := err.(*APIError)
+			if !ok {
+				t.Fatalf("error type = %T, want *APIError", err)
+			}
+			if apiErr.Message != tt.wantMsg {
+				t.Errorf("Message = %q, want %q", apiErr.Message, tt.wantMsg)
+			}
+			if apiErr.RequestID != "req_123" {
+				t.Errorf("RequestID = %q, want req_123", apiErr.RequestID)
+			}
+		})
+	}
+}
+
 func TestParseInsufficientCreditsError(t *testing.T) {
 	body := `{"error": {"type": "insufficient_credits", "message": "Not enough credits", "details": {"credits_required": 100, "credits_remaining": 50, "reset_date": "2025-12-01"}}}`
 	resp := &http.Response{
@@ -273,6 +453,25 @@ func TestRateLimitError_Error(t *testing.T) {
 	}
 }
 
+func TestAPIError_Is(t *testing.T) {
+	rateErr := &RateLimitError{APIError: APIError{StatusCode: 429, Type: "rate_limit_exceeded"}}
+	if !errors.Is(rateErr, ErrRateLimited) {
+		t.Error("RateLimitError should match ErrRateLimited")
+	}
+	if errors.Is(rateErr, ErrNotFound) {
+		t.Error("RateLimitError should not match ErrNotFound")
+	}
+
+	var apiErr *APIError
+	if !errors.As(rateErr, &apiErr) || apiErr.StatusCode != 429 {
+		t.Errorf("errors.As() should unwrap to the APIError, got %v", apiErr)
+	}
+
+	if !errors.Is(&APIError{StatusCode: 404}, ErrNotFound) {
+		t.Error("404 APIError should match ErrNotFound")
+	}
+}
+
 func TestHTTPClient_Do(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify headers
@@ -308,6 +507,141 @@ func TestHTTPClient_Do(t *testing.T) {
 	}
 }
 
+func TestHTTPClient_Do_QueryParams(t *testing.T) {
+	params := map[string]string{
+		"cursor":   "a+b/c==",
+		"after":    "batch_1&limit=1000",
+		"provider": "50% off",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if len(query) != len(params) {
+			t.Errorf("query = %v, want %d parameters", query, len(params))
+		}
+		for k, want := range params {
+			if got := query.Get(k); got != want {
+				t.Errorf("query %s = %q, want %q", k, got, want)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(&http.Client{}, server.URL, "test-key", "test-version")
+	resp, err := client.Do(context.Background(), RequestConfig{
+		Method:      "GET",
+		Path:        "/v1/list",
+		QueryParams: params,
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestHTTPClient_Do_MaxRequestBytes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(&http.Client{}, server.URL, "test-key", "test-version")
+	client.MaxRequestBytes = 16
+
+	resp, err := client.Do(context.Background(), RequestConfig{
+		Method: "POST",
+		Path:   "/v1/test",
+		Body:   map[string]string{"a": "b"},
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	_, err = client.Do(context.Background(), RequestConfig{
+		Method: "POST",
+		Path:   "/v1/test",
+		Body:   map[string]string{"input": "a long enough value"},
+	})
+	tooLarge, ok := err.(*RequestTooLargeError)
+	if !ok {
+		t.Fatalf("Do() error = %v, want *RequestTooLargeError", err)
+	}
+	if tooLarge.Size != 31 || tooLarge.Limit != 16 || tooLarge.Path != "/v1/test" {
+		t.Errorf("RequestTooLargeError = %+v", tooLarge)
+	}
+	want := "request body too large for /v1/test: 31 bytes exceeds limit of 16 bytes"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestHTTPClient_Shutdown(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(&http.Client{}, server.URL, "test-key", "test-version")
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.Do(context.Background(), RequestConfig{Method: "GET", Path: "/"})
+		errCh <- err
+	}()
+
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := client.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("in-flight Do() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("in-flight request was not cancelled by Shutdown")
+	}
+
+	_, err := client.Do(context.Background(), RequestConfig{Method: "GET", Path: "/"})
+	if !errors.Is(err, ErrClientClosed) {
+		t.Errorf("Do() after Shutdown error = %v, want ErrClientClosed", err)
+	}
+}
+
+func TestHTTPClient_Shutdown_WaitsForOpenBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(&http.Client{}, server.URL, "test-key", "test-version")
+
+	resp, err := client.Do(context.Background(), RequestConfig{Method: "GET", Path: "/"})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	// The body is still open, so Shutdown must give up when its context expires
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := client.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown() error = %v, want context.DeadlineExceeded", err)
+	}
+
+	resp.Body.Close()
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown() after body close error = %v", err)
+	}
+}
+
 func TestHTTPClient_DoJSON(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -358,3 +692,237 @@ func TestHTTPClient_DoJSON_Error(t *testing.T) {
 		t.Fatal("DoJSON() should have returned error")
 	}
 }
+
+func TestHTTPClient_Do_Compression(t *testing.T) {
+	var gotEncoding, gotAcceptEncoding, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotEncoding = r.Header.Get("Content-Encoding")
+		gotAcceptEncoding = r.Header.Get("Accept-Encoding")
+
+		body := io.Reader(r.Body)
+		if gotEncoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Errorf("gzip.NewReader() error = %v", err)
+				return
+			}
+			body = zr
+		}
+		data, _ := io.ReadAll(body)
+		gotBody = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(gotAcceptEncoding, "gzip") {
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			zw.Write([]byte(`{"ok":true}`))
+			zw.Close()
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	client := NewHTTPClient(&http.Client{}, server.URL, "test-key", "test-version")
+	client.EnableCompression = true
+	client.CompressionThreshold = 32
+
+	tests := []struct {
+		name         string
+		input        string
+		wantEncoding string
+	}{
+		{name: "below threshold", input: "short", wantEncoding: ""},
+		{name: "above threshold", input: strings.Repeat("embed me ", 20), wantEncoding: "gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var result struct {
+				OK bool `json:"ok"`
+			}
+			err := client.DoJSON(context.Background(), RequestConfig{
+				Method: "POST",
+				Path:   "/v1/embeddings",
+				Body:   map[string]string{"input": tt.input},
+			}, &result)
+			if err != nil {
+				t.Fatalf("DoJSON() error = %v", err)
+			}
+			if !result.OK {
+				t.Error("response was not decompressed")
+			}
+			if gotEncoding != tt.wantEncoding {
+				t.Errorf("Content-Encoding = %q, want %q", gotEncoding, tt.wantEncoding)
+			}
+			if gotAcceptEncoding != "gzip" {
+				t.Errorf("Accept-Encoding = %q, want gzip", gotAcceptEncoding)
+			}
+			if want := `{"input":"` + tt.input + `"}`; gotBody != want {
+				t.Errorf("request body = %q, want %q", gotBody, want)
+			}
+		})
+	}
+
+	// Without compression, the transport handles gzip itself
+	client = NewHTTPClient(&http.Client{}, server.URL, "test-key", "test-version")
+	resp, err := client.Do(context.Background(), RequestConfig{
+		Method: "POST",
+		Path:   "/v1/embeddings",
+		Body:   map[string]string{"input": strings.Repeat("embed me ", 200)},
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if string(data) != `{"ok":true}` {
+		t.Errorf("response body = %q, want {\"ok\":true}", data)
+	}
+	if gotEncoding != "" {
+		t.Errorf("Content-Encoding = %q, want none", gotEncoding)
+	}
+}
+
+func TestHTTPClient_Do_Retries(t *testing.T) {
+	var attempts int
+	var retryAfter string
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		io.Copy(io.Discard, r.Body)
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	var slept []time.Duration
+	client := NewHTTPClient(&http.Client{}, server.URL, "test-key", "test-version")
+	client.Sleep = func(ctx context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	tests := []struct {
+		name         string
+		status       int
+		body         interface{}
+		retryDelay   time.Duration
+		retryAfter   string
+		wantAttempts int
+		wantSlept    []time.Duration // upper bounds; jitter waits at least half
+	}{
+		{name: "client error is not retried", status: http.StatusBadRequest, body: map[string]string{"a": "b"}, wantAttempts: 1},
+		{name: "server error is retried with backoff", status: http.StatusBadGateway, body: map[string]string{"a": "b"}, wantAttempts: 3, wantSlept: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}},
+		{name: "overloaded is retried", status: StatusOverloaded, body: map[string]string{"a": "b"}, wantAttempts: 3, wantSlept: []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}},
+		{name: "not implemented is not retried", status: http.StatusNotImplemented, body: map[string]string{"a": "b"}, wantAttempts: 1},
+		{name: "reader body is not retried", status: http.StatusBadGateway, body: strings.NewReader("raw"), wantAttempts: 1},
+		{name: "backoff is capped", status: http.StatusBadGateway, body: map[string]string{"a": "b"}, retryDelay: 20 * time.Second, wantAttempts: 3, wantSlept: []time.Duration{20 * time.Second, MaxRetryDelay}},
+		{name: "retry after is honored", status: http.StatusTooManyRequests, body: map[string]string{"a": "b"}, retryAfter: "2", wantAttempts: 3, wantSlept: []time.Duration{2 * time.Second, 2 * time.Second}},
+		{name: "long retry after is not retried", status: http.StatusTooManyRequests, body: map[string]string{"a": "b"}, retryAfter: "86400", wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts, slept, status, retryAfter = 0, nil, tt.status, tt.retryAfter
+			retryDelay := tt.retryDelay
+			if retryDelay == 0 {
+				retryDelay = 10 * time.Millisecond
+			}
+			resp, err := client.Do(context.Background(), RequestConfig{
+				Method:     "POST",
+				Path:       "/v1/test",
+				Body:       tt.body,
+				MaxRetries: 2,
+				RetryDelay: retryDelay,
+			})
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Errorf("StatusCode = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if len(slept) != len(tt.wantSlept) {
+				t.Fatalf("slept %v, want %v", slept, tt.wantSlept)
+			}
+			for i := range slept {
+				if slept[i] > tt.wantSlept[i] || slept[i] < tt.wantSlept[i]/2 {
+					t.Errorf("slept %v, want between half of and %v", slept, tt.wantSlept)
+				}
+			}
+		})
+	}
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if got := jitter(time.Second); got < 500*time.Millisecond || got > time.Second {
+			t.Fatalf("jitter(1s) = %v, want between 500ms and 1s", got)
+		}
+	}
+	if got := jitter(0); got != 0 {
+		t.Errorf("jitter(0) = %v, want 0", got)
+	}
+}
+
+func TestRetryableStatus(t *testing.T) {
+	tests := []struct {
+		code int
+		want bool
+	}{
+		{http.StatusOK, false},
+		{http.StatusBadRequest, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusRequestTimeout, true},
+		{http.StatusTooManyRequests, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusNotImplemented, false},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusGatewayTimeout, true},
+		{http.StatusHTTPVersionNotSupported, false},
+		{http.StatusInsufficientStorage, false},
+		{StatusOverloaded, true},
+	}
+
+	for _, tt := range tests {
+		if got := RetryableStatus(tt.code); got != tt.want {
+			t.Errorf("RetryableStatus(%d) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+// idleCountingTransport counts CloseIdleConnections calls.
+type idleCountingTransport struct {
+	http.RoundTripper
+	closes int
+}
+
+func (t *idleCountingTransport) CloseIdleConnections() {
+	t.closes++
+}
+
+func TestHTTPClient_Shutdown_OwnsTransport(t *testing.T) {
+	for _, owns := range []bool{false, true} {
+		transport := &idleCountingTransport{RoundTripper: http.DefaultTransport}
+		client := NewHTTPClient(&http.Client{Transport: transport}, "http://localhost", "test-key", "test-version")
+		client.OwnsTransport = owns
+
+		if err := client.Shutdown(context.Background()); err != nil {
+			t.Fatalf("Shutdown() error = %v", err)
+		}
+		want := 0
+		if owns {
+			want = 1
+		}
+		if transport.closes != want {
+			t.Errorf("OwnsTransport = %v: CloseIdleConnections called %d times, want %d", owns, transport.closes, want)
+		}
+	}
+}
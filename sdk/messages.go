@@ -1,5 +1,12 @@
 package zaguansdk
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
 // MessagesRequest represents a request to Anthropic's native Messages API.
 //
 // This follows the Anthropic Messages API format and is exposed via the
@@ -15,8 +22,10 @@ type MessagesRequest struct {
 	Messages []AnthropicMessage `json:"messages"`
 
 	// System is the system prompt.
+	// Can be a string or []AnthropicContentBlock of text blocks; use the
+	// block form (see SetSystemBlocks) to cache the system prompt.
 	// Optional.
-	System string `json:"system,omitempty"`
+	System interface{} `json:"system,omitempty"`
 
 	// MaxTokens is the maximum number of tokens to generate.
 	// Required for Anthropic API.
@@ -47,11 +56,35 @@ type MessagesRequest struct {
 	// Optional.
 	Thinking *AnthropicThinkingConfig `json:"thinking,omitempty"`
 
+	// Tools are the tools the model may call.
+	// Optional.
+	Tools []AnthropicTool `json:"tools,omitempty"`
+
+	// ToolChoice controls how the model uses tools.
+	// Can be an AnthropicToolChoice or a raw map.
+	// Optional.
+	ToolChoice interface{} `json:"tool_choice,omitempty"`
+
 	// Metadata for application-specific tracking.
+	// Non-string values are sent JSON-encoded as strings, or rejected if
+	// Config.StrictMetadata is set.
 	// Optional.
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// SetSystemBlocks sets the system prompt to the given text blocks, typically
+// built with AnthropicTextBlock.
+//
+// Example:
+//
+//	req.SetSystemBlocks(
+//		zaguansdk.AnthropicTextBlock(instructions, true),
+//		zaguansdk.AnthropicTextBlock("Answer briefly.", false),
+//	)
+func (r *MessagesRequest) SetSystemBlocks(blocks ...AnthropicContentBlock) {
+	r.System = blocks
+}
+
 // AnthropicMessage represents a message in Anthropic's format.
 type AnthropicMessage struct {
 	// Role is the message role.
@@ -65,6 +98,146 @@ type AnthropicMessage struct {
 	Content interface{} `json:"content"`
 }
 
+// AnthropicTool defines a tool the model can call.
+type AnthropicTool struct {
+	// Name is the tool name.
+	// Required.
+	Name string `json:"name"`
+
+	// Description explains what the tool does.
+	// Optional.
+	Description string `json:"description,omitempty"`
+
+	// InputSchema is the JSON Schema for the tool input.
+	// Required.
+	InputSchema interface{} `json:"input_schema"`
+
+	// CacheControl marks the end of a cacheable prompt prefix.
+	// Optional.
+	CacheControl *AnthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+// AnthropicToolChoice controls how the model uses tools.
+type AnthropicToolChoice struct {
+	// Type is the tool choice mode.
+	// Values: "auto", "any", "tool", "none"
+	// Required.
+	Type string `json:"type"`
+
+	// Name is the tool the model must use (for type="tool").
+	// Optional.
+	Name string `json:"name,omitempty"`
+
+	// DisableParallelToolUse limits the model to at most one tool call.
+	// Optional.
+	DisableParallelToolUse bool `json:"disable_parallel_tool_use,omitempty"`
+}
+
+// ConvertToAnthropicMessages converts chat completion messages to the
+// Messages API format.
+//
+// System and developer messages are removed and their text joined into the
+// returned system prompt. User and assistant messages keep their role; string
+// content is passed through and []ContentPart content becomes text and image
+// blocks, with base64 data URIs sent as base64 image sources. Tool messages,
+// tool calls and audio parts have no direct equivalent and are rejected with
+// a *ValidationError; use Conversation for tool-using histories.
+//
+// Example:
+//
+//	messages, system, err := zaguansdk.ConvertToAnthropicMessages(history)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	resp, err := client.Messages(ctx, zaguansdk.MessagesRequest{
+//		Model:     "anthropic/claude-3-5-sonnet-20241022",
+//		MaxTokens: 1024,
+//		System:    system,
+//		Messages:  messages,
+//	}, nil)
+func ConvertToAnthropicMessages(msgs []Message) ([]AnthropicMessage, string, error) {
+	var system []string
+	messages := make([]AnthropicMessage, 0, len(msgs))
+
+	for i, msg := range msgs {
+		field := fmt.Sprintf("messages[%d]", i)
+
+		switch msg.Role {
+		case "system", "developer":
+			text, _ := contentText(msg.Content)
+			system = append(system, text)
+			continue
+		case "user", "assistant":
+		default:
+			return nil, "", &ValidationError{
+				Field:   field + ".role",
+				Message: fmt.Sprintf("role %q has no Messages API equivalent", msg.Role),
+			}
+		}
+
+		if len(msg.ToolCalls) > 0 {
+			return nil, "", &ValidationError{
+				Field:   field + ".tool_calls",
+				Message: "tool calls are not supported; use Conversation.AnthropicMessages",
+			}
+		}
+
+		var content interface{}
+		switch c := msg.Content.(type) {
+		case string:
+			content = c
+		case []ContentPart:
+			blocks := make([]AnthropicContentBlock, 0, len(c))
+			for j, part := range c {
+				block, err := anthropicBlockFromPart(part)
+				if err != nil {
+					return nil, "", &ValidationError{
+						Field:   fmt.Sprintf("%s.content[%d]", field, j),
+						Message: err.Error(),
+					}
+				}
+				blocks = append(blocks, block)
+			}
+			content = blocks
+		default:
+			return nil, "", &ValidationError{
+				Field:   field + ".content",
+				Message: fmt.Sprintf("content must be a string or []ContentPart, got %T", msg.Content),
+			}
+		}
+
+		messages = append(messages, AnthropicMessage{Role: msg.Role, Content: content})
+	}
+
+	return messages, strings.Join(system, "\n\n"), nil
+}
+
+// anthropicBlockFromPart converts a chat content part to a Messages API
+// content block.
+func anthropicBlockFromPart(part ContentPart) (AnthropicContentBlock, error) {
+	switch part.Type {
+	case "text":
+		return AnthropicTextBlock(part.Text, false), nil
+	case "image_url":
+		if part.ImageURL == nil || part.ImageURL.URL == "" {
+			return AnthropicContentBlock{}, errors.New("image_url is required")
+		}
+		url := part.ImageURL.URL
+		if rest, ok := strings.CutPrefix(url, "data:"); ok {
+			mediaType, data, ok := strings.Cut(rest, ";base64,")
+			if !ok {
+				return AnthropicContentBlock{}, errors.New("image data URI must be base64-encoded")
+			}
+			return AnthropicImageBlock(mediaType, data, false), nil
+		}
+		return AnthropicContentBlock{
+			Type:   "image",
+			Source: &AnthropicImageSource{Type: "url", URL: url},
+		}, nil
+	}
+	return AnthropicContentBlock{}, fmt.Errorf("content part type %q is not supported", part.Type)
+}
+
 // AnthropicThinkingConfig configures extended thinking (Beta).
 type AnthropicThinkingConfig struct {
 	// Type controls thinking behavior.
@@ -72,7 +245,9 @@ type AnthropicThinkingConfig struct {
 	// Required.
 	Type string `json:"type"`
 
-	// BudgetTokens is the maximum number of thinking tokens (1,000 - 10,000).
+	// BudgetTokens is the maximum number of thinking tokens (at least 1,000).
+	// Thinking counts toward MaxTokens, so the budget must be less than
+	// MaxTokens when thinking is enabled.
 	// Optional.
 	BudgetTokens int `json:"budget_tokens,omitempty"`
 }
@@ -95,7 +270,8 @@ type MessagesResponse struct {
 	Model string `json:"model"`
 
 	// StopReason indicates why the generation stopped.
-	// Values: "end_turn", "max_tokens", "stop_sequence", "tool_use"
+	// Values: "end_turn", "max_tokens", "stop_sequence", "tool_use",
+	// "pause_turn", "refusal" (see the StopReason constants)
 	StopReason string `json:"stop_reason,omitempty"`
 
 	// StopSequence is the stop sequence that was matched (if any).
@@ -105,10 +281,80 @@ type MessagesResponse struct {
 	Usage AnthropicUsage `json:"usage"`
 }
 
+// Stop reasons reported in MessagesResponse.StopReason.
+const (
+	// StopReasonEndTurn means the model finished its turn naturally.
+	StopReasonEndTurn = "end_turn"
+
+	// StopReasonMaxTokens means the response hit MaxTokens.
+	StopReasonMaxTokens = "max_tokens"
+
+	// StopReasonStopSequence means a stop sequence was generated.
+	StopReasonStopSequence = "stop_sequence"
+
+	// StopReasonToolUse means the model is waiting for tool results.
+	StopReasonToolUse = "tool_use"
+
+	// StopReasonPauseTurn means the server paused a long-running turn (for
+	// example during server-side tool use). The turn is not finished: send
+	// the response back as an assistant message to let the model continue.
+	StopReasonPauseTurn = "pause_turn"
+
+	// StopReasonRefusal means the model declined to respond for safety
+	// reasons.
+	StopReasonRefusal = "refusal"
+)
+
+// IsRefusal reports whether the model refused to respond.
+func (r *MessagesResponse) IsRefusal() bool {
+	return r.StopReason == StopReasonRefusal
+}
+
+// IsPausedTurn reports whether the server paused the turn before it finished.
+//
+// A paused turn must be resubmitted to continue: append the response content
+// as an assistant message, unchanged, and send the request again.
+//
+// Example:
+//
+//	for resp.IsPausedTurn() {
+//		req.Messages = append(req.Messages, zaguansdk.AnthropicMessage{
+//			Role:    "assistant",
+//			Content: resp.Content,
+//		})
+//		resp, err = client.Messages(ctx, req, nil)
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func (r *MessagesResponse) IsPausedTurn() bool {
+	return r.StopReason == StopReasonPauseTurn
+}
+
+// AnswerText returns the concatenated text of the response's "text" content
+// blocks, skipping "thinking" and "tool_use" blocks.
+//
+// Example:
+//
+//	resp, err := client.Messages(ctx, req, nil)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	fmt.Println(resp.AnswerText())
+func (r *MessagesResponse) AnswerText() string {
+	var sb strings.Builder
+	for _, block := range r.Content {
+		if block.Type == "text" {
+			sb.WriteString(block.Text)
+		}
+	}
+	return sb.String()
+}
+
 // AnthropicContentBlock represents a content block in the response.
 type AnthropicContentBlock struct {
 	// Type is the content block type.
-	// Values: "text", "thinking", "tool_use"
+	// Values: "text", "image", "thinking", "tool_use", "tool_result"
 	Type string `json:"type"`
 
 	// Text content (for type="text").
@@ -129,6 +375,141 @@ type AnthropicContentBlock struct {
 
 	// Input is the tool input (for type="tool_use").
 	Input interface{} `json:"input,omitempty"`
+
+	// ToolUseID is the ID of the tool use being answered (for type="tool_result").
+	ToolUseID string `json:"tool_use_id,omitempty"`
+
+	// Content is the tool result (for type="tool_result").
+	// Can be a string or an array of content blocks.
+	Content interface{} `json:"content,omitempty"`
+
+	// IsError marks a tool result as a failed tool execution (for type="tool_result").
+	IsError bool `json:"is_error,omitempty"`
+
+	// Source is the image data (for type="image").
+	Source *AnthropicImageSource `json:"source,omitempty"`
+
+	// CacheControl marks the end of a cacheable prompt prefix (request only).
+	// Use CacheControl() for the default ephemeral cache.
+	CacheControl *AnthropicCacheControl `json:"cache_control,omitempty"`
+}
+
+// AnthropicImageSource is the source of an image content block.
+type AnthropicImageSource struct {
+	// Type is the source type.
+	// Values: "base64", "url"
+	Type string `json:"type"`
+
+	// MediaType is the image MIME type (for type="base64").
+	// Values: "image/jpeg", "image/png", "image/gif", "image/webp"
+	MediaType string `json:"media_type,omitempty"`
+
+	// Data is the base64-encoded image (for type="base64").
+	Data string `json:"data,omitempty"`
+
+	// URL is the image URL (for type="url").
+	URL string `json:"url,omitempty"`
+}
+
+// AnthropicCacheControl configures prompt caching for a content block.
+type AnthropicCacheControl struct {
+	// Type is the cache type.
+	// Values: "ephemeral"
+	Type string `json:"type"`
+}
+
+// CacheControl returns the ephemeral cache control Anthropic uses for prompt
+// caching. Setting it on a content block caches the prompt up to and
+// including that block; later requests with the same prefix read it from the
+// cache, as reported by AnthropicUsage.CacheReadInputTokens.
+func CacheControl() *AnthropicCacheControl {
+	return &AnthropicCacheControl{Type: "ephemeral"}
+}
+
+// AnthropicTextBlock creates a text content block. If cache is true the block
+// is marked with CacheControl(), ending the cacheable prefix.
+//
+// Example:
+//
+//	req.Messages = []zaguansdk.AnthropicMessage{{
+//		Role: "user",
+//		Content: []zaguansdk.AnthropicContentBlock{
+//			zaguansdk.AnthropicTextBlock(document, true),
+//			zaguansdk.AnthropicTextBlock("Summarize the document.", false),
+//		},
+//	}}
+func AnthropicTextBlock(text string, cache bool) AnthropicContentBlock {
+	block := AnthropicContentBlock{Type: "text", Text: text}
+	if cache {
+		block.CacheControl = CacheControl()
+	}
+	return block
+}
+
+// AnthropicImageBlock creates an image content block from base64-encoded
+// data of the given media type (e.g. "image/png"). If cache is true the block
+// is marked with CacheControl(), ending the cacheable prefix.
+func AnthropicImageBlock(mediaType, data string, cache bool) AnthropicContentBlock {
+	block := AnthropicContentBlock{
+		Type: "image",
+		Source: &AnthropicImageSource{
+			Type:      "base64",
+			MediaType: mediaType,
+			Data:      data,
+		},
+	}
+	if cache {
+		block.CacheControl = CacheControl()
+	}
+	return block
+}
+
+// AnthropicToolResultBlock creates a tool_result content block answering the
+// tool_use block identified by toolUseID. content can be a string or a slice
+// of content blocks.
+//
+// Example:
+//
+//	for _, block := range resp.Content {
+//		if block.Type == "tool_use" {
+//			reply := zaguansdk.AnthropicToolResultBlock(block.ID, runTool(block.Name, block.Input))
+//			req.Messages = append(req.Messages,
+//				zaguansdk.AnthropicMessage{Role: "assistant", Content: resp.Content},
+//				zaguansdk.AnthropicMessage{Role: "user", Content: []zaguansdk.AnthropicContentBlock{reply}},
+//			)
+//		}
+//	}
+func AnthropicToolResultBlock(toolUseID string, content interface{}) AnthropicContentBlock {
+	return AnthropicContentBlock{
+		Type:      "tool_result",
+		ToolUseID: toolUseID,
+		Content:   content,
+	}
+}
+
+// AnthropicToolErrorBlock creates a tool_result content block reporting that
+// the tool identified by toolUseID failed with err.
+//
+// The block has is_error set so the model knows the tool failed. Send it in a
+// user message that answers the assistant's tool_use block.
+//
+// Example:
+//
+//	result, err := runTool(block.Input)
+//	if err != nil {
+//		reply := zaguansdk.AnthropicToolErrorBlock(block.ID, err)
+//		req.Messages = append(req.Messages, zaguansdk.AnthropicMessage{
+//			Role:    "user",
+//			Content: []zaguansdk.AnthropicContentBlock{reply},
+//		})
+//	}
+func AnthropicToolErrorBlock(toolUseID string, err error) AnthropicContentBlock {
+	return AnthropicContentBlock{
+		Type:      "tool_result",
+		ToolUseID: toolUseID,
+		Content:   toolErrorText(err),
+		IsError:   true,
+	}
 }
 
 // AnthropicUsage represents token usage in Anthropic's format.
@@ -155,7 +536,11 @@ type CountTokensRequest struct {
 	Messages []AnthropicMessage `json:"messages"`
 
 	// System is the system prompt.
-	System string `json:"system,omitempty"`
+	// Can be a string or []AnthropicContentBlock, as in MessagesRequest.
+	System interface{} `json:"system,omitempty"`
+
+	// Tools are the tool definitions to count tokens for.
+	Tools []AnthropicTool `json:"tools,omitempty"`
 }
 
 // CountTokensResponse represents the response from token counting.
@@ -230,3 +615,96 @@ type MessagesBatchRequestCounts struct {
 	// Expired is the number of expired requests.
 	Expired int `json:"expired"`
 }
+
+// IsEnded returns true if the batch has finished processing and its results
+// are available.
+func (b *MessagesBatchResponse) IsEnded() bool {
+	return b.ProcessingStatus == "ended"
+}
+
+// IsInProgress returns true if the batch is still processing requests.
+func (b *MessagesBatchResponse) IsInProgress() bool {
+	return b.ProcessingStatus == "in_progress"
+}
+
+// IsCanceling returns true if the batch is being canceled.
+func (b *MessagesBatchResponse) IsCanceling() bool {
+	return b.ProcessingStatus == "canceling"
+}
+
+// TotalRequests returns the number of requests in the batch across all
+// request counts.
+func (b *MessagesBatchResponse) TotalRequests() int {
+	c := b.RequestCounts
+	return c.Processing + c.Succeeded + c.Errored + c.Canceled + c.Expired
+}
+
+// ParseCreatedAt parses CreatedAt into a time.Time. An empty value parses as
+// the zero time.
+func (b *MessagesBatchResponse) ParseCreatedAt() (time.Time, error) {
+	return parseBatchTime(b.CreatedAt)
+}
+
+// ParseExpiresAt parses ExpiresAt into a time.Time. An empty value parses as
+// the zero time.
+func (b *MessagesBatchResponse) ParseExpiresAt() (time.Time, error) {
+	return parseBatchTime(b.ExpiresAt)
+}
+
+// ParseEndedAt parses EndedAt into a time.Time. It is the zero time until the
+// batch has ended.
+func (b *MessagesBatchResponse) ParseEndedAt() (time.Time, error) {
+	return parseBatchTime(b.EndedAt)
+}
+
+// parseBatchTime parses an RFC 3339 timestamp, treating "" as the zero time.
+func parseBatchTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// MessagesBatchResult is a single line of a Messages batch's results.
+type MessagesBatchResult struct {
+	// CustomID is the user-provided identifier from the batch request.
+	CustomID string `json:"custom_id"`
+
+	// Type is the outcome of the request.
+	// Values: "succeeded", "errored", "canceled", "expired"
+	Type string `json:"type"`
+
+	// Message is the response for "succeeded" results.
+	Message *MessagesResponse `json:"message,omitempty"`
+
+	// Error describes the failure for "errored" results.
+	Error *MessagesBatchError `json:"error,omitempty"`
+}
+
+// IsSuccess returns true if the individual request succeeded.
+func (r *MessagesBatchResult) IsSuccess() bool {
+	return r.Type == "succeeded" && r.Message != nil
+}
+
+// MessagesBatchError describes why a Messages batch request failed.
+type MessagesBatchError struct {
+	// Type is the error type (e.g. "invalid_request_error").
+	Type string `json:"type"`
+
+	// Message is the error message.
+	Message string `json:"message"`
+}
+
+// messagesBatchResultLine is the wire format of a Messages batch results line.
+// The error is wrapped in an Anthropic error envelope.
+type messagesBatchResultLine struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string            `json:"type"`
+		Message *MessagesResponse `json:"message"`
+		Error   *struct {
+			Type  string              `json:"type"`
+			Error *MessagesBatchError `json:"error"`
+		} `json:"error"`
+	} `json:"result"`
+}
@@ -1,7 +1,11 @@
 package zaguansdk
 
 import (
+	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestMessagesRequest_Validation(t *testing.T) {
@@ -39,6 +43,103 @@ func TestMessagesRequest_WithSystem(t *testing.T) {
 	}
 }
 
+func TestMessagesRequest_SystemForms(t *testing.T) {
+	cached := MessagesRequest{}
+	cached.SetSystemBlocks(
+		AnthropicTextBlock("You are a legal assistant.", true),
+		AnthropicTextBlock("Answer briefly.", false),
+	)
+
+	tests := []struct {
+		name   string
+		system interface{}
+		want   string
+	}{
+		{
+			name:   "string",
+			system: "You are a helpful assistant.",
+			want:   `"system":"You are a helpful assistant."`,
+		},
+		{
+			name:   "cached blocks",
+			system: cached.System,
+			want: `"system":[{"type":"text","text":"You are a legal assistant.","cache_control":{"type":"ephemeral"}},` +
+				`{"type":"text","text":"Answer briefly."}]`,
+		},
+		{
+			name:   "empty string",
+			system: "",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := MessagesRequest{
+				Model:     "anthropic/claude-3-5-sonnet-20241022",
+				MaxTokens: 1024,
+				System:    tt.system,
+				Messages:  []AnthropicMessage{{Role: "user", Content: "Hello"}},
+			}
+			if err := validateMessagesRequest(&req); err != nil {
+				t.Fatalf("validateMessagesRequest() error = %v", err)
+			}
+
+			data, err := json.Marshal(req)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if tt.want == "" {
+				if strings.Contains(string(data), `"system"`) {
+					t.Errorf("Marshal() = %s, want system omitted", data)
+				}
+				return
+			}
+			if !strings.Contains(string(data), tt.want) {
+				t.Errorf("Marshal() = %s, want it to contain %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessagesRequest_InvalidSystem(t *testing.T) {
+	tests := []struct {
+		name      string
+		system    interface{}
+		wantField string
+	}{
+		{
+			name:      "non-text block",
+			system:    []AnthropicContentBlock{AnthropicImageBlock("image/png", "iVBORw0KGgo=", false)},
+			wantField: "system[0].type",
+		},
+		{
+			name:      "unsupported type",
+			system:    42,
+			wantField: "system",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := MessagesRequest{
+				Model:     "anthropic/claude-3-5-sonnet-20241022",
+				MaxTokens: 1024,
+				System:    tt.system,
+				Messages:  []AnthropicMessage{{Role: "user", Content: "Hello"}},
+			}
+			err := validateMessagesRequest(&req)
+			ve, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("validateMessagesRequest() error = %v, want *ValidationError", err)
+			}
+			if ve.Field != tt.wantField {
+				t.Errorf("ValidationError.Field = %q, want %q", ve.Field, tt.wantField)
+			}
+		})
+	}
+}
+
 func TestMessagesRequest_WithThinking(t *testing.T) {
 	req := MessagesRequest{
 		Model:     "anthropic/claude-3-5-sonnet-20241022",
@@ -325,3 +426,351 @@ func TestMessagesBatchRequestCounts(t *testing.T) {
 		t.Errorf("total count = %d, want 18", total)
 	}
 }
+
+func TestMessagesBatchResponseHelpers(t *testing.T) {
+	tests := []struct {
+		status                                   string
+		wantEnded, wantInProgress, wantCanceling bool
+	}{
+		{"in_progress", false, true, false},
+		{"canceling", false, false, true},
+		{"ended", true, false, false},
+		{"", false, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.status, func(t *testing.T) {
+			batch := MessagesBatchResponse{ProcessingStatus: tt.status}
+			if got := batch.IsEnded(); got != tt.wantEnded {
+				t.Errorf("IsEnded() = %v, want %v", got, tt.wantEnded)
+			}
+			if got := batch.IsInProgress(); got != tt.wantInProgress {
+				t.Errorf("IsInProgress() = %v, want %v", got, tt.wantInProgress)
+			}
+			if got := batch.IsCanceling(); got != tt.wantCanceling {
+				t.Errorf("IsCanceling() = %v, want %v", got, tt.wantCanceling)
+			}
+		})
+	}
+
+	t.Run("TotalRequests", func(t *testing.T) {
+		batch := MessagesBatchResponse{RequestCounts: MessagesBatchRequestCounts{
+			Processing: 5,
+			Succeeded:  10,
+			Errored:    2,
+			Canceled:   1,
+			Expired:    3,
+		}}
+		if got := batch.TotalRequests(); got != 21 {
+			t.Errorf("TotalRequests() = %d, want 21", got)
+		}
+	})
+
+	t.Run("ParseTimes", func(t *testing.T) {
+		batch := MessagesBatchResponse{
+			CreatedAt: "2025-11-19T12:00:00Z",
+			ExpiresAt: "2025-11-20T12:00:00.123456Z",
+		}
+
+		created, err := batch.ParseCreatedAt()
+		if err != nil || !created.Equal(time.Date(2025, 11, 19, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("ParseCreatedAt() = %v, %v", created, err)
+		}
+		expires, err := batch.ParseExpiresAt()
+		if err != nil || !expires.Equal(time.Date(2025, 11, 20, 12, 0, 0, 123456000, time.UTC)) {
+			t.Errorf("ParseExpiresAt() = %v, %v", expires, err)
+		}
+		ended, err := batch.ParseEndedAt()
+		if err != nil || !ended.IsZero() {
+			t.Errorf("ParseEndedAt() = %v, %v, want the zero time for an unended batch", ended, err)
+		}
+
+		batch.EndedAt = "yesterday"
+		if _, err := batch.ParseEndedAt(); err == nil {
+			t.Error("ParseEndedAt() error = nil, want an error for an invalid timestamp")
+		}
+	})
+}
+
+func TestAnthropicToolErrorBlock(t *testing.T) {
+	block := AnthropicToolErrorBlock("toolu_123", errors.New("city not found"))
+
+	data, err := json.Marshal(block)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `{"type":"tool_result","tool_use_id":"toolu_123","content":"Error: city not found","is_error":true}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestAnthropicContentBlockBuilders(t *testing.T) {
+	tests := []struct {
+		name  string
+		block AnthropicContentBlock
+		want  string
+	}{
+		{
+			name:  "text",
+			block: AnthropicTextBlock("Summarize this.", false),
+			want:  `{"type":"text","text":"Summarize this."}`,
+		},
+		{
+			name:  "cached text",
+			block: AnthropicTextBlock("Long document", true),
+			want:  `{"type":"text","text":"Long document","cache_control":{"type":"ephemeral"}}`,
+		},
+		{
+			name:  "image",
+			block: AnthropicImageBlock("image/png", "iVBORw0KGgo=", false),
+			want:  `{"type":"image","source":{"type":"base64","media_type":"image/png","data":"iVBORw0KGgo="}}`,
+		},
+		{
+			name:  "cached image",
+			block: AnthropicImageBlock("image/jpeg", "/9j/4AAQ", true),
+			want:  `{"type":"image","source":{"type":"base64","media_type":"image/jpeg","data":"/9j/4AAQ"},"cache_control":{"type":"ephemeral"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.block)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessagesRequest_WithTools(t *testing.T) {
+	req := MessagesRequest{
+		Model:     "anthropic/claude-3-5-sonnet-20241022",
+		MaxTokens: 1024,
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: "What's the weather in Paris?"},
+		},
+		Tools: []AnthropicTool{
+			{
+				Name:        "get_weather",
+				Description: "Get the current weather",
+				InputSchema: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+					"required":   []string{"city"},
+				},
+			},
+		},
+		ToolChoice: AnthropicToolChoice{Type: "tool", Name: "get_weather"},
+	}
+
+	if err := validateMessagesRequest(&req); err != nil {
+		t.Fatalf("validateMessagesRequest() error = %v", err)
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	tools, ok := body["tools"].([]interface{})
+	if !ok || len(tools) != 1 {
+		t.Fatalf("tools = %v, want one tool", body["tools"])
+	}
+	tool := tools[0].(map[string]interface{})
+	if tool["name"] != "get_weather" {
+		t.Errorf("tools[0].name = %v, want get_weather", tool["name"])
+	}
+	if _, ok := tool["input_schema"].(map[string]interface{}); !ok {
+		t.Errorf("tools[0].input_schema = %v, want an object", tool["input_schema"])
+	}
+	choice := body["tool_choice"].(map[string]interface{})
+	if choice["type"] != "tool" || choice["name"] != "get_weather" {
+		t.Errorf("tool_choice = %v, want type tool and name get_weather", choice)
+	}
+}
+
+func TestMessages_ToolResultFollowUp(t *testing.T) {
+	data := `{
+		"id": "msg_1",
+		"type": "message",
+		"role": "assistant",
+		"model": "claude-3-5-sonnet-20241022",
+		"stop_reason": "tool_use",
+		"content": [
+			{"type": "text", "text": "Let me check."},
+			{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {"city": "Paris"}}
+		],
+		"usage": {"input_tokens": 20, "output_tokens": 10}
+	}`
+
+	var resp MessagesResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	toolUse := resp.Content[1]
+	if toolUse.ID != "toolu_1" || toolUse.Name != "get_weather" {
+		t.Fatalf("tool_use block = %+v, want ID toolu_1 and name get_weather", toolUse)
+	}
+
+	// Echo the assistant turn and answer the tool call
+	followUp := []AnthropicMessage{
+		{Role: "assistant", Content: resp.Content},
+		{Role: "user", Content: []AnthropicContentBlock{AnthropicToolResultBlock(toolUse.ID, "18°C and sunny")}},
+	}
+
+	got, err := json.Marshal(followUp)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `[{"role":"assistant","content":[{"type":"text","text":"Let me check."},` +
+		`{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}]},` +
+		`{"role":"user","content":[{"type":"tool_result","tool_use_id":"toolu_1","content":"18°C and sunny"}]}]`
+	if string(got) != want {
+		t.Errorf("Marshal() = %s, want %s", got, want)
+	}
+}
+
+func TestConvertToAnthropicMessages(t *testing.T) {
+	msgs := []Message{
+		{Role: "system", Content: "You are a helpful assistant."},
+		{Role: "developer", Content: "Answer briefly."},
+		{Role: "user", Content: "Hello"},
+		{Role: "assistant", Content: "Hi! How can I help?"},
+		NewUserMessage(
+			TextPart("What is in these images?"),
+			ImageBase64Part("image/png", []byte("png"), "high"),
+			ImageURLPart("https://example.com/photo.jpg", "auto"),
+		),
+	}
+
+	messages, system, err := ConvertToAnthropicMessages(msgs)
+	if err != nil {
+		t.Fatalf("ConvertToAnthropicMessages() error = %v", err)
+	}
+
+	if want := "You are a helpful assistant.\n\nAnswer briefly."; system != want {
+		t.Errorf("system = %q, want %q", system, want)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("len(messages) = %d, want 3", len(messages))
+	}
+	if messages[0].Role != "user" || messages[0].Content != "Hello" {
+		t.Errorf("messages[0] = %+v, want user message Hello", messages[0])
+	}
+	if messages[1].Role != "assistant" {
+		t.Errorf("messages[1].Role = %q, want assistant", messages[1].Role)
+	}
+
+	data, err := json.Marshal(messages[2])
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"role":"user","content":[{"type":"text","text":"What is in these images?"},` +
+		`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"cG5n"}},` +
+		`{"type":"image","source":{"type":"url","url":"https://example.com/photo.jpg"}}]}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestConvertToAnthropicMessages_Unsupported(t *testing.T) {
+	tests := []struct {
+		name      string
+		msgs      []Message
+		wantField string
+	}{
+		{
+			name:      "tool role",
+			msgs:      []Message{{Role: "tool", Content: "72F", ToolCallID: "call_1"}},
+			wantField: "messages[0].role",
+		},
+		{
+			name: "tool calls",
+			msgs: []Message{
+				{Role: "user", Content: "Weather?"},
+				{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Type: "function"}}},
+			},
+			wantField: "messages[1].tool_calls",
+		},
+		{
+			name: "audio part",
+			msgs: []Message{NewUserMessage(ContentPart{
+				Type:       "input_audio",
+				InputAudio: &InputAudio{Data: "AAAA", Format: "wav"},
+			})},
+			wantField: "messages[0].content[0]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ConvertToAnthropicMessages(tt.msgs)
+			ve, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("ConvertToAnthropicMessages() error = %v, want *ValidationError", err)
+			}
+			if ve.Field != tt.wantField {
+				t.Errorf("ValidationError.Field = %q, want %q", ve.Field, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestMessagesResponse_AnswerText(t *testing.T) {
+	resp := MessagesResponse{
+		Content: []AnthropicContentBlock{
+			{Type: "thinking", Thinking: "The user wants the weather."},
+			{Type: "text", Text: "Let me check. "},
+			{Type: "tool_use", ID: "toolu_1", Name: "get_weather", Input: map[string]interface{}{"city": "Paris"}},
+			{Type: "text", Text: "It is sunny."},
+		},
+	}
+
+	if got := resp.AnswerText(); got != "Let me check. It is sunny." {
+		t.Errorf("AnswerText() = %q, want %q", got, "Let me check. It is sunny.")
+	}
+
+	empty := MessagesResponse{}
+	if got := empty.AnswerText(); got != "" {
+		t.Errorf("AnswerText() on empty response = %q, want empty", got)
+	}
+}
+
+func TestMessagesResponse_StopReasons(t *testing.T) {
+	tests := []struct {
+		stopReason  string
+		wantRefusal bool
+		wantPaused  bool
+	}{
+		{StopReasonEndTurn, false, false},
+		{StopReasonToolUse, false, false},
+		{StopReasonRefusal, true, false},
+		{StopReasonPauseTurn, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stopReason, func(t *testing.T) {
+			var resp MessagesResponse
+			data := `{"id":"msg_1","type":"message","role":"assistant","content":[],"stop_reason":"` + tt.stopReason + `"}`
+			if err := json.Unmarshal([]byte(data), &resp); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if got := resp.IsRefusal(); got != tt.wantRefusal {
+				t.Errorf("IsRefusal() = %v, want %v", got, tt.wantRefusal)
+			}
+			if got := resp.IsPausedTurn(); got != tt.wantPaused {
+				t.Errorf("IsPausedTurn() = %v, want %v", got, tt.wantPaused)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Package zaguansdk provides transport middleware for the Zaguan SDK.
+//
+// This file implements Config.Middleware, which wraps every HTTP attempt the
+// client makes.
+package zaguansdk
+
+import "net/http"
+
+// RoundTripperFunc sends a single HTTP request and returns its response.
+// Like http.RoundTripper, it must return either a response or an error.
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the sending of HTTP requests. It receives the next step in
+// the chain and returns a function that may inspect or modify the request,
+// call next, and inspect or replace the response.
+//
+// Middleware runs once per attempt, inside the retry loop, after the SDK has
+// set its headers, so each retry is observable and header changes are
+// sent as-is.
+//
+// Example:
+//
+//	addTenant := func(next zaguansdk.RoundTripperFunc) zaguansdk.RoundTripperFunc {
+//		return func(req *http.Request) (*http.Response, error) {
+//			req.Header.Set("X-Tenant", "acme")
+//			return next(req)
+//		}
+//	}
+//	client := zaguansdk.NewClient(zaguansdk.Config{
+//		BaseURL:    baseURL,
+//		APIKey:     apiKey,
+//		Middleware: []zaguansdk.Middleware{addTenant},
+//	})
+type Middleware func(next RoundTripperFunc) RoundTripperFunc
+
+// chainMiddleware composes middleware so that the first element is the
+// outermost: it sees the request first and the response last.
+func chainMiddleware(middleware []Middleware) func(next func(*http.Request) (*http.Response, error)) func(*http.Request) (*http.Response, error) {
+	return func(next func(*http.Request) (*http.Response, error)) func(*http.Request) (*http.Response, error) {
+		rt := RoundTripperFunc(next)
+		for i := len(middleware) - 1; i >= 0; i-- {
+			rt = middleware[i](rt)
+		}
+		return rt
+	}
+}
@@ -0,0 +1,69 @@
+package zaguansdk
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
+)
+
+func TestClient_Middleware(t *testing.T) {
+	var tenants []string
+	failures := 1
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenants = append(tenants, r.Header.Get("X-Tenant"))
+		if failures > 0 {
+			failures--
+			testutil.ErrorHandler(http.StatusServiceUnavailable, "server_error", "try again")(w, r)
+			return
+		}
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next RoundTripperFunc) RoundTripperFunc {
+			return func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" request")
+				resp, err := next(req)
+				order = append(order, name+" response")
+				return resp, err
+			}
+		}
+	}
+	addTenant := func(next RoundTripperFunc) RoundTripperFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("X-Tenant", "acme")
+			return next(req)
+		}
+	}
+
+	client := NewClient(Config{
+		BaseURL:    mockServer.URL(),
+		APIKey:     "test-key",
+		Middleware: []Middleware{trace("outer"), trace("inner"), addTenant},
+	})
+
+	_, err := client.Chat(context.Background(), ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}, WithRetries(1, time.Millisecond))
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	// Middleware runs for each attempt, including the retry
+	if want := []string{"acme", "acme"}; !reflect.DeepEqual(tenants, want) {
+		t.Errorf("X-Tenant per attempt = %q, want %q", tenants, want)
+	}
+
+	attempt := []string{"outer request", "inner request", "inner response", "outer response"}
+	want := append(append([]string(nil), attempt...), attempt...)
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("middleware order = %q, want %q", order, want)
+	}
+}
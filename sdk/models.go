@@ -26,6 +26,14 @@ type Model struct {
 	// Example: "openai", "anthropic", "google"
 	OwnedBy string `json:"owned_by,omitempty"`
 
+	// Provider is the Zaguan provider that serves the model.
+	// Example: "openai", "anthropic", "google"
+	Provider string `json:"provider,omitempty"`
+
+	// Band is the Zaguan pricing band the model belongs to.
+	// Examples: "A", "B", "C"
+	Band string `json:"band,omitempty"`
+
 	// Description is a human-readable description of the model.
 	Description string `json:"description,omitempty"`
 
@@ -61,43 +69,57 @@ type ModelsResponse struct {
 	Data []Model `json:"data"`
 }
 
+// ListModelsOptions contains options for filtering the model list.
+type ListModelsOptions struct {
+	// Provider filters models by provider name.
+	// Example: "openai"
+	Provider string
+
+	// Band filters models by band.
+	// Example: "A"
+	Band string
+}
+
 // ListModels retrieves all available models from Zaguan CoreX.
 //
 // This includes models from all configured providers with their provider-prefixed IDs.
+// Pass ListModelsOptions to filter the list by provider or band.
 //
 // Example:
 //
-//	models, err := client.ListModels(ctx, nil)
+//	models, err := client.ListModels(ctx, &zaguansdk.ListModelsOptions{
+//		Provider: "anthropic",
+//	}, nil)
 //	if err != nil {
 //		log.Fatal(err)
 //	}
 //	for _, model := range models {
 //		fmt.Printf("%s - %s\n", model.ID, model.Description)
 //	}
-func (c *Client) ListModels(ctx context.Context, opts *RequestOptions) ([]Model, error) {
+func (c *Client) ListModels(ctx context.Context, listOpts *ListModelsOptions, opts *RequestOptions) ([]Model, error) {
 	c.log(ctx, LogLevelDebug, "listing models")
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "GET",
-		Path:   "/v1/models",
+		Operation:   "zaguan.ListModels",
+		Method:      "GET",
+		Path:        "/v1/models",
+		QueryParams: make(map[string]string),
 	}
 
-	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
+	// Add query parameters from list options
+	if listOpts != nil {
+		if listOpts.Provider != "" {
+			reqCfg.QueryParams["provider"] = listOpts.Provider
 		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
+		if listOpts.Band != "" {
+			reqCfg.QueryParams["band"] = listOpts.Band
 		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
 	}
 
+	// Apply request options
+	applyRequestOptions(&reqCfg, opts, c.timeout)
+
 	// Execute request
 	var resp ModelsResponse
 	if err := c.internalHTTP.DoJSON(ctx, reqCfg, &resp); err != nil {
@@ -125,24 +147,13 @@ func (c *Client) GetModel(ctx context.Context, modelID string, opts *RequestOpti
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "GET",
-		Path:   "/v1/models/" + modelID,
+		Operation: "zaguan.GetModel",
+		Method:    "GET",
+		Path:      "/v1/models/" + modelID,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var model Model
@@ -171,24 +182,13 @@ func (c *Client) DeleteModel(ctx context.Context, modelID string, opts *RequestO
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "DELETE",
-		Path:   "/v1/models/" + modelID,
+		Operation: "zaguan.DeleteModel",
+		Method:    "DELETE",
+		Path:      "/v1/models/" + modelID,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request (no response body expected)
 	resp, err := c.internalHTTP.Do(ctx, reqCfg)
@@ -200,7 +200,7 @@ func (c *Client) DeleteModel(ctx context.Context, modelID string, opts *RequestO
 
 	// Check for error status codes
 	if resp.StatusCode >= 400 {
-		return internal.ParseErrorResponse(resp)
+		return c.internalHTTP.ParseErrorResponse(resp)
 	}
 
 	c.log(ctx, LogLevelDebug, "delete model request succeeded", "model_id", modelID)
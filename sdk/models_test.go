@@ -46,7 +46,7 @@ func TestClient_ListModels(t *testing.T) {
 		APIKey:  "test-key",
 	})
 
-	models, err := client.ListModels(context.Background(), nil)
+	models, err := client.ListModels(context.Background(), nil, nil)
 	if err != nil {
 		t.Fatalf("ListModels() error = %v", err)
 	}
@@ -60,6 +60,56 @@ func TestClient_ListModels(t *testing.T) {
 	}
 }
 
+func TestClient_ListModels_WithOptions(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("provider"); got != "anthropic" {
+				t.Errorf("provider query = %q, want anthropic", got)
+			}
+			if got := r.URL.Query().Get("band"); got != "B" {
+				t.Errorf("band query = %q, want B", got)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{
+				"object": "list",
+				"data": [
+					{
+						"id": "anthropic/claude-3-5-sonnet-20241022",
+						"object": "model",
+						"created": 1677652288,
+						"owned_by": "anthropic",
+						"provider": "anthropic",
+						"band": "B"
+					}
+				]
+			}`))
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	models, err := client.ListModels(context.Background(), &ListModelsOptions{
+		Provider: "anthropic",
+		Band:     "B",
+	}, nil)
+	if err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+
+	if len(models) != 1 {
+		t.Fatalf("ListModels() returned %d models, want 1", len(models))
+	}
+	if models[0].Provider != "anthropic" || models[0].Band != "B" {
+		t.Errorf("model = %+v, want provider anthropic and band B", models[0])
+	}
+}
+
 func TestClient_GetModel(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -8,6 +8,7 @@ package zaguansdk
 
 import (
 	"context"
+	"sort"
 
 	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
 )
@@ -16,17 +17,61 @@ import (
 //
 // The moderation endpoint checks whether content complies with usage policies.
 type ModerationRequest struct {
-	// Input is the text to classify.
-	// Can be a string or array of strings.
+	// Input is the content to classify.
+	// Can be a string, an array of strings, or, for multimodal models such
+	// as "omni-moderation-latest", a []ModerationInput of text and image
+	// parts built with ModerationTextInput and ModerationImageInput.
+	// A []interface{} of strings is converted to []string before sending.
 	// Required.
 	Input interface{} `json:"input"`
 
 	// Model is the moderation model to use.
-	// Examples: "text-moderation-latest", "text-moderation-stable"
+	// Examples: "text-moderation-latest", "text-moderation-stable",
+	// "omni-moderation-latest"
 	// Optional (default: "text-moderation-latest").
 	Model string `json:"model,omitempty"`
 }
 
+// ModerationInput is one part of a multimodal moderation input.
+//
+// Example:
+//
+//	resp, err := client.CreateModeration(ctx, zaguansdk.ModerationRequest{
+//		Model: "omni-moderation-latest",
+//		Input: []zaguansdk.ModerationInput{
+//			zaguansdk.ModerationTextInput("caption for the picture"),
+//			zaguansdk.ModerationImageInput("https://example.com/picture.png"),
+//		},
+//	}, nil)
+type ModerationInput struct {
+	// Type is the part type.
+	// Values: "text", "image_url"
+	Type string `json:"type"`
+
+	// Text is the text to classify (for type="text").
+	Text string `json:"text,omitempty"`
+
+	// ImageURL is the image to classify (for type="image_url").
+	ImageURL *ModerationImageURL `json:"image_url,omitempty"`
+}
+
+// ModerationImageURL references an image to classify.
+type ModerationImageURL struct {
+	// URL is the image URL or a base64 data URL.
+	URL string `json:"url"`
+}
+
+// ModerationTextInput returns a text part for a multimodal moderation input.
+func ModerationTextInput(text string) ModerationInput {
+	return ModerationInput{Type: "text", Text: text}
+}
+
+// ModerationImageInput returns an image part for a multimodal moderation
+// input. url may be an https URL or a base64 data URL.
+func ModerationImageInput(url string) ModerationInput {
+	return ModerationInput{Type: "image_url", ImageURL: &ModerationImageURL{URL: url}}
+}
+
 // ModerationResponse represents the response from a moderation request.
 type ModerationResponse struct {
 	// ID is the unique identifier for the moderation request.
@@ -49,6 +94,11 @@ type ModerationResult struct {
 
 	// CategoryScores contains confidence scores for each category.
 	CategoryScores ModerationCategoryScores `json:"category_scores"`
+
+	// CategoryAppliedInputTypes lists, for each category, the input types
+	// ("text", "image") the score was computed from. Only multimodal models
+	// report it.
+	CategoryAppliedInputTypes map[string][]string `json:"category_applied_input_types,omitempty"`
 }
 
 // ModerationCategories contains boolean flags for content categories.
@@ -152,25 +202,14 @@ func (c *Client) CreateModeration(ctx context.Context, req ModerationRequest, op
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/moderations",
-		Body:   req,
+		Operation: "zaguan.CreateModeration",
+		Method:    "POST",
+		Path:      "/v1/moderations",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.timeout)
 
 	// Execute request
 	var resp ModerationResponse
@@ -227,3 +266,167 @@ func (r *ModerationResult) GetViolatedCategories() []string {
 	}
 	return violated
 }
+
+// CategoriesAboveThreshold returns the names of the categories whose score
+// exceeds threshold, in sorted order, regardless of Flagged. Use it to gate
+// content more strictly than the provider's own verdict.
+//
+// Example:
+//
+//	if categories := result.CategoriesAboveThreshold(0.2); len(categories) > 0 {
+//		return fmt.Errorf("content rejected: %v", categories)
+//	}
+func (r *ModerationResult) CategoriesAboveThreshold(threshold float64) []string {
+	var categories []string
+	for category, score := range r.CategoryScores.Map() {
+		if score > threshold {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// MaxScore returns the category with the highest score and that score. Ties
+// go to the category name that sorts first. It returns "" and 0 if no
+// category has a positive score.
+func (r *ModerationResult) MaxScore() (category string, score float64) {
+	for name, s := range r.CategoryScores.Map() {
+		if s > score || (s == score && s > 0 && name < category) {
+			category, score = name, s
+		}
+	}
+	return category, score
+}
+
+// Map returns the category scores keyed by category name
+// (e.g. "violence", "hate/threatening").
+func (s *ModerationCategoryScores) Map() map[string]float64 {
+	return map[string]float64{
+		"sexual":                 s.Sexual,
+		"hate":                   s.Hate,
+		"harassment":             s.Harassment,
+		"self-harm":              s.SelfHarm,
+		"sexual/minors":          s.SexualMinors,
+		"hate/threatening":       s.HateThreatening,
+		"violence/graphic":       s.ViolenceGraphic,
+		"self-harm/intent":       s.SelfHarmIntent,
+		"self-harm/instructions": s.SelfHarmInstructions,
+		"harassment/threatening": s.HarassmentThreatening,
+		"violence":               s.Violence,
+	}
+}
+
+// ModeratedChatOptions configures ChatModerated.
+type ModeratedChatOptions struct {
+	// ModerationModel is the moderation model to use.
+	// Optional (default: the API's default moderation model).
+	ModerationModel string
+
+	// Thresholds maps category names (e.g. "violence", "hate/threatening") to
+	// score thresholds. Content is blocked when any listed category's score
+	// is at or above its threshold.
+	// If empty, the API's Flagged verdict is used instead.
+	Thresholds map[string]float64
+
+	// RequestOptions apply to both the moderation and the chat request.
+	RequestOptions *RequestOptions
+}
+
+// ChatModerated moderates the latest user message(s) of req and, if they pass,
+// sends the chat completion request.
+//
+// The trailing run of user messages (those after the last non-user message)
+// is sent to CreateModeration. If any of them is blocked, a
+// *ContentBlockedError is returned and the model is not called.
+// Only text content is moderated.
+//
+// Example:
+//
+//	resp, err := client.ChatModerated(ctx, req, &zaguansdk.ModeratedChatOptions{
+//		Thresholds: map[string]float64{"violence": 0.5, "hate": 0.3},
+//	})
+//	var blocked *zaguansdk.ContentBlockedError
+//	if errors.As(err, &blocked) {
+//		fmt.Println("blocked:", blocked.Categories)
+//	}
+func (c *Client) ChatModerated(ctx context.Context, req ChatRequest, opts *ModeratedChatOptions) (*ChatResponse, error) {
+	if opts == nil {
+		opts = &ModeratedChatOptions{}
+	}
+
+	inputs := latestUserTexts(req.Messages)
+	if len(inputs) > 0 {
+		c.log(ctx, LogLevelDebug, "moderating chat input", "count", len(inputs))
+
+		modResp, err := c.CreateModeration(ctx, ModerationRequest{
+			Input: inputs,
+			Model: opts.ModerationModel,
+		}, opts.RequestOptions)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range modResp.Results {
+			if categories := blockedCategories(&result, opts.Thresholds); categories != nil {
+				c.log(ctx, LogLevelWarn, "chat input blocked by moderation", "categories", categories)
+				return nil, &ContentBlockedError{
+					Categories: categories,
+					Result:     result,
+				}
+			}
+		}
+	}
+
+	return c.Chat(ctx, req, opts.RequestOptions)
+}
+
+// latestUserTexts returns the text of the trailing user messages.
+func latestUserTexts(messages []Message) []string {
+	start := len(messages)
+	for start > 0 && messages[start-1].Role == "user" {
+		start--
+	}
+
+	var texts []string
+	for _, msg := range messages[start:] {
+		switch content := msg.Content.(type) {
+		case string:
+			if content != "" {
+				texts = append(texts, content)
+			}
+		case []ContentPart:
+			for _, part := range content {
+				if part.Type == "text" && part.Text != "" {
+					texts = append(texts, part.Text)
+				}
+			}
+		}
+	}
+	return texts
+}
+
+// blockedCategories returns the categories that block a moderation result, or
+// nil if it passes. Without thresholds, the API's Flagged verdict is used.
+func blockedCategories(result *ModerationResult, thresholds map[string]float64) []string {
+	if len(thresholds) == 0 {
+		if !result.Flagged {
+			return nil
+		}
+		categories := result.GetViolatedCategories()
+		if categories == nil {
+			categories = []string{}
+		}
+		return categories
+	}
+
+	scores := result.CategoryScores.Map()
+	var categories []string
+	for category, threshold := range thresholds {
+		if score, ok := scores[category]; ok && score >= threshold {
+			categories = append(categories, category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
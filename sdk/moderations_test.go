@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
 )
 
 func TestCreateModeration(t *testing.T) {
@@ -313,6 +316,60 @@ func TestModerationResultHelpers(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("CategoriesAboveThreshold", func(t *testing.T) {
+		result := ModerationResult{
+			Flagged: false,
+			CategoryScores: ModerationCategoryScores{
+				Sexual:          0.05,
+				Hate:            0.31,
+				Harassment:      0.30,
+				Violence:        0.72,
+				SelfHarmIntent:  0.12,
+				HateThreatening: 0.30,
+			},
+		}
+
+		tests := []struct {
+			threshold float64
+			want      []string
+		}{
+			{0.5, []string{"violence"}},
+			{0.3, []string{"hate", "violence"}},
+			{0.1, []string{"harassment", "hate", "hate/threatening", "self-harm/intent", "violence"}},
+			{0.9, nil},
+		}
+
+		for _, tt := range tests {
+			got := result.CategoriesAboveThreshold(tt.threshold)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CategoriesAboveThreshold(%v) = %v, want %v", tt.threshold, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("MaxScore", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			scores       ModerationCategoryScores
+			wantCategory string
+			wantScore    float64
+		}{
+			{"single max", ModerationCategoryScores{Hate: 0.2, Violence: 0.7}, "violence", 0.7},
+			{"tie", ModerationCategoryScores{Violence: 0.4, Harassment: 0.4}, "harassment", 0.4},
+			{"all zero", ModerationCategoryScores{}, "", 0},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				result := ModerationResult{CategoryScores: tt.scores}
+				category, score := result.MaxScore()
+				if category != tt.wantCategory || score != tt.wantScore {
+					t.Errorf("MaxScore() = %q, %v, want %q, %v", category, score, tt.wantCategory, tt.wantScore)
+				}
+			})
+		}
+	})
 }
 
 func TestValidateModerationRequest(t *testing.T) {
@@ -348,6 +405,51 @@ func TestValidateModerationRequest(t *testing.T) {
 			req:     ModerationRequest{},
 			wantErr: true,
 		},
+		{
+			name: "interface array input",
+			req: ModerationRequest{
+				Input: []interface{}{"test1", "test2"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "unsupported input type",
+			req: ModerationRequest{
+				Input: 42,
+			},
+			wantErr: true,
+		},
+		{
+			name: "multimodal input",
+			req: ModerationRequest{
+				Input: []ModerationInput{
+					ModerationTextInput("caption"),
+					ModerationImageInput("https://example.com/a.png"),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "multimodal input with empty image URL",
+			req: ModerationRequest{
+				Input: []ModerationInput{ModerationImageInput("")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "multimodal input with unknown type",
+			req: ModerationRequest{
+				Input: []ModerationInput{{Type: "video"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty multimodal input",
+			req: ModerationRequest{
+				Input: []ModerationInput{},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -359,3 +461,156 @@ func TestValidateModerationRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_ChatModerated(t *testing.T) {
+	tests := []struct {
+		name           string
+		flagged        bool
+		violenceScore  float64
+		thresholds     map[string]float64
+		wantBlocked    bool
+		wantCategories []string
+	}{
+		{
+			name:          "passes moderation",
+			violenceScore: 0.1,
+			wantBlocked:   false,
+		},
+		{
+			name:           "flagged by API",
+			flagged:        true,
+			violenceScore:  0.9,
+			wantBlocked:    true,
+			wantCategories: []string{"violence"},
+		},
+		{
+			name:           "blocked by threshold",
+			violenceScore:  0.4,
+			thresholds:     map[string]float64{"violence": 0.3},
+			wantBlocked:    true,
+			wantCategories: []string{"violence"},
+		},
+		{
+			name:          "threshold overrides API flag",
+			flagged:       true,
+			violenceScore: 0.4,
+			thresholds:    map[string]float64{"violence": 0.8},
+			wantBlocked:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var moderatedInput []string
+			var chatCalled bool
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1/moderations":
+					var req struct {
+						Input []string `json:"input"`
+					}
+					json.NewDecoder(r.Body).Decode(&req)
+					moderatedInput = req.Input
+
+					json.NewEncoder(w).Encode(ModerationResponse{
+						ID: "modr-1",
+						Results: []ModerationResult{{
+							Flagged:        tt.flagged,
+							Categories:     ModerationCategories{Violence: tt.flagged},
+							CategoryScores: ModerationCategoryScores{Violence: tt.violenceScore},
+						}},
+					})
+				case "/v1/chat/completions":
+					chatCalled = true
+					testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+				default:
+					t.Errorf("unexpected path: %s", r.URL.Path)
+				}
+			}))
+			defer server.Close()
+
+			client := NewClient(Config{
+				BaseURL: server.URL,
+				APIKey:  "test-key",
+			})
+
+			resp, err := client.ChatModerated(context.Background(), ChatRequest{
+				Model: "openai/gpt-4o",
+				Messages: []Message{
+					{Role: "user", Content: "earlier question"},
+					{Role: "assistant", Content: "earlier answer"},
+					{Role: "user", Content: "first"},
+					{Role: "user", Content: []ContentPart{{Type: "text", Text: "second"}}},
+				},
+			}, &ModeratedChatOptions{Thresholds: tt.thresholds})
+
+			if len(moderatedInput) != 2 || moderatedInput[0] != "first" || moderatedInput[1] != "second" {
+				t.Errorf("moderated input = %v, want [first second]", moderatedInput)
+			}
+
+			if tt.wantBlocked {
+				blocked, ok := err.(*ContentBlockedError)
+				if !ok {
+					t.Fatalf("ChatModerated() error = %v, want *ContentBlockedError", err)
+				}
+				if len(blocked.Categories) != len(tt.wantCategories) || blocked.Categories[0] != tt.wantCategories[0] {
+					t.Errorf("Categories = %v, want %v", blocked.Categories, tt.wantCategories)
+				}
+				if chatCalled {
+					t.Error("chat endpoint should not be called for blocked content")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ChatModerated() error = %v", err)
+			}
+			if !chatCalled || resp == nil {
+				t.Error("chat endpoint should be called for content that passes moderation")
+			}
+		})
+	}
+}
+
+func TestCreateModeration_Multimodal(t *testing.T) {
+	var gotBody map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.Write([]byte(`{
+			"id": "modr-789",
+			"model": "omni-moderation-latest",
+			"results": [{
+				"flagged": true,
+				"categories": {"violence": true},
+				"category_scores": {"violence": 0.91},
+				"category_applied_input_types": {"violence": ["text", "image"]}
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{BaseURL: server.URL, APIKey: "test-key"})
+	resp, err := client.CreateModeration(context.Background(), ModerationRequest{
+		Model: "omni-moderation-latest",
+		Input: []ModerationInput{
+			ModerationTextInput("caption"),
+			ModerationImageInput("https://example.com/a.png"),
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("CreateModeration() error = %v", err)
+	}
+
+	wantInput := []interface{}{
+		map[string]interface{}{"type": "text", "text": "caption"},
+		map[string]interface{}{"type": "image_url", "image_url": map[string]interface{}{"url": "https://example.com/a.png"}},
+	}
+	if !reflect.DeepEqual(gotBody["input"], wantInput) {
+		t.Errorf("input = %v, want %v", gotBody["input"], wantInput)
+	}
+
+	types := resp.Results[0].CategoryAppliedInputTypes["violence"]
+	if !reflect.DeepEqual(types, []string{"text", "image"}) {
+		t.Errorf("CategoryAppliedInputTypes[violence] = %v, want [text image]", types)
+	}
+}
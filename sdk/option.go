@@ -1,8 +1,11 @@
 package zaguansdk
 
 import (
+	"context"
 	"net/http"
 	"time"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
 )
 
 // RequestOptions allows per-request configuration overrides.
@@ -11,44 +14,155 @@ import (
 // will be used.
 type RequestOptions struct {
 	// RequestID is a unique identifier for this request.
-	// If empty, a UUID will be automatically generated.
+	// If empty, the ID from ContextWithRequestID is used, or a UUID is
+	// generated.
 	// This ID is sent in the X-Request-Id header and can be used for debugging.
 	RequestID string
 
-	// Timeout overrides the client's default timeout for this request.
+	// Timeout overrides the client's default timeout for this request
+	// (Config.Timeout, or Config.StreamTimeout for streaming methods).
+	// For streaming methods it bounds the total duration of the stream.
 	// If zero, the client's default timeout is used.
 	Timeout time.Duration
 
+	// APIKey overrides the client's API key for this request.
+	// Useful for multi-tenant applications that send requests on behalf of
+	// different keys without creating a new Client per key.
+	// If empty, the client's default API key is used.
+	APIKey string
+
 	// Headers are additional HTTP headers to include in the request.
 	// These will be merged with the default headers (Authorization, Content-Type, etc.).
+	// A header with the same name as a default header replaces it.
 	Headers http.Header
 
+	// ResponseHeaders, if non-nil, receives a copy of the response headers,
+	// such as X-Request-Id and X-RateLimit-*, once the response arrives.
+	// It is also populated for error responses. For streaming methods the
+	// headers are available as soon as the stream is opened.
+	//
+	// Example:
+	//
+	//	var headers http.Header
+	//	resp, err := client.Chat(ctx, req, &zaguansdk.RequestOptions{ResponseHeaders: &headers})
+	//	log.Printf("request %s, %s requests remaining",
+	//		headers.Get("X-Request-Id"), headers.Get("X-RateLimit-Remaining-Requests"))
+	ResponseHeaders *http.Header
+
+	// IdempotencyKey is sent in the Idempotency-Key header so the gateway can
+	// deduplicate replayed requests. It is never sent on GET requests.
+	// If empty and MaxRetries is positive, a key is generated for the
+	// request and reused across its retry attempts.
+	IdempotencyKey string
+
 	// MaxRetries specifies the maximum number of retry attempts for this request.
-	// If zero, no retries will be attempted.
-	// If negative, the client's default retry policy is used.
+	// Transport errors and 408, 429, 5xx (500, 502, 503, 504), and 529
+	// responses are retried; a Retry-After header longer than the backoff is
+	// honored, but a response asking to wait longer than 30 seconds is
+	// returned without retrying.
+	// Multipart uploads are not retried.
+	// If zero or negative, the request is not retried.
 	MaxRetries int
 
 	// RetryDelay is the initial delay between retry attempts.
-	// Subsequent retries use exponential backoff.
+	// Subsequent retries use exponential backoff, capped at 30 seconds, and
+	// each delay is randomized between half and all of its value.
 	// If zero, a default of 1 second is used.
 	RetryDelay time.Duration
 }
 
+// applyRequestOptions copies the per-request overrides in opts onto reqCfg.
+// Headers are merged into any headers reqCfg already has. If neither opts nor
+// reqCfg sets a Timeout, defaultTimeout (Config.Timeout or
+// Config.StreamTimeout) is used.
+func applyRequestOptions(reqCfg *internal.RequestConfig, opts *RequestOptions, defaultTimeout time.Duration) {
+	if opts != nil {
+		if opts.Timeout > 0 {
+			reqCfg.Timeout = opts.Timeout
+		}
+		if opts.RequestID != "" {
+			reqCfg.RequestID = opts.RequestID
+		}
+		if opts.APIKey != "" {
+			reqCfg.APIKey = opts.APIKey
+		}
+		if len(opts.Headers) > 0 {
+			if reqCfg.Headers == nil {
+				reqCfg.Headers = make(http.Header, len(opts.Headers))
+			}
+			for k, v := range opts.Headers {
+				reqCfg.Headers[k] = v
+			}
+		}
+		if opts.ResponseHeaders != nil {
+			reqCfg.ResponseHeaders = opts.ResponseHeaders
+		}
+		if opts.IdempotencyKey != "" {
+			reqCfg.IdempotencyKey = opts.IdempotencyKey
+		}
+		if opts.MaxRetries > 0 {
+			reqCfg.MaxRetries = opts.MaxRetries
+			reqCfg.RetryDelay = opts.RetryDelay
+		}
+	}
+	if reqCfg.Timeout == 0 {
+		reqCfg.Timeout = defaultTimeout
+	}
+}
+
 // WithRequestID returns a new RequestOptions with the specified request ID.
 func WithRequestID(id string) *RequestOptions {
 	return &RequestOptions{RequestID: id}
 }
 
+// ContextWithRequestID returns a copy of ctx carrying a request ID.
+//
+// Requests made with the returned context send requestID in the X-Request-Id
+// header, so middleware that already tracks a correlation ID can propagate it
+// without touching every call site. RequestOptions.RequestID takes precedence;
+// if neither is set, a UUID is generated.
+//
+// Example:
+//
+//	ctx = zaguansdk.ContextWithRequestID(ctx, traceID)
+//	resp, err := client.Chat(ctx, req, nil)
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return internal.ContextWithRequestID(ctx, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by
+// ContextWithRequestID, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	return internal.RequestIDFromContext(ctx)
+}
+
 // WithTimeout returns a new RequestOptions with the specified timeout.
 func WithTimeout(timeout time.Duration) *RequestOptions {
 	return &RequestOptions{Timeout: timeout}
 }
 
+// WithAPIKey returns a new RequestOptions that overrides the client's API key.
+func WithAPIKey(apiKey string) *RequestOptions {
+	return &RequestOptions{APIKey: apiKey}
+}
+
 // WithHeaders returns a new RequestOptions with the specified headers.
 func WithHeaders(headers http.Header) *RequestOptions {
 	return &RequestOptions{Headers: headers}
 }
 
+// WithResponseHeaders returns a new RequestOptions that captures the response
+// headers into headers.
+func WithResponseHeaders(headers *http.Header) *RequestOptions {
+	return &RequestOptions{ResponseHeaders: headers}
+}
+
+// WithIdempotencyKey returns a new RequestOptions with the specified
+// idempotency key.
+func WithIdempotencyKey(key string) *RequestOptions {
+	return &RequestOptions{IdempotencyKey: key}
+}
+
 // WithRetries returns a new RequestOptions with the specified retry configuration.
 func WithRetries(maxRetries int, delay time.Duration) *RequestOptions {
 	return &RequestOptions{
@@ -72,6 +186,13 @@ func (o *RequestOptions) Merge(other *RequestOptions) *RequestOptions {
 		merged.RequestID = o.RequestID
 	}
 
+	// API key
+	if other.APIKey != "" {
+		merged.APIKey = other.APIKey
+	} else if o != nil {
+		merged.APIKey = o.APIKey
+	}
+
 	// Timeout
 	if other.Timeout > 0 {
 		merged.Timeout = other.Timeout
@@ -92,6 +213,20 @@ func (o *RequestOptions) Merge(other *RequestOptions) *RequestOptions {
 		}
 	}
 
+	// Response headers
+	if other.ResponseHeaders != nil {
+		merged.ResponseHeaders = other.ResponseHeaders
+	} else if o != nil {
+		merged.ResponseHeaders = o.ResponseHeaders
+	}
+
+	// Idempotency key
+	if other.IdempotencyKey != "" {
+		merged.IdempotencyKey = other.IdempotencyKey
+	} else if o != nil {
+		merged.IdempotencyKey = o.IdempotencyKey
+	}
+
 	// Retries
 	if other.MaxRetries != 0 {
 		merged.MaxRetries = other.MaxRetries
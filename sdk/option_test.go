@@ -1,9 +1,14 @@
 package zaguansdk
 
 import (
+	"context"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
 )
 
 func TestWithRequestID(t *testing.T) {
@@ -184,3 +189,247 @@ func TestRequestOptions_MergeNilBase(t *testing.T) {
 		t.Errorf("Merge() with nil base RequestID = %v, want other-id", got.RequestID)
 	}
 }
+
+func TestApplyRequestOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        *RequestOptions
+		wantTimeout time.Duration
+		wantRetries int
+	}{
+		{"nil options use the default timeout", nil, time.Minute, 0},
+		{"options without a timeout keep the default", &RequestOptions{RequestID: "req-1"}, time.Minute, 0},
+		{"option timeout wins", &RequestOptions{Timeout: time.Second}, time.Second, 0},
+		{"negative retries are ignored", &RequestOptions{MaxRetries: -1}, time.Minute, 0},
+		{"retries are copied", &RequestOptions{MaxRetries: 2}, time.Minute, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reqCfg internal.RequestConfig
+			applyRequestOptions(&reqCfg, tt.opts, time.Minute)
+			if reqCfg.Timeout != tt.wantTimeout {
+				t.Errorf("Timeout = %v, want %v", reqCfg.Timeout, tt.wantTimeout)
+			}
+			if reqCfg.MaxRetries != tt.wantRetries {
+				t.Errorf("MaxRetries = %d, want %d", reqCfg.MaxRetries, tt.wantRetries)
+			}
+		})
+	}
+
+	t.Run("headers are merged without aliasing", func(t *testing.T) {
+		optHeaders := http.Header{"X-Tenant": []string{"acme"}}
+		reqCfg := internal.RequestConfig{Headers: http.Header{"Content-Type": []string{"audio/wav"}}}
+		applyRequestOptions(&reqCfg, &RequestOptions{Headers: optHeaders}, 0)
+
+		if reqCfg.Headers.Get("Content-Type") != "audio/wav" || reqCfg.Headers.Get("X-Tenant") != "acme" {
+			t.Errorf("Headers = %v, want both headers", reqCfg.Headers)
+		}
+		reqCfg.Headers.Set("X-Extra", "1")
+		if optHeaders.Get("X-Extra") != "" {
+			t.Error("applyRequestOptions aliased the caller's headers")
+		}
+	})
+}
+
+func TestContextWithRequestID(t *testing.T) {
+	if _, ok := RequestIDFromContext(context.Background()); ok {
+		t.Error("RequestIDFromContext() should report no ID for an empty context")
+	}
+
+	ctx := ContextWithRequestID(context.Background(), "trace-123")
+	if id, ok := RequestIDFromContext(ctx); !ok || id != "trace-123" {
+		t.Errorf("RequestIDFromContext() = %q, %v, want trace-123, true", id, ok)
+	}
+}
+
+func TestClient_RequestIDPrecedence(t *testing.T) {
+	var gotID string
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.Header.Get("X-Request-Id")
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+	ctx := ContextWithRequestID(context.Background(), "from-context")
+
+	// RequestOptions.RequestID wins over the context
+	if _, err := client.Chat(ctx, req, WithRequestID("from-options")); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if gotID != "from-options" {
+		t.Errorf("X-Request-Id = %q, want from-options", gotID)
+	}
+
+	// The context is used when no option is set
+	if _, err := client.Chat(ctx, req, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if gotID != "from-context" {
+		t.Errorf("X-Request-Id = %q, want from-context", gotID)
+	}
+
+	// Otherwise a UUID is generated
+	if _, err := client.Chat(context.Background(), req, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(gotID) != 36 || gotID == "from-context" {
+		t.Errorf("X-Request-Id = %q, want a generated UUID", gotID)
+	}
+}
+
+func TestClient_RequestIDPrefix(t *testing.T) {
+	var gotID string
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID = r.Header.Get("X-Request-Id")
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL:         mockServer.URL(),
+		APIKey:          "test-key",
+		RequestIDPrefix: "myapp",
+	})
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+
+	// Generated IDs carry the prefix
+	if _, err := client.Chat(context.Background(), req, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if !strings.HasPrefix(gotID, "myapp-") || len(gotID) != len("myapp-")+36 {
+		t.Errorf("X-Request-Id = %q, want myapp-<uuid>", gotID)
+	}
+
+	// Explicit IDs are sent unchanged
+	if _, err := client.Chat(context.Background(), req, WithRequestID("from-options")); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if gotID != "from-options" {
+		t.Errorf("X-Request-Id = %q, want from-options", gotID)
+	}
+}
+
+func TestClient_ResponseHeaders(t *testing.T) {
+	status := http.StatusOK
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "server-req-123")
+		w.Header().Set("X-RateLimit-Remaining-Requests", "42")
+		if status != http.StatusOK {
+			testutil.ErrorHandler(status, "rate_limit_error", "slow down")(w, r)
+			return
+		}
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+
+	var headers http.Header
+	if _, err := client.Chat(context.Background(), req, WithResponseHeaders(&headers)); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if got := headers.Get("X-Request-Id"); got != "server-req-123" {
+		t.Errorf("X-Request-Id = %q, want server-req-123", got)
+	}
+	if got := headers.Get("X-RateLimit-Remaining-Requests"); got != "42" {
+		t.Errorf("X-RateLimit-Remaining-Requests = %q, want 42", got)
+	}
+
+	// Headers are captured for error responses too
+	status = http.StatusTooManyRequests
+	headers = nil
+	if _, err := client.Chat(context.Background(), req, WithResponseHeaders(&headers)); err == nil {
+		t.Fatal("Chat() error = nil, want error")
+	}
+	if got := headers.Get("X-RateLimit-Remaining-Requests"); got != "42" {
+		t.Errorf("X-RateLimit-Remaining-Requests = %q, want 42", got)
+	}
+}
+
+func TestClient_IdempotencyKeyAcrossRetries(t *testing.T) {
+	var keys []string
+	failures := 2
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if failures > 0 {
+			failures--
+			testutil.ErrorHandler(http.StatusServiceUnavailable, "server_error", "try again")(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"object":"list","data":[]}`))
+			return
+		}
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+
+	// A generated key is reused by the initial attempt and each retry
+	if _, err := client.Chat(context.Background(), req, WithRetries(3, time.Millisecond)); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("server received %d attempts, want 3", len(keys))
+	}
+	if keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("Idempotency-Key per attempt = %q, want the same non-empty key", keys)
+	}
+
+	// An explicit key is sent as-is, with or without retries
+	keys = nil
+	if _, err := client.Chat(context.Background(), req, WithIdempotencyKey("order-42")); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "order-42" {
+		t.Errorf("Idempotency-Key = %q, want [order-42]", keys)
+	}
+
+	// Without retries or an explicit key, no key is sent
+	keys = nil
+	if _, err := client.Chat(context.Background(), req, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if keys[0] != "" {
+		t.Errorf("Idempotency-Key = %q, want none", keys[0])
+	}
+
+	// GET requests never carry the header
+	keys = nil
+	failures = 1
+	opts := &RequestOptions{IdempotencyKey: "ignored", MaxRetries: 2, RetryDelay: time.Millisecond}
+	if _, err := client.ListModels(context.Background(), nil, opts); err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if len(keys) != 2 || keys[0] != "" || keys[1] != "" {
+		t.Errorf("GET Idempotency-Key per attempt = %q, want none", keys)
+	}
+}
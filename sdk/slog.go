@@ -0,0 +1,82 @@
+// Package zaguansdk provides a log/slog adapter for the Zaguan SDK.
+//
+// This file implements NewSlogLogger, which lets a *slog.Logger be used as the
+// SDK's Logger.
+package zaguansdk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// slogBadKey is the attribute key used for a value without a key, matching
+// the key slog itself uses for malformed arguments.
+const slogBadKey = "!BADKEY"
+
+// slogLogger adapts a *slog.Logger to the Logger interface.
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a Logger that writes to logger. If logger is nil,
+// slog.Default() is used.
+//
+// LogLevelDebug, LogLevelInfo, LogLevelWarn and LogLevelError map to the slog
+// levels of the same name, and key-value pairs are forwarded as attributes.
+// Non-string keys are formatted with fmt.Sprint; a trailing value without a
+// key is logged under "!BADKEY".
+//
+// Example:
+//
+//	client := zaguansdk.NewClient(zaguansdk.Config{
+//		BaseURL: "https://api.zaguanai.com",
+//		APIKey:  os.Getenv("ZAGUAN_API_KEY"),
+//		Logger:  zaguansdk.NewSlogLogger(slog.Default()),
+//	})
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &slogLogger{logger: logger}
+}
+
+// Log implements Logger.
+func (l *slogLogger) Log(ctx context.Context, level LogLevel, msg string, keysAndValues ...interface{}) {
+	slogLevel := slogLevelFor(level)
+	if !l.logger.Enabled(ctx, slogLevel) {
+		return
+	}
+	l.logger.LogAttrs(ctx, slogLevel, msg, slogAttrs(keysAndValues)...)
+}
+
+// slogLevelFor maps a LogLevel to the corresponding slog level.
+func slogLevelFor(level LogLevel) slog.Level {
+	switch level {
+	case LogLevelDebug:
+		return slog.LevelDebug
+	case LogLevelWarn:
+		return slog.LevelWarn
+	case LogLevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// slogAttrs converts alternating keys and values to slog attributes.
+func slogAttrs(keysAndValues []interface{}) []slog.Attr {
+	attrs := make([]slog.Attr, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			attrs = append(attrs, slog.Any(slogBadKey, keysAndValues[i]))
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		attrs = append(attrs, slog.Any(key, keysAndValues[i+1]))
+	}
+	return attrs
+}
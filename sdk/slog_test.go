@@ -0,0 +1,92 @@
+package zaguansdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestNewSlogLogger(t *testing.T) {
+	tests := []struct {
+		name          string
+		level         LogLevel
+		keysAndValues []interface{}
+		wantLevel     string
+		wantAttrs     map[string]interface{}
+	}{
+		{
+			name:          "debug with pairs",
+			level:         LogLevelDebug,
+			keysAndValues: []interface{}{"model", "openai/gpt-4o", "count", 3},
+			wantLevel:     "DEBUG",
+			wantAttrs:     map[string]interface{}{"model": "openai/gpt-4o", "count": float64(3)},
+		},
+		{
+			name:      "info",
+			level:     LogLevelInfo,
+			wantLevel: "INFO",
+		},
+		{
+			name:      "warn",
+			level:     LogLevelWarn,
+			wantLevel: "WARN",
+		},
+		{
+			name:          "error with odd-length pairs",
+			level:         LogLevelError,
+			keysAndValues: []interface{}{"error", "boom", "dangling"},
+			wantLevel:     "ERROR",
+			wantAttrs:     map[string]interface{}{"error": "boom", "!BADKEY": "dangling"},
+		},
+		{
+			name:          "non-string key",
+			level:         LogLevelInfo,
+			keysAndValues: []interface{}{42, "answer"},
+			wantLevel:     "INFO",
+			wantAttrs:     map[string]interface{}{"42": "answer"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+			logger := NewSlogLogger(slog.New(handler))
+
+			logger.Log(context.Background(), tt.level, "hello", tt.keysAndValues...)
+
+			var record map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("Unmarshal() error = %v (output %q)", err, buf.String())
+			}
+			if record["level"] != tt.wantLevel {
+				t.Errorf("level = %v, want %s", record["level"], tt.wantLevel)
+			}
+			if record["msg"] != "hello" {
+				t.Errorf("msg = %v, want hello", record["msg"])
+			}
+			for k, want := range tt.wantAttrs {
+				if record[k] != want {
+					t.Errorf("attr %s = %v, want %v", k, record[k], want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewSlogLogger_LevelFiltering(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})
+	logger := NewSlogLogger(slog.New(handler))
+
+	logger.Log(context.Background(), LogLevelDebug, "ignored")
+	if buf.Len() != 0 {
+		t.Errorf("debug message logged at warn level: %q", buf.String())
+	}
+
+	if NewSlogLogger(nil) == nil {
+		t.Error("NewSlogLogger(nil) = nil, want a logger using slog.Default()")
+	}
+}
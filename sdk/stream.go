@@ -2,6 +2,7 @@ package zaguansdk
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -21,12 +22,24 @@ type ChatStream struct {
 	resp   *http.Response
 	ctx    context.Context
 	closed bool
+	eof    bool
+	usage  *Usage
+	span   *operationSpan
+	err    error // terminal error, returned by Err
+	event  *ChatStreamEvent
+
+	resumeToken string
+	citations   []string
 }
 
 // Recv reads the next event from the chat stream.
 //
 // Returns io.EOF when the stream is complete.
-// Returns an error if the stream encounters an error.
+// Returns an error if the stream encounters an error. If the gateway emits an
+// error object mid-stream, it is returned as a typed error (e.g. *APIError or
+// *RateLimitError).
+// Errors other than io.EOF end the stream: later calls return the same error,
+// which is also reported by Err.
 //
 // Example:
 //
@@ -43,23 +56,27 @@ type ChatStream struct {
 //		}
 //	}
 func (s *ChatStream) Recv() (*ChatStreamEvent, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
 	if s.closed {
 		return nil, errors.New("stream is closed")
 	}
 
 	// Check context
 	if err := s.ctx.Err(); err != nil {
-		_ = s.Close() // Explicitly ignore error in cleanup
-		return nil, err
+		return nil, s.fail(err)
 	}
 
 	for {
 		line, err := s.reader.ReadString('\n')
 		if err != nil {
 			if err == io.EOF {
+				s.eof = true
 				_ = s.Close() // Explicitly ignore error in cleanup
+				return nil, err
 			}
-			return nil, err
+			return nil, s.fail(err)
 		}
 
 		line = strings.TrimSpace(line)
@@ -79,26 +96,161 @@ func (s *ChatStream) Recv() (*ChatStreamEvent, error) {
 
 		// Check for stream end
 		if data == "[DONE]" {
+			s.eof = true
 			_ = s.Close() // Explicitly ignore error in cleanup
 			return nil, io.EOF
 		}
 
-		// Parse JSON event
-		var event ChatStreamEvent
-		if err := json.Unmarshal([]byte(data), &event); err != nil {
-			return nil, fmt.Errorf("failed to parse stream event: %w", err)
+		// Parse JSON event, checking for an error emitted mid-stream
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, s.fail(fmt.Errorf("failed to parse stream event: %w", err))
+		}
+		if err := internal.ParseStreamError(chunk.Error, s.resp); err != nil {
+			return nil, s.fail(err)
+		}
+		event := chunk.ChatStreamEvent
+
+		if event.Usage != nil {
+			s.usage = event.Usage
+		}
+		if event.ResumeToken != "" {
+			s.resumeToken = event.ResumeToken
+		}
+		if len(event.Citations) > 0 {
+			s.citations = event.Citations
 		}
 
 		return &event, nil
 	}
 }
 
+// Next advances the stream to the next event, which is then available from
+// Current. It returns false when the stream ends or fails; Err distinguishes
+// the two.
+//
+// Example:
+//
+//	for stream.Next() {
+//		event := stream.Current()
+//		// ...
+//	}
+//	if err := stream.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+func (s *ChatStream) Next() bool {
+	event, err := s.Recv()
+	if err != nil {
+		s.event = nil
+		return false
+	}
+	s.event = event
+	return true
+}
+
+// Current returns the event read by the last successful call to Next.
+func (s *ChatStream) Current() *ChatStreamEvent {
+	return s.event
+}
+
+// Err returns the error that ended the stream, such as a malformed event, a
+// mid-stream error from the gateway, or context cancellation. It returns nil
+// if the stream completed cleanly or is still open, mirroring
+// bufio.Scanner.Err.
+func (s *ChatStream) Err() error {
+	return s.err
+}
+
+// fail records err as the stream's terminal error, closes the stream, and
+// returns err.
+func (s *ChatStream) fail(err error) error {
+	s.err = err
+	_ = s.Close() // Explicitly ignore error in cleanup
+	return err
+}
+
+// ResumeToken returns the most recent resume token sent by the gateway, or
+// an empty string if none has been received.
+//
+// Gateways that support resumable streams attach a token to events marking
+// the position reached so far. After a disconnect, the token lets the
+// gateway continue generation from that point instead of starting over.
+// Gateways without resume support send no tokens; callers should then treat
+// a broken stream as lost and retry the whole request.
+func (s *ChatStream) ResumeToken() string {
+	return s.resumeToken
+}
+
+// Citations returns the most recent list of source URLs sent by a
+// search-augmented model, or nil if none has been received.
+func (s *ChatStream) Citations() []string {
+	return s.citations
+}
+
+// Usage returns the token usage reported by the stream.
+//
+// Usage is only sent in the final chunk, so this returns nil until Recv has
+// returned io.EOF. It also returns nil if the provider did not report usage.
+func (s *ChatStream) Usage() *Usage {
+	if !s.eof {
+		return nil
+	}
+	return s.usage
+}
+
+// WriteSSE forwards the remaining stream events to w as Server-Sent Events.
+//
+// Each event is written as a "data:" line and flushed immediately, followed by
+// a final "data: [DONE]" line once the stream completes. This is useful for
+// proxying model output from a backend handler to a browser. The stream is
+// closed when WriteSSE returns.
+//
+// Example:
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		stream, err := client.ChatStream(r.Context(), req, nil)
+//		if err != nil {
+//			http.Error(w, err.Error(), http.StatusBadGateway)
+//			return
+//		}
+//		if err := stream.WriteSSE(w); err != nil {
+//			log.Printf("stream error: %v", err)
+//		}
+//	}
+func (s *ChatStream) WriteSSE(w http.ResponseWriter) error {
+	defer s.Close()
+
+	flusher := prepareSSE(w)
+
+	for {
+		event, err := s.Recv()
+		if err == io.EOF {
+			return writeSSEData(w, flusher, "", []byte("[DONE]"))
+		}
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal stream event: %w", err)
+		}
+		if err := writeSSEData(w, flusher, "", data); err != nil {
+			return err
+		}
+	}
+}
+
 // Close closes the stream and releases resources.
 func (s *ChatStream) Close() error {
 	if s.closed {
 		return nil
 	}
 	s.closed = true
+	if s.usage != nil {
+		s.span.setUsage(s.usage.PromptTokens, s.usage.CompletionTokens)
+	}
+	s.span.end(s.err)
 	if s.resp != nil && s.resp.Body != nil {
 		return s.resp.Body.Close()
 	}
@@ -124,6 +276,21 @@ type ChatStreamEvent struct {
 
 	// Usage contains token usage information (only in final event).
 	Usage *Usage `json:"usage,omitempty"`
+
+	// ResumeToken identifies the stream position after this event, for
+	// gateways that support resumable streams. Empty if not supported.
+	ResumeToken string `json:"resume_token,omitempty"`
+
+	// Citations lists the source URLs found so far by search-augmented
+	// models (e.g. Perplexity). Each event carries the full list.
+	Citations []string `json:"citations,omitempty"`
+}
+
+// chatStreamChunk is a ChatStreamEvent as decoded from the wire, where the
+// gateway may send an error object in place of a chunk.
+type chatStreamChunk struct {
+	ChatStreamEvent
+	Error json.RawMessage `json:"error"`
 }
 
 // ChatStreamChoice represents a choice in a streaming response.
@@ -152,12 +319,35 @@ type ChatStreamDelta struct {
 
 	// ToolCalls contains incremental tool call information.
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// Audio contains incremental audio output when the request asks for
+	// the "audio" modality.
+	Audio *ChatStreamAudioDelta `json:"audio,omitempty"`
+}
+
+// ChatStreamAudioDelta is a chunk of streamed audio output.
+type ChatStreamAudioDelta struct {
+	// ID is the identifier of the audio response, sent in the first chunk.
+	ID string `json:"id,omitempty"`
+
+	// Data is a base64-encoded chunk of audio in the requested format.
+	Data string `json:"data,omitempty"`
+
+	// Transcript is the incremental transcript of the audio.
+	Transcript string `json:"transcript,omitempty"`
+
+	// ExpiresAt is the Unix timestamp after which the audio can no longer
+	// be referenced in follow-up requests.
+	ExpiresAt int64 `json:"expires_at,omitempty"`
 }
 
 // ChatStream sends a streaming chat completion request to Zaguan CoreX.
 //
 // The stream must be closed when done to release resources.
 //
+// The stream is bounded by RequestOptions.Timeout if set, otherwise by
+// Config.StreamTimeout. Config.Timeout does not apply to streams.
+//
 // Example:
 //
 //	stream, err := client.ChatStream(ctx, zaguansdk.ChatRequest{
@@ -184,10 +374,35 @@ type ChatStreamDelta struct {
 //		}
 //	}
 func (c *Client) ChatStream(ctx context.Context, req ChatRequest, opts *RequestOptions) (*ChatStream, error) {
+	virtualModel := c.applyVirtualModelMode(&req)
+
 	// Validate request
 	if err := validateChatRequest(&req); err != nil {
 		return nil, err
 	}
+	if c.strictToolMessages {
+		if err := validateToolMessages(req.Messages); err != nil {
+			return nil, err
+		}
+	}
+
+	metadata, err := c.prepareMetadata(req.Metadata, "metadata")
+	if err != nil {
+		return nil, err
+	}
+	req.Metadata = metadata
+
+	if c.checkAudioOutput && requestsAudioOutput(&req) {
+		if err := c.precheckAudioOutput(ctx, req.Model, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.checkStreamingSupport {
+		if err := c.precheckStreaming(ctx, req.Model, opts); err != nil {
+			return nil, err
+		}
+	}
 
 	// Ensure stream is true
 	req.Stream = true
@@ -198,29 +413,23 @@ func (c *Client) ChatStream(ctx context.Context, req ChatRequest, opts *RequestO
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/chat/completions",
-		Body:   req,
+		Operation: "zaguan.ChatStream",
+		Method:    "POST",
+		Path:      "/v1/chat/completions",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
+	applyRequestOptions(&reqCfg, opts, c.streamTimeout)
+	if virtualModel != "" {
+		reqCfg.Headers = withVirtualModelHeader(reqCfg.Headers, virtualModel)
 	}
 
 	// Execute request
+	ctx, span := c.startSpan(ctx, "zaguan.ChatStream", req.Model)
 	resp, err := c.internalHTTP.Do(ctx, reqCfg)
 	if err != nil {
+		span.end(err)
 		c.log(ctx, LogLevelError, "streaming chat completion request failed", "error", err)
 		return nil, err
 	}
@@ -228,7 +437,9 @@ func (c *Client) ChatStream(ctx context.Context, req ChatRequest, opts *RequestO
 	// Check for error status codes
 	if resp.StatusCode >= 400 {
 		defer resp.Body.Close()
-		return nil, internal.ParseErrorResponse(resp)
+		err := c.internalHTTP.ParseErrorResponse(resp)
+		span.end(err)
+		return nil, err
 	}
 
 	c.log(ctx, LogLevelDebug, "streaming chat completion request started")
@@ -239,6 +450,7 @@ func (c *Client) ChatStream(ctx context.Context, req ChatRequest, opts *RequestO
 		resp:   resp,
 		ctx:    ctx,
 		closed: false,
+		span:   span,
 	}
 
 	return stream, nil
@@ -250,20 +462,38 @@ type MessagesStream struct {
 	resp   *http.Response
 	ctx    context.Context
 	closed bool
+	done   bool
+	usage  *AnthropicUsage
+	span   *operationSpan
+	err    error // terminal error, returned by Err
+	event  *MessagesStreamEvent
+
+	resumeToken string
 }
 
 // Recv reads the next event from the messages stream.
 //
-// Returns io.EOF when the stream is complete.
+// The final "message_stop" event is returned like any other event;
+// io.EOF is returned on the following call once the stream is complete.
+// An "error" event from the gateway is returned as a typed error rather than
+// an event. Errors other than io.EOF end the stream: later calls return the
+// same error, which is also reported by Err.
 func (s *MessagesStream) Recv() (*MessagesStreamEvent, error) {
+	if s.done {
+		_ = s.Close() // Explicitly ignore error in cleanup
+		return nil, io.EOF
+	}
+
+	if s.err != nil {
+		return nil, s.err
+	}
 	if s.closed {
 		return nil, errors.New("stream is closed")
 	}
 
 	// Check context
 	if err := s.ctx.Err(); err != nil {
-		_ = s.Close() // Explicitly ignore error in cleanup
-		return nil, err
+		return nil, s.fail(err)
 	}
 
 	for {
@@ -271,8 +501,9 @@ func (s *MessagesStream) Recv() (*MessagesStreamEvent, error) {
 		if err != nil {
 			if err == io.EOF {
 				_ = s.Close() // Explicitly ignore error in cleanup
+				return nil, err
 			}
-			return nil, err
+			return nil, s.fail(err)
 		}
 
 		line = strings.TrimSpace(line)
@@ -296,28 +527,154 @@ func (s *MessagesStream) Recv() (*MessagesStreamEvent, error) {
 		// Extract data
 		data := strings.TrimPrefix(line, "data: ")
 
-		// Parse JSON event
-		var event MessagesStreamEvent
-		if err := json.Unmarshal([]byte(data), &event); err != nil {
-			return nil, fmt.Errorf("failed to parse stream event: %w", err)
+		// Parse JSON event, checking for an "error" event emitted mid-stream
+		var chunk messagesStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, s.fail(fmt.Errorf("failed to parse stream event: %w", err))
+		}
+		if chunk.Type == "error" {
+			err := internal.ParseStreamError(chunk.Error, s.resp)
+			if err == nil {
+				err = &APIError{StatusCode: s.resp.StatusCode, Message: "stream error event without details"}
+			}
+			return nil, s.fail(err)
+		}
+		event := chunk.MessagesStreamEvent
+
+		s.trackUsage(&event)
+		if event.ResumeToken != "" {
+			s.resumeToken = event.ResumeToken
 		}
 
-		// Check for stream end
+		// Check for stream end; EOF is reported on the next call
 		if event.Type == "message_stop" {
-			_ = s.Close() // Explicitly ignore error in cleanup
-			return &event, io.EOF
+			s.done = true
 		}
 
 		return &event, nil
 	}
 }
 
+// ResumeToken returns the most recent resume token sent by the gateway, or
+// an empty string if none has been received. See ChatStream.ResumeToken.
+func (s *MessagesStream) ResumeToken() string {
+	return s.resumeToken
+}
+
+// trackUsage accumulates token usage from message_start and message_delta events.
+func (s *MessagesStream) trackUsage(event *MessagesStreamEvent) {
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			usage := event.Message.Usage
+			s.usage = &usage
+		}
+	case "message_delta":
+		if event.Usage == nil {
+			return
+		}
+		if s.usage == nil {
+			s.usage = &AnthropicUsage{}
+		}
+		mergeAnthropicUsage(s.usage, event.Usage)
+	}
+}
+
+// Next advances the stream to the next event, which is then available from
+// Current. It returns false when the stream ends or fails; Err distinguishes
+// the two.
+//
+// Example:
+//
+//	for stream.Next() {
+//		event := stream.Current()
+//		// ...
+//	}
+//	if err := stream.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+func (s *MessagesStream) Next() bool {
+	event, err := s.Recv()
+	if err != nil {
+		s.event = nil
+		return false
+	}
+	s.event = event
+	return true
+}
+
+// Current returns the event read by the last successful call to Next.
+func (s *MessagesStream) Current() *MessagesStreamEvent {
+	return s.event
+}
+
+// Err returns the error that ended the stream, such as a malformed event, a
+// mid-stream error from the gateway, or context cancellation. It returns nil
+// if the stream completed cleanly or is still open, mirroring
+// bufio.Scanner.Err.
+func (s *MessagesStream) Err() error {
+	return s.err
+}
+
+// fail records err as the stream's terminal error, closes the stream, and
+// returns err.
+func (s *MessagesStream) fail(err error) error {
+	s.err = err
+	_ = s.Close() // Explicitly ignore error in cleanup
+	return err
+}
+
+// Usage returns the accumulated token usage for the stream.
+//
+// The usage combines the message_start snapshot with message_delta updates.
+// It returns nil until the message_stop event has been received.
+func (s *MessagesStream) Usage() *AnthropicUsage {
+	if !s.done {
+		return nil
+	}
+	return s.usage
+}
+
+// WriteSSE forwards the remaining stream events to w as Server-Sent Events.
+//
+// Events are written in Anthropic's native format (an "event:" line with the
+// event type followed by a "data:" line) and flushed immediately. The stream
+// ends with the message_stop event, as in the upstream API. The stream is
+// closed when WriteSSE returns.
+func (s *MessagesStream) WriteSSE(w http.ResponseWriter) error {
+	defer s.Close()
+
+	flusher := prepareSSE(w)
+
+	for {
+		event, err := s.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal stream event: %w", err)
+		}
+		if err := writeSSEData(w, flusher, event.Type, data); err != nil {
+			return err
+		}
+	}
+}
+
 // Close closes the stream and releases resources.
 func (s *MessagesStream) Close() error {
 	if s.closed {
 		return nil
 	}
 	s.closed = true
+	if s.usage != nil {
+		s.span.setUsage(s.usage.InputTokens, s.usage.OutputTokens)
+	}
+	s.span.end(s.err)
 	if s.resp != nil && s.resp.Body != nil {
 		return s.resp.Body.Close()
 	}
@@ -345,6 +702,17 @@ type MessagesStreamEvent struct {
 
 	// Usage contains token usage updates (for message_delta).
 	Usage *AnthropicUsage `json:"usage,omitempty"`
+
+	// ResumeToken identifies the stream position after this event, for
+	// gateways that support resumable streams. Empty if not supported.
+	ResumeToken string `json:"resume_token,omitempty"`
+}
+
+// messagesStreamChunk is a MessagesStreamEvent as decoded from the wire,
+// where an "error" event carries its details in an error object.
+type messagesStreamChunk struct {
+	MessagesStreamEvent
+	Error json.RawMessage `json:"error"`
 }
 
 // MessagesStreamDelta represents incremental content in a Messages stream.
@@ -373,6 +741,9 @@ type MessagesStreamDelta struct {
 //
 // The stream must be closed when done to release resources.
 //
+// The stream is bounded by RequestOptions.Timeout if set, otherwise by
+// Config.StreamTimeout. Config.Timeout does not apply to streams.
+//
 // Example:
 //
 //	stream, err := client.MessagesStream(ctx, zaguansdk.MessagesRequest{
@@ -405,6 +776,12 @@ func (c *Client) MessagesStream(ctx context.Context, req MessagesRequest, opts *
 		return nil, err
 	}
 
+	metadata, err := c.prepareMetadata(req.Metadata, "metadata")
+	if err != nil {
+		return nil, err
+	}
+	req.Metadata = metadata
+
 	// Ensure stream is true
 	req.Stream = true
 
@@ -414,29 +791,20 @@ func (c *Client) MessagesStream(ctx context.Context, req MessagesRequest, opts *
 
 	// Build request config
 	reqCfg := internal.RequestConfig{
-		Method: "POST",
-		Path:   "/v1/messages",
-		Body:   req,
+		Operation: "zaguan.MessagesStream",
+		Method:    "POST",
+		Path:      "/v1/messages",
+		Body:      req,
 	}
 
 	// Apply request options
-	if opts != nil {
-		if opts.Timeout > 0 {
-			reqCfg.Timeout = opts.Timeout
-		}
-		if opts.RequestID != "" {
-			reqCfg.RequestID = opts.RequestID
-		}
-		if opts.Headers != nil {
-			reqCfg.Headers = opts.Headers
-		}
-	} else if c.timeout > 0 {
-		reqCfg.Timeout = c.timeout
-	}
+	applyRequestOptions(&reqCfg, opts, c.streamTimeout)
 
 	// Execute request
+	ctx, span := c.startSpan(ctx, "zaguan.MessagesStream", req.Model)
 	resp, err := c.internalHTTP.Do(ctx, reqCfg)
 	if err != nil {
+		span.end(err)
 		c.log(ctx, LogLevelError, "streaming messages request failed", "error", err)
 		return nil, err
 	}
@@ -444,7 +812,9 @@ func (c *Client) MessagesStream(ctx context.Context, req MessagesRequest, opts *
 	// Check for error status codes
 	if resp.StatusCode >= 400 {
 		defer resp.Body.Close()
-		return nil, internal.ParseErrorResponse(resp)
+		err := c.internalHTTP.ParseErrorResponse(resp)
+		span.end(err)
+		return nil, err
 	}
 
 	c.log(ctx, LogLevelDebug, "streaming messages request started")
@@ -455,7 +825,38 @@ func (c *Client) MessagesStream(ctx context.Context, req MessagesRequest, opts *
 		resp:   resp,
 		ctx:    ctx,
 		closed: false,
+		span:   span,
 	}
 
 	return stream, nil
 }
+
+// prepareSSE sets the Server-Sent Events response headers on w and returns
+// its flusher, if any.
+func prepareSSE(w http.ResponseWriter) http.Flusher {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	flusher, _ := w.(http.Flusher)
+	return flusher
+}
+
+// writeSSEData writes a single SSE message to w and flushes it.
+func writeSSEData(w io.Writer, flusher http.Flusher, eventType string, data []byte) error {
+	var buf bytes.Buffer
+	if eventType != "" {
+		buf.WriteString("event: " + eventType + "\n")
+	}
+	buf.WriteString("data: ")
+	buf.Write(data)
+	buf.WriteString("\n\n")
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write stream event: %w", err)
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+//go:build go1.23
+
+// Package zaguansdk provides range-over-func iterators for the Zaguan SDK.
+//
+// This file implements Seq on ChatStream and MessagesStream. It requires Go
+// 1.23; on older toolchains, use Recv or Next instead.
+package zaguansdk
+
+import (
+	"io"
+	"iter"
+)
+
+// Seq returns an iterator over the events of the stream.
+//
+// Each iteration yields an event and a nil error. If the stream fails, a
+// final iteration yields a nil event and the error, and iteration stops. The
+// stream is closed when the loop completes or exits early.
+//
+// Example:
+//
+//	for event, err := range stream.Seq() {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		if len(event.Choices) > 0 {
+//			fmt.Print(event.Choices[0].Delta.Content)
+//		}
+//	}
+func (s *ChatStream) Seq() iter.Seq2[*ChatStreamEvent, error] {
+	return func(yield func(*ChatStreamEvent, error) bool) {
+		defer s.Close()
+		for {
+			event, err := s.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Seq returns an iterator over the events of the stream.
+//
+// Each iteration yields an event and a nil error. If the stream fails, a
+// final iteration yields a nil event and the error, and iteration stops. The
+// stream is closed when the loop completes or exits early.
+//
+// Example:
+//
+//	for event, err := range stream.Seq() {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		if event.Delta != nil {
+//			fmt.Print(event.Delta.Text)
+//		}
+//	}
+func (s *MessagesStream) Seq() iter.Seq2[*MessagesStreamEvent, error] {
+	return func(yield func(*MessagesStreamEvent, error) bool) {
+		defer s.Close()
+		for {
+			event, err := s.Recv()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+//go:build go1.23
+
+package zaguansdk
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
+)
+
+func TestChatStream_Seq(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []string
+		breakAfter  int
+		wantContent string
+		wantErr     bool
+	}{
+		{
+			name:        "complete",
+			events:      []string{testutil.ChatStreamEventFixture("Hello"), testutil.ChatStreamEventFixture(" world")},
+			wantContent: "Hello world",
+		},
+		{
+			name:        "break early",
+			events:      []string{testutil.ChatStreamEventFixture("Hello"), testutil.ChatStreamEventFixture(" world")},
+			breakAfter:  1,
+			wantContent: "Hello",
+		},
+		{
+			name:        "malformed event",
+			events:      []string{testutil.ChatStreamEventFixture("Hello"), `{not json`},
+			wantContent: "Hello",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := testutil.NewMockServer(testutil.StreamingHandler(tt.events))
+			defer mockServer.Close()
+
+			client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+			stream, err := client.ChatStream(context.Background(), ChatRequest{
+				Model:    "openai/gpt-4o",
+				Messages: []Message{{Role: "user", Content: "Hello"}},
+			}, nil)
+			if err != nil {
+				t.Fatalf("ChatStream() error = %v", err)
+			}
+
+			var content strings.Builder
+			var gotErr error
+			n := 0
+			for event, err := range stream.Seq() {
+				if err != nil {
+					gotErr = err
+					continue
+				}
+				if len(event.Choices) > 0 {
+					content.WriteString(event.Choices[0].Delta.Content)
+				}
+				n++
+				if n == tt.breakAfter {
+					break
+				}
+			}
+
+			if content.String() != tt.wantContent {
+				t.Errorf("content = %q, want %q", content.String(), tt.wantContent)
+			}
+			if (gotErr != nil) != tt.wantErr {
+				t.Errorf("Seq() error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if !stream.closed {
+				t.Error("stream should be closed after the loop")
+			}
+		})
+	}
+}
+
+func TestMessagesStream_Seq(t *testing.T) {
+	mockServer := testutil.NewMockServer(testutil.StreamingHandler([]string{
+		testutil.MessagesStreamEventFixture("Hello"),
+		testutil.MessagesStreamEventFixture(" there"),
+		`{"type":"message_stop"}`,
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+	stream, err := client.MessagesStream(context.Background(), MessagesRequest{
+		Model:     "anthropic/claude-3-5-sonnet",
+		MaxTokens: 100,
+		Messages:  []AnthropicMessage{{Role: "user", Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("MessagesStream() error = %v", err)
+	}
+
+	var content strings.Builder
+	for event, err := range stream.Seq() {
+		if err != nil {
+			t.Fatalf("Seq() error = %v", err)
+		}
+		if event.Delta != nil {
+			content.WriteString(event.Delta.Text)
+		}
+	}
+
+	if content.String() != "Hello there" {
+		t.Errorf("content = %q, want %q", content.String(), "Hello there")
+	}
+	if !stream.closed {
+		t.Error("stream should be closed after the loop")
+	}
+}
@@ -1,10 +1,18 @@
 package zaguansdk
 
 import (
+	"compress/gzip"
 	"context"
+	"errors"
 	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
 	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
 )
 
@@ -171,6 +179,7 @@ func TestClient_MessagesStream(t *testing.T) {
 
 			// Read all events
 			eventCount := 0
+			var last *MessagesStreamEvent
 			for {
 				event, err := stream.Recv()
 				if err == io.EOF {
@@ -180,20 +189,410 @@ func TestClient_MessagesStream(t *testing.T) {
 					t.Fatalf("stream.Recv() error = %v", err)
 				}
 				if event == nil {
-					t.Error("stream.Recv() returned nil event")
+					t.Fatal("stream.Recv() returned nil event")
 				}
+				last = event
 				eventCount++
 			}
 
-			// Should receive all events except the stop event (which triggers EOF)
-			expectedCount := len(tt.events) - 1 // message_stop triggers EOF
-			if eventCount != expectedCount {
-				t.Errorf("received %d events, want %d", eventCount, expectedCount)
+			// The message_stop event is delivered before EOF
+			if eventCount != len(tt.events) {
+				t.Errorf("received %d events, want %d", eventCount, len(tt.events))
+			}
+			if last == nil || last.Type != "message_stop" {
+				t.Errorf("last event = %+v, want type message_stop", last)
 			}
 		})
 	}
 }
 
+func TestChatStream_ErrorEvent(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		testutil.StreamingHandler([]string{
+			testutil.ChatStreamEventFixture("Hello"),
+			`{"error":{"type":"rate_limit_exceeded","message":"Too many requests","details":{"retry_after":30}}}`,
+			testutil.ChatStreamEventFixture("unreachable"),
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	stream, err := client.ChatStream(context.Background(), ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "Hello"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("first Recv() error = %v", err)
+	}
+
+	_, err = stream.Recv()
+	var rateErr *internal.RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("Recv() error = %T (%v), want *RateLimitError", err, err)
+	}
+	if rateErr.Message != "Too many requests" {
+		t.Errorf("Message = %q, want %q", rateErr.Message, "Too many requests")
+	}
+	if rateErr.RetryAfter != 30 {
+		t.Errorf("RetryAfter = %d, want 30", rateErr.RetryAfter)
+	}
+}
+
+func TestMessagesStream_ErrorEvent(t *testing.T) {
+	mockServer := testutil.NewMockServer(testutil.StreamingHandler([]string{
+		testutil.MessagesStreamEventFixture("Hello"),
+		`{"type":"error","error":{"type":"rate_limit_exceeded","message":"Too many requests"}}`,
+		testutil.MessagesStreamEventFixture("unreachable"),
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+	stream, err := client.MessagesStream(context.Background(), MessagesRequest{
+		Model:     "anthropic/claude-3-5-sonnet",
+		MaxTokens: 100,
+		Messages:  []AnthropicMessage{{Role: "user", Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("MessagesStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if !stream.Next() {
+		t.Fatalf("Next() = false, want true (Err() = %v)", stream.Err())
+	}
+	if stream.Next() {
+		t.Fatalf("Next() = true for an error event, want false (event = %+v)", stream.Current())
+	}
+
+	var rateErr *internal.RateLimitError
+	if !errors.As(stream.Err(), &rateErr) {
+		t.Fatalf("Err() = %T (%v), want *RateLimitError", stream.Err(), stream.Err())
+	}
+	if rateErr.Message != "Too many requests" {
+		t.Errorf("Message = %q, want %q", rateErr.Message, "Too many requests")
+	}
+	if _, err := stream.Recv(); !errors.As(err, &rateErr) {
+		t.Errorf("Recv() after error = %v, want the same *RateLimitError", err)
+	}
+}
+
+func TestChatStream_Usage(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		testutil.StreamingHandler([]string{
+			testutil.ChatStreamEventFixture("Hello"),
+			`{"id":"chatcmpl-123","object":"chat.completion.chunk","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	stream, err := client.ChatStream(context.Background(), ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "Hello"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("stream.Recv() error = %v", err)
+		}
+		if stream.Usage() != nil {
+			t.Error("Usage() should be nil before EOF")
+		}
+	}
+
+	usage := stream.Usage()
+	if usage == nil {
+		t.Fatal("Usage() returned nil after EOF")
+	}
+	if usage.TotalTokens != 15 {
+		t.Errorf("TotalTokens = %d, want 15", usage.TotalTokens)
+	}
+}
+
+func TestMessagesStream_Usage(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		testutil.StreamingHandler([]string{
+			`{"type":"message_start","message":{"id":"msg_123","type":"message","role":"assistant","content":[],"model":"claude","usage":{"input_tokens":25,"output_tokens":1,"cache_read_input_tokens":100}}}`,
+			testutil.MessagesStreamEventFixture("Hello"),
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":12}}`,
+			`{"type":"message_stop"}`,
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	stream, err := client.MessagesStream(context.Background(), MessagesRequest{
+		Model:     "anthropic/claude-3-5-sonnet-20241022",
+		MaxTokens: 1024,
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: "Hello"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("MessagesStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	for {
+		_, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("stream.Recv() error = %v", err)
+		}
+	}
+
+	usage := stream.Usage()
+	if usage == nil {
+		t.Fatal("Usage() returned nil after EOF")
+	}
+	if usage.InputTokens != 25 {
+		t.Errorf("InputTokens = %d, want 25", usage.InputTokens)
+	}
+	if usage.OutputTokens != 12 {
+		t.Errorf("OutputTokens = %d, want 12", usage.OutputTokens)
+	}
+	if usage.CacheReadInputTokens != 100 {
+		t.Errorf("CacheReadInputTokens = %d, want 100", usage.CacheReadInputTokens)
+	}
+}
+
+func TestChatStream_WriteSSE(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		testutil.StreamingHandler([]string{
+			testutil.ChatStreamEventFixture("Hello"),
+			testutil.ChatStreamEventFixture(" there"),
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	stream, err := client.ChatStream(context.Background(), ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "Hello"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := stream.WriteSSE(rec); err != nil {
+		t.Fatalf("WriteSSE() error = %v", err)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	if !rec.Flushed {
+		t.Error("WriteSSE() did not flush the response")
+	}
+
+	body := rec.Body.String()
+	if got := strings.Count(body, "data: "); got != 3 {
+		t.Errorf("wrote %d data lines, want 3", got)
+	}
+	if !strings.Contains(body, `"content":" there"`) {
+		t.Errorf("body missing forwarded content: %s", body)
+	}
+	if !strings.HasSuffix(body, "data: [DONE]\n\n") {
+		t.Errorf("body does not end with [DONE]: %s", body)
+	}
+}
+
+func TestMessagesStream_WriteSSE(t *testing.T) {
+	mockServer := testutil.NewMockServer(
+		testutil.StreamingHandler([]string{
+			testutil.MessagesStreamEventFixture("Hello"),
+			`{"type":"message_stop"}`,
+		}),
+	)
+	defer mockServer.Close()
+
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+	})
+
+	stream, err := client.MessagesStream(context.Background(), MessagesRequest{
+		Model:     "anthropic/claude-3-5-sonnet-20241022",
+		MaxTokens: 1024,
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: "Hello"},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("MessagesStream() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	if err := stream.WriteSSE(rec); err != nil {
+		t.Fatalf("WriteSSE() error = %v", err)
+	}
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "event: content_block_delta\ndata: ") {
+		t.Errorf("body missing content_block_delta event: %s", body)
+	}
+	if !strings.HasSuffix(body, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n") {
+		t.Errorf("body does not end with message_stop: %s", body)
+	}
+}
+
+// slowStreamingHandler streams events with a delay between each one.
+func slowStreamingHandler(events []string, delay time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, event := range events {
+			time.Sleep(delay)
+			w.Write([]byte("data: " + event + "\n\n"))
+			flusher.Flush()
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+		flusher.Flush()
+	}
+}
+
+func TestChatStream_StreamTimeout(t *testing.T) {
+	events := []string{
+		testutil.ChatStreamEventFixture("a"),
+		testutil.ChatStreamEventFixture("b"),
+		testutil.ChatStreamEventFixture("c"),
+		testutil.ChatStreamEventFixture("d"),
+	}
+	req := ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "Hello"},
+		},
+	}
+
+	readAll := func(stream *ChatStream) error {
+		defer stream.Close()
+		for {
+			_, err := stream.Recv()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	t.Run("unary timeout does not apply to streams", func(t *testing.T) {
+		mockServer := testutil.NewMockServer(slowStreamingHandler(events, 20*time.Millisecond))
+		defer mockServer.Close()
+
+		client := NewClient(Config{
+			BaseURL: mockServer.URL(),
+			APIKey:  "test-key",
+			Timeout: 30 * time.Millisecond,
+		})
+
+		stream, err := client.ChatStream(context.Background(), req, nil)
+		if err != nil {
+			t.Fatalf("ChatStream() error = %v", err)
+		}
+		if err := readAll(stream); err != nil {
+			t.Errorf("stream error = %v, want complete stream", err)
+		}
+	})
+
+	t.Run("stream timeout bounds the stream", func(t *testing.T) {
+		mockServer := testutil.NewMockServer(slowStreamingHandler(events, 20*time.Millisecond))
+		defer mockServer.Close()
+
+		client := NewClient(Config{
+			BaseURL:       mockServer.URL(),
+			APIKey:        "test-key",
+			StreamTimeout: 50 * time.Millisecond,
+		})
+
+		stream, err := client.ChatStream(context.Background(), req, nil)
+		if err != nil {
+			t.Fatalf("ChatStream() error = %v", err)
+		}
+		if err := readAll(stream); err == nil {
+			t.Error("stream should fail when StreamTimeout elapses")
+		}
+	})
+
+	t.Run("stream timeout applies with other request options", func(t *testing.T) {
+		mockServer := testutil.NewMockServer(slowStreamingHandler(events, 20*time.Millisecond))
+		defer mockServer.Close()
+
+		client := NewClient(Config{
+			BaseURL:       mockServer.URL(),
+			APIKey:        "test-key",
+			StreamTimeout: 50 * time.Millisecond,
+		})
+
+		stream, err := client.ChatStream(context.Background(), req, WithRequestID("req-123"))
+		if err != nil {
+			t.Fatalf("ChatStream() error = %v", err)
+		}
+		if err := readAll(stream); err == nil {
+			t.Error("stream should fail when StreamTimeout elapses")
+		}
+	})
+
+	t.Run("request timeout overrides stream timeout", func(t *testing.T) {
+		mockServer := testutil.NewMockServer(slowStreamingHandler(events, 20*time.Millisecond))
+		defer mockServer.Close()
+
+		client := NewClient(Config{
+			BaseURL:       mockServer.URL(),
+			APIKey:        "test-key",
+			StreamTimeout: 50 * time.Millisecond,
+		})
+
+		stream, err := client.ChatStream(context.Background(), req, WithTimeout(5*time.Second))
+		if err != nil {
+			t.Fatalf("ChatStream() error = %v", err)
+		}
+		if err := readAll(stream); err != nil {
+			t.Errorf("stream error = %v, want complete stream", err)
+		}
+	})
+}
+
 func TestChatStream_Close(t *testing.T) {
 	mockServer := testutil.NewMockServer(
 		testutil.StreamingHandler([]string{
@@ -269,3 +668,272 @@ func TestChatStream_ContextCancellation(t *testing.T) {
 		t.Error("Recv() should return error after context cancellation")
 	}
 }
+
+func TestChatStream_GzipCompression(t *testing.T) {
+	received := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusOK)
+
+		zw := gzip.NewWriter(w)
+		flusher := w.(http.Flusher)
+		send := func(data string) {
+			zw.Write([]byte("data: " + data + "\n\n"))
+			zw.Flush()
+			flusher.Flush()
+		}
+
+		send(testutil.ChatStreamEventFixture("Hello"))
+		// Hold the rest until the client has decoded the first event, so the
+		// test fails if decompression buffers the whole stream
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Error("client did not receive the first event")
+		}
+		send(testutil.ChatStreamEventFixture(" world"))
+		send("[DONE]")
+		zw.Close()
+	}))
+	defer server.Close()
+
+	client := NewClient(Config{
+		BaseURL:           server.URL,
+		APIKey:            "test-key",
+		EnableCompression: true,
+	})
+
+	stream, err := client.ChatStream(context.Background(), ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	var content strings.Builder
+	for {
+		event, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("stream.Recv() error = %v", err)
+		}
+		if content.Len() == 0 {
+			close(received)
+		}
+		if len(event.Choices) > 0 {
+			content.WriteString(event.Choices[0].Delta.Content)
+		}
+	}
+
+	if content.String() != "Hello world" {
+		t.Errorf("content = %q, want %q", content.String(), "Hello world")
+	}
+}
+
+func TestChatStream_NextAndErr(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []string
+		wantContent string
+		wantErr     bool
+	}{
+		{
+			name:        "clean completion",
+			events:      []string{testutil.ChatStreamEventFixture("Hello"), testutil.ChatStreamEventFixture(" world")},
+			wantContent: "Hello world",
+		},
+		{
+			name:        "malformed event",
+			events:      []string{testutil.ChatStreamEventFixture("Hello"), `{not json`},
+			wantContent: "Hello",
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := testutil.NewMockServer(testutil.StreamingHandler(tt.events))
+			defer mockServer.Close()
+
+			client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+			stream, err := client.ChatStream(context.Background(), ChatRequest{
+				Model:    "openai/gpt-4o",
+				Messages: []Message{{Role: "user", Content: "Hello"}},
+			}, nil)
+			if err != nil {
+				t.Fatalf("ChatStream() error = %v", err)
+			}
+			defer stream.Close()
+
+			var content strings.Builder
+			for stream.Next() {
+				if event := stream.Current(); len(event.Choices) > 0 {
+					content.WriteString(event.Choices[0].Delta.Content)
+				}
+			}
+
+			if content.String() != tt.wantContent {
+				t.Errorf("content = %q, want %q", content.String(), tt.wantContent)
+			}
+			if (stream.Err() != nil) != tt.wantErr {
+				t.Errorf("Err() = %v, wantErr %v", stream.Err(), tt.wantErr)
+			}
+			if stream.Current() != nil {
+				t.Error("Current() should be nil after Next returns false")
+			}
+			// The terminal error is sticky
+			if _, err := stream.Recv(); tt.wantErr && err != stream.Err() {
+				t.Errorf("Recv() after failure = %v, want %v", err, stream.Err())
+			}
+		})
+	}
+}
+
+func TestMessagesStream_ErrOnCancel(t *testing.T) {
+	mockServer := testutil.NewMockServer(testutil.StreamingHandler([]string{
+		testutil.MessagesStreamEventFixture("Hello"),
+		testutil.MessagesStreamEventFixture(" world"),
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.MessagesStream(ctx, MessagesRequest{
+		Model:     "anthropic/claude-3-5-sonnet",
+		MaxTokens: 100,
+		Messages:  []AnthropicMessage{{Role: "user", Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("MessagesStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	if !stream.Next() {
+		t.Fatalf("Next() = false, want true (Err() = %v)", stream.Err())
+	}
+	cancel()
+	if stream.Next() {
+		t.Error("Next() = true after cancel, want false")
+	}
+	if !errors.Is(stream.Err(), context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", stream.Err())
+	}
+}
+
+func TestChatStream_ResumeToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		want   string
+	}{
+		{
+			name: "tokens sent",
+			events: []string{
+				`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"Hel"}}],"resume_token":"tok-1"}`,
+				`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"lo"}}],"resume_token":"tok-2"}`,
+				`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{}}]}`,
+			},
+			want: "tok-2",
+		},
+		{
+			name:   "not supported",
+			events: []string{testutil.ChatStreamEventFixture("Hello")},
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockServer := testutil.NewMockServer(testutil.StreamingHandler(tt.events))
+			defer mockServer.Close()
+
+			client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+			stream, err := client.ChatStream(context.Background(), ChatRequest{
+				Model:    "openai/gpt-4o",
+				Messages: []Message{{Role: "user", Content: "Hello"}},
+			}, nil)
+			if err != nil {
+				t.Fatalf("ChatStream() error = %v", err)
+			}
+			defer stream.Close()
+
+			for stream.Next() {
+			}
+			if err := stream.Err(); err != nil {
+				t.Fatalf("Err() = %v", err)
+			}
+			if got := stream.ResumeToken(); got != tt.want {
+				t.Errorf("ResumeToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessagesStream_ResumeToken(t *testing.T) {
+	mockServer := testutil.NewMockServer(testutil.StreamingHandler([]string{
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"},"resume_token":"tok-7"}`,
+		`{"type":"message_stop"}`,
+	}))
+	defer mockServer.Close()
+
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+	stream, err := client.MessagesStream(context.Background(), MessagesRequest{
+		Model:     "anthropic/claude-3-5-sonnet",
+		MaxTokens: 100,
+		Messages:  []AnthropicMessage{{Role: "user", Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("MessagesStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	for stream.Next() {
+	}
+	if got := stream.ResumeToken(); got != "tok-7" {
+		t.Errorf("ResumeToken() = %q, want tok-7", got)
+	}
+}
+
+func TestChatStream_Citations(t *testing.T) {
+	events := []string{
+		`{"id":"pplx-1","choices":[{"index":0,"delta":{"content":"Go"}}],"citations":["https://go.dev/"]}`,
+		`{"id":"pplx-1","choices":[{"index":0,"delta":{"content":" rocks"}}],"citations":["https://go.dev/","https://pkg.go.dev/"]}`,
+		`{"id":"pplx-1","choices":[{"index":0,"delta":{}}]}`,
+	}
+	mockServer := testutil.NewMockServer(testutil.StreamingHandler(events))
+	defer mockServer.Close()
+
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+	stream, err := client.ChatStream(context.Background(), ChatRequest{
+		Model:    "perplexity/sonar",
+		Messages: []Message{{Role: "user", Content: "What is Go?"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	defer stream.Close()
+
+	acc := NewChatStreamAccumulator()
+	for stream.Next() {
+		acc.Add(stream.Current())
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	want := []string{"https://go.dev/", "https://pkg.go.dev/"}
+	if got := stream.Citations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Citations() = %v, want %v", got, want)
+	}
+	if got := acc.Citations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ChatStreamAccumulator.Citations() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,168 @@
+// Package zaguansdk provides tracing hooks for the Zaguan SDK.
+//
+// This file implements the Tracer and Span interfaces used to wrap SDK
+// operations in spans. They are deliberately minimal so that OpenTelemetry
+// (or any other tracing library) can be plugged in through a small adapter
+// without the SDK depending on it.
+package zaguansdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal"
+)
+
+// Tracer starts spans around SDK operations. Set Config.Tracer to enable
+// tracing.
+//
+// Every operation that calls the API is traced, in spans named after the
+// method: "zaguan.Chat", "zaguan.ChatStream", "zaguan.ListModels", and so on.
+// Spans carry the attribute "http.status_code" from the final response, and
+// Chat, ChatStream, Messages, MessagesStream, CreateCompletion,
+// CompletionStream, and CreateEmbeddings also record "zaguan.model" and, when
+// the response reports usage, "zaguan.usage.input_tokens" and
+// "zaguan.usage.output_tokens". Stream spans end when the stream is closed.
+//
+// If the Tracer also implements TracePropagator, its Inject method is called
+// on the headers of every outgoing request, so trace context reaches the
+// server.
+//
+// An OpenTelemetry adapter takes a few lines:
+//
+//	type otelTracer struct{ tracer trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, op string) (context.Context, zaguansdk.Span) {
+//		ctx, span := t.tracer.Start(ctx, op, trace.WithSpanKind(trace.SpanKindClient))
+//		return ctx, otelSpan{span}
+//	}
+//
+//	func (t otelTracer) Inject(ctx context.Context, h http.Header) {
+//		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+//	}
+//
+//	type otelSpan struct{ span trace.Span }
+//
+//	func (s otelSpan) SetAttribute(key string, value interface{}) {
+//		s.span.SetAttributes(attribute.String(key, fmt.Sprint(value)))
+//	}
+//
+//	func (s otelSpan) RecordError(err error) {
+//		s.span.RecordError(err)
+//		s.span.SetStatus(codes.Error, err.Error())
+//	}
+//
+//	func (s otelSpan) End() { s.span.End() }
+type Tracer interface {
+	// Start starts a span for operation and returns a context carrying it.
+	Start(ctx context.Context, operation string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttribute records an attribute on the span.
+	SetAttribute(key string, value interface{})
+
+	// RecordError records err on the span and marks the span as failed.
+	RecordError(err error)
+
+	// End ends the span.
+	End()
+}
+
+// TracePropagator is implemented by Tracers that propagate trace context in
+// outgoing HTTP headers.
+type TracePropagator interface {
+	// Inject adds the trace context from ctx to header.
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span attribute keys set by the SDK.
+const (
+	spanAttrModel        = "zaguan.model"
+	spanAttrStatusCode   = "http.status_code"
+	spanAttrInputTokens  = "zaguan.usage.input_tokens"
+	spanAttrOutputTokens = "zaguan.usage.output_tokens"
+)
+
+// operationSpan wraps a Span for an SDK operation. A nil *operationSpan is
+// valid and does nothing, so call sites need not check whether tracing is
+// enabled.
+type operationSpan struct {
+	span Span
+}
+
+// operationSpanKey is the context key under which startSpan stores the
+// *operationSpan, so that traceRequest can record the HTTP status on it.
+type operationSpanKey struct{}
+
+// startSpan starts a span for operation if a Tracer is configured.
+func (c *Client) startSpan(ctx context.Context, operation, model string) (context.Context, *operationSpan) {
+	if c.tracer == nil {
+		return ctx, nil
+	}
+	ctx, span := c.tracer.Start(ctx, operation)
+	if model != "" {
+		span.SetAttribute(spanAttrModel, model)
+	}
+	s := &operationSpan{span: span}
+	return context.WithValue(ctx, operationSpanKey{}, s), s
+}
+
+// traceRequest is the internal.HTTPClient Trace hook. Requests made within
+// an operation that started its own span record their status code on it;
+// any other request gets a span named after cfg.Operation.
+func (c *Client) traceRequest(ctx context.Context, cfg internal.RequestConfig) (context.Context, func(*http.Response, error)) {
+	if s, ok := ctx.Value(operationSpanKey{}).(*operationSpan); ok {
+		return ctx, func(resp *http.Response, err error) {
+			s.setStatus(resp)
+		}
+	}
+
+	operation := cfg.Operation
+	if operation == "" {
+		operation = "zaguan.Request"
+	}
+	ctx, s := c.startSpan(ctx, operation, "")
+	return ctx, func(resp *http.Response, err error) {
+		s.setStatus(resp)
+		if err == nil && resp.StatusCode >= http.StatusBadRequest {
+			err = fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		s.end(err)
+	}
+}
+
+// setStatus records the status code of resp on the span.
+func (s *operationSpan) setStatus(resp *http.Response) {
+	if s == nil || resp == nil {
+		return
+	}
+	s.span.SetAttribute(spanAttrStatusCode, resp.StatusCode)
+}
+
+// setUsage records token usage on the span.
+func (s *operationSpan) setUsage(inputTokens, outputTokens int) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttribute(spanAttrInputTokens, inputTokens)
+	s.span.SetAttribute(spanAttrOutputTokens, outputTokens)
+}
+
+// end records the outcome of the operation and ends the span.
+func (s *operationSpan) end(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode != 0 {
+			s.span.SetAttribute(spanAttrStatusCode, apiErr.StatusCode)
+		}
+		s.span.RecordError(err)
+	}
+	s.span.End()
+}
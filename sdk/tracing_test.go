@@ -0,0 +1,252 @@
+package zaguansdk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
+)
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+type traceIDKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, operation string) (context.Context, Span) {
+	span := &recordingSpan{name: operation, attrs: map[string]interface{}{}}
+	t.mu.Lock()
+	t.spans = append(t.spans, span)
+	t.mu.Unlock()
+	return context.WithValue(ctx, traceIDKey{}, operation), span
+}
+
+func (t *recordingTracer) Inject(ctx context.Context, header http.Header) {
+	if op, ok := ctx.Value(traceIDKey{}).(string); ok {
+		header.Set("Traceparent", op)
+	}
+}
+
+func (s *recordingSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *recordingSpan) RecordError(err error)                      { s.err = err }
+func (s *recordingSpan) End()                                       { s.ended = true }
+
+func TestClient_Tracing(t *testing.T) {
+	var traceparent string
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		if r.Header.Get("X-Fail") != "" {
+			testutil.ErrorHandler(http.StatusTooManyRequests, "rate_limit_exceeded", "slow down")(w, r)
+			return
+		}
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	tracer := &recordingTracer{}
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+		Tracer:  tracer,
+	})
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}
+
+	resp, err := client.Chat(context.Background(), req, nil)
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	failOpts := WithHeaders(http.Header{"X-Fail": []string{"1"}})
+	if _, err := client.Chat(context.Background(), req, failOpts); err == nil {
+		t.Fatal("Chat() error = nil, want error")
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(tracer.spans))
+	}
+	if traceparent != "zaguan.Chat" {
+		t.Errorf("Traceparent = %q, want trace context injected", traceparent)
+	}
+
+	ok := tracer.spans[0]
+	if ok.name != "zaguan.Chat" || !ok.ended || ok.err != nil {
+		t.Errorf("span = %+v, want ended zaguan.Chat without error", ok)
+	}
+	wantAttrs := map[string]interface{}{
+		"zaguan.model":               "openai/gpt-4o",
+		"http.status_code":           http.StatusOK,
+		"zaguan.usage.input_tokens":  resp.Usage.PromptTokens,
+		"zaguan.usage.output_tokens": resp.Usage.CompletionTokens,
+	}
+	for k, want := range wantAttrs {
+		if ok.attrs[k] != want {
+			t.Errorf("attribute %s = %v, want %v", k, ok.attrs[k], want)
+		}
+	}
+
+	failed := tracer.spans[1]
+	if failed.err == nil || !failed.ended {
+		t.Errorf("failed span = %+v, want ended with error", failed)
+	}
+	if failed.attrs["http.status_code"] != http.StatusTooManyRequests {
+		t.Errorf("failed span status = %v, want 429", failed.attrs["http.status_code"])
+	}
+}
+
+func TestClient_Tracing_Stream(t *testing.T) {
+	mockServer := testutil.NewMockServer(testutil.StreamingHandler([]string{
+		testutil.ChatStreamEventFixture("Hello"),
+		`{"id":"chatcmpl-123","object":"chat.completion.chunk","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`,
+	}))
+	defer mockServer.Close()
+
+	tracer := &recordingTracer{}
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+		Tracer:  tracer,
+	})
+
+	stream, err := client.ChatStream(context.Background(), ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+
+	span := tracer.spans[0]
+	if span.ended {
+		t.Error("stream span ended before the stream was consumed")
+	}
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("stream.Recv() error = %v", err)
+		}
+	}
+	stream.Close()
+
+	if span.name != "zaguan.ChatStream" || !span.ended {
+		t.Errorf("span = %+v, want ended zaguan.ChatStream", span)
+	}
+	if span.attrs["zaguan.usage.input_tokens"] != 10 || span.attrs["zaguan.usage.output_tokens"] != 5 {
+		t.Errorf("span usage = %v", span.attrs)
+	}
+}
+
+func TestClient_NoTracer(t *testing.T) {
+	mockServer := testutil.NewMockServer(testutil.ChatCompletionHandler(testutil.ChatCompletionFixture()))
+	defer mockServer.Close()
+
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+	if _, err := client.Chat(context.Background(), ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+}
+
+func TestClient_Tracing_UntracedOperations(t *testing.T) {
+	var traceparent string
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("Traceparent")
+		switch r.URL.Path {
+		case "/v1/models":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, `{"object":"list","data":[]}`)
+		default:
+			testutil.ErrorHandler(http.StatusNotFound, "not_found", "no such model")(w, r)
+		}
+	}))
+	defer mockServer.Close()
+
+	tracer := &recordingTracer{}
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+		Tracer:  tracer,
+	})
+
+	if _, err := client.ListModels(context.Background(), nil, nil); err != nil {
+		t.Fatalf("ListModels() error = %v", err)
+	}
+	if _, err := client.GetModel(context.Background(), "missing", nil); err == nil {
+		t.Fatal("GetModel() error = nil, want error")
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(tracer.spans))
+	}
+	if traceparent != "zaguan.GetModel" {
+		t.Errorf("Traceparent = %q, want trace context injected", traceparent)
+	}
+
+	tests := []struct {
+		span       *recordingSpan
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{tracer.spans[0], "zaguan.ListModels", http.StatusOK, false},
+		{tracer.spans[1], "zaguan.GetModel", http.StatusNotFound, true},
+	}
+	for _, tt := range tests {
+		if tt.span.name != tt.name || !tt.span.ended {
+			t.Errorf("span = %+v, want ended %s", tt.span, tt.name)
+		}
+		if tt.span.attrs["http.status_code"] != tt.statusCode {
+			t.Errorf("%s status = %v, want %d", tt.name, tt.span.attrs["http.status_code"], tt.statusCode)
+		}
+		if (tt.span.err != nil) != tt.wantErr {
+			t.Errorf("%s error = %v, wantErr %v", tt.name, tt.span.err, tt.wantErr)
+		}
+	}
+}
+
+func TestClient_Tracing_RealStatusCode(t *testing.T) {
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(testutil.ChatCompletionFixture())
+	}))
+	defer mockServer.Close()
+
+	tracer := &recordingTracer{}
+	client := NewClient(Config{
+		BaseURL: mockServer.URL(),
+		APIKey:  "test-key",
+		Tracer:  tracer,
+	})
+
+	if _, err := client.Chat(context.Background(), ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	}, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(tracer.spans))
+	}
+	if got := tracer.spans[0].attrs["http.status_code"]; got != http.StatusAccepted {
+		t.Errorf("status = %v, want %d", got, http.StatusAccepted)
+	}
+}
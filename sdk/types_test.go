@@ -1,6 +1,8 @@
 package zaguansdk
 
 import (
+	"encoding/json"
+	"reflect"
 	"testing"
 )
 
@@ -84,6 +86,78 @@ func TestUsage_HasCachedTokens(t *testing.T) {
 	}
 }
 
+func TestSumUsage(t *testing.T) {
+	usages := []Usage{
+		{
+			PromptTokens:     100,
+			CompletionTokens: 50,
+			TotalTokens:      150,
+			PromptTokensDetails: &TokenDetails{
+				CachedTokens: 40,
+			},
+		},
+		{
+			PromptTokens:     200,
+			CompletionTokens: 80,
+			TotalTokens:      280,
+			CompletionTokensDetails: &TokenDetails{
+				ReasoningTokens:          30,
+				AcceptedPredictionTokens: 5,
+			},
+		},
+		{
+			PromptTokens:     10,
+			CompletionTokens: 20,
+			TotalTokens:      30,
+			PromptTokensDetails: &TokenDetails{
+				CachedTokens: 2,
+				AudioTokens:  7,
+			},
+			CompletionTokensDetails: &TokenDetails{
+				ReasoningTokens:          1,
+				AudioTokens:              3,
+				RejectedPredictionTokens: 4,
+			},
+		},
+	}
+
+	want := Usage{
+		PromptTokens:     310,
+		CompletionTokens: 150,
+		TotalTokens:      460,
+		PromptTokensDetails: &TokenDetails{
+			CachedTokens: 42,
+			AudioTokens:  7,
+		},
+		CompletionTokensDetails: &TokenDetails{
+			ReasoningTokens:          31,
+			AudioTokens:              3,
+			AcceptedPredictionTokens: 5,
+			RejectedPredictionTokens: 4,
+		},
+	}
+
+	got := SumUsage(usages...)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SumUsage() = %+v, want %+v", got, want)
+	}
+
+	// Add agrees with SumUsage and does not modify its operands
+	added := usages[0].Add(usages[1])
+	if added = added.Add(usages[2]); !reflect.DeepEqual(added, want) {
+		t.Errorf("Add() = %+v, want %+v", added, want)
+	}
+	if usages[0].PromptTokensDetails.CachedTokens != 40 {
+		t.Errorf("Add() modified its receiver: CachedTokens = %d", usages[0].PromptTokensDetails.CachedTokens)
+	}
+
+	// Details stay nil when no usage has them
+	bare := Usage{PromptTokens: 1}
+	if got := bare.Add(Usage{PromptTokens: 2}); got.PromptTokensDetails != nil || got.CompletionTokensDetails != nil {
+		t.Errorf("Add() details = %+v, %+v, want nil", got.PromptTokensDetails, got.CompletionTokensDetails)
+	}
+}
+
 func TestMessage_Types(t *testing.T) {
 	// Test basic message creation
 	msg := Message{
@@ -245,6 +319,27 @@ func TestChatResponse_Structure(t *testing.T) {
 	}
 }
 
+func TestChatResponse_Citations(t *testing.T) {
+	data := `{
+		"id": "pplx-123",
+		"object": "chat.completion",
+		"model": "perplexity/sonar",
+		"citations": ["https://go.dev/doc/", "https://pkg.go.dev/"],
+		"choices": [{"index": 0, "message": {"role": "assistant", "content": "Go is... [1][2]"}, "finish_reason": "stop"}],
+		"usage": {"prompt_tokens": 5, "completion_tokens": 7, "total_tokens": 12}
+	}`
+
+	var resp ChatResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	want := []string{"https://go.dev/doc/", "https://pkg.go.dev/"}
+	if !reflect.DeepEqual(resp.Citations, want) {
+		t.Errorf("Citations = %v, want %v", resp.Citations, want)
+	}
+}
+
 func TestAnthropicMessage_Types(t *testing.T) {
 	msg := AnthropicMessage{
 		Role:    "user",
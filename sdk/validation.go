@@ -1,9 +1,13 @@
 package zaguansdk
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 // ValidationError represents an input validation error.
@@ -56,6 +60,27 @@ func validateChatRequest(req *ChatRequest) error {
 		}
 	}
 
+	// Validate n
+	if req.N != nil && *req.N < 1 {
+		return &ValidationError{Field: "n", Message: "n must be at least 1"}
+	}
+
+	// Validate top_logprobs
+	if req.TopLogprobs != nil {
+		if *req.TopLogprobs < 0 || *req.TopLogprobs > 20 {
+			return &ValidationError{
+				Field:   "top_logprobs",
+				Message: "top_logprobs must be between 0 and 20",
+			}
+		}
+		if req.Logprobs == nil || !*req.Logprobs {
+			return &ValidationError{
+				Field:   "top_logprobs",
+				Message: "top_logprobs requires logprobs to be true",
+			}
+		}
+	}
+
 	// Validate presence_penalty range
 	if req.PresencePenalty != nil {
 		if *req.PresencePenalty < -2 || *req.PresencePenalty > 2 {
@@ -76,6 +101,31 @@ func validateChatRequest(req *ChatRequest) error {
 		}
 	}
 
+	// Validate tool schemas
+	for i := range req.Tools {
+		if err := req.Tools[i].ValidateSchema(); err != nil {
+			if ve, ok := err.(*ValidationError); ok {
+				return &ValidationError{
+					Field:   fmt.Sprintf("tools[%d].%s", i, ve.Field),
+					Message: ve.Message,
+				}
+			}
+			return err
+		}
+	}
+
+	// Audio output requires a voice and format
+	if requestsAudioOutput(req) {
+		if err := validateChatAudioConfig(req.Audio); err != nil {
+			return err
+		}
+	} else if req.Audio != nil {
+		return &ValidationError{
+			Field:   "modalities",
+			Message: "modalities must include audio when audio is set",
+		}
+	}
+
 	// Validate reasoning_effort
 	if req.ReasoningEffort != "" {
 		validEfforts := map[string]bool{
@@ -95,6 +145,216 @@ func validateChatRequest(req *ChatRequest) error {
 	return nil
 }
 
+// validChatAudioVoices are the voices accepted in AudioConfig.Voice.
+var validChatAudioVoices = map[string]bool{
+	"alloy":   true,
+	"ash":     true,
+	"ballad":  true,
+	"coral":   true,
+	"echo":    true,
+	"fable":   true,
+	"onyx":    true,
+	"nova":    true,
+	"sage":    true,
+	"shimmer": true,
+	"verse":   true,
+}
+
+// validChatAudioFormats are the formats accepted in AudioConfig.Format.
+var validChatAudioFormats = map[string]bool{
+	"wav":   true,
+	"mp3":   true,
+	"opus":  true,
+	"aac":   true,
+	"flac":  true,
+	"pcm":   true,
+	"pcm16": true,
+}
+
+// requestsAudioOutput reports whether req asks for the audio output modality.
+func requestsAudioOutput(req *ChatRequest) bool {
+	for _, m := range req.Modalities {
+		if m == "audio" {
+			return true
+		}
+	}
+	return false
+}
+
+// validateChatAudioConfig checks the audio output configuration of a chat
+// request that requests the audio modality.
+func validateChatAudioConfig(audio *AudioConfig) error {
+	if audio == nil {
+		return &ValidationError{
+			Field:   "audio",
+			Message: "audio is required when modalities include audio",
+		}
+	}
+	if audio.Voice == "" {
+		return &ValidationError{Field: "audio.voice", Message: "voice is required for audio output"}
+	}
+	if !validChatAudioVoices[audio.Voice] {
+		return &ValidationError{
+			Field:   "audio.voice",
+			Message: fmt.Sprintf("unsupported voice %q", audio.Voice),
+		}
+	}
+	if audio.Format == "" {
+		return &ValidationError{Field: "audio.format", Message: "format is required for audio output"}
+	}
+	if !validChatAudioFormats[audio.Format] {
+		return &ValidationError{
+			Field:   "audio.format",
+			Message: "format must be one of: wav, mp3, opus, aac, flac, pcm, pcm16",
+		}
+	}
+	return nil
+}
+
+// ValidateSchema checks that the tool's function parameters are a well-formed
+// JSON Schema object.
+//
+// The schema must declare a "type"; object schemas must have a "properties"
+// object whose entries are schemas, and every name listed in "required" must
+// be one of those properties. Nested object and array schemas are checked
+// recursively. A nil Parameters value is allowed for functions without
+// arguments.
+//
+// ValidateSchema is called automatically for each tool when sending a chat
+// request, but can be used to check tools at definition time.
+func (t *Tool) ValidateSchema() error {
+	if t.Function.Name == "" {
+		return &ValidationError{Field: "function.name", Message: "function name is required"}
+	}
+	if t.Function.Parameters == nil {
+		return nil
+	}
+
+	// Normalize the schema (map, struct, or raw JSON) into a generic object
+	raw, err := json.Marshal(t.Function.Parameters)
+	if err != nil {
+		return &ValidationError{
+			Field:   "function.parameters",
+			Message: fmt.Sprintf("parameters must be JSON-serializable: %v", err),
+		}
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return &ValidationError{
+			Field:   "function.parameters",
+			Message: "parameters must be a JSON Schema object",
+		}
+	}
+
+	if _, ok := schema["type"].(string); !ok {
+		return &ValidationError{
+			Field:   "function.parameters.type",
+			Message: "schema type is required",
+		}
+	}
+
+	return validateSchemaNode(schema, "function.parameters")
+}
+
+// validateSchemaNode validates the properties, required, and items keywords of
+// a JSON Schema node.
+func validateSchemaNode(schema map[string]interface{}, path string) error {
+	var properties map[string]interface{}
+	if v, ok := schema["properties"]; ok {
+		properties, ok = v.(map[string]interface{})
+		if !ok {
+			return &ValidationError{
+				Field:   path + ".properties",
+				Message: "properties must be an object",
+			}
+		}
+		for name, prop := range properties {
+			propSchema, ok := prop.(map[string]interface{})
+			if !ok {
+				return &ValidationError{
+					Field:   path + ".properties." + name,
+					Message: "property schema must be an object",
+				}
+			}
+			if err := validateSchemaNode(propSchema, path+".properties."+name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if v, ok := schema["required"]; ok {
+		required, ok := v.([]interface{})
+		if !ok {
+			return &ValidationError{
+				Field:   path + ".required",
+				Message: "required must be an array of property names",
+			}
+		}
+		for _, r := range required {
+			name, ok := r.(string)
+			if !ok {
+				return &ValidationError{
+					Field:   path + ".required",
+					Message: "required must be an array of property names",
+				}
+			}
+			if _, ok := properties[name]; !ok {
+				return &ValidationError{
+					Field:   path + ".required",
+					Message: fmt.Sprintf("required property %q is not defined in properties", name),
+				}
+			}
+		}
+	}
+
+	if v, ok := schema["items"]; ok {
+		items, ok := v.(map[string]interface{})
+		if !ok {
+			return &ValidationError{
+				Field:   path + ".items",
+				Message: "items must be a schema object",
+			}
+		}
+		if err := validateSchemaNode(items, path+".items"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateToolMessages checks that every tool message answers a tool call made
+// by an earlier assistant message. It is applied when Config.StrictToolMessages
+// is set.
+func validateToolMessages(messages []Message) error {
+	calls := make(map[string]bool)
+	for i, msg := range messages {
+		switch msg.Role {
+		case "assistant":
+			for _, tc := range msg.ToolCalls {
+				if tc.ID != "" {
+					calls[tc.ID] = true
+				}
+			}
+		case "tool":
+			if msg.ToolCallID == "" {
+				return &ValidationError{
+					Field:   fmt.Sprintf("messages[%d].tool_call_id", i),
+					Message: "tool_call_id is required for tool messages",
+				}
+			}
+			if !calls[msg.ToolCallID] {
+				return &ValidationError{
+					Field: fmt.Sprintf("messages[%d].tool_call_id", i),
+					Message: fmt.Sprintf("tool_call_id %q does not match a tool call in an earlier assistant message",
+						msg.ToolCallID),
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // validateMessagesRequest validates a MessagesRequest before sending to the API.
 func validateMessagesRequest(req *MessagesRequest) error {
 	// Model is required
@@ -107,6 +367,13 @@ func validateMessagesRequest(req *MessagesRequest) error {
 		return &ValidationError{Field: "messages", Message: "at least one message is required"}
 	}
 
+	// System must be a string or text blocks
+	system, err := normalizeSystem(req.System, "system")
+	if err != nil {
+		return err
+	}
+	req.System = system
+
 	// MaxTokens is required for Anthropic API
 	if req.MaxTokens < 1 {
 		return &ValidationError{
@@ -151,12 +418,34 @@ func validateMessagesRequest(req *MessagesRequest) error {
 				Message: "thinking.type must be 'enabled' or 'disabled'",
 			}
 		}
-		if req.Thinking.BudgetTokens > 0 {
-			if req.Thinking.BudgetTokens < 1000 || req.Thinking.BudgetTokens > 10000 {
-				return &ValidationError{
-					Field:   "thinking.budget_tokens",
-					Message: "thinking.budget_tokens must be between 1000 and 10000",
-				}
+		if req.Thinking.BudgetTokens > 0 && req.Thinking.BudgetTokens < 1000 {
+			return &ValidationError{
+				Field:   "thinking.budget_tokens",
+				Message: "thinking.budget_tokens must be at least 1000",
+			}
+		}
+		// The budget is part of max_tokens, so it must leave room for the answer
+		if req.Thinking.Type == "enabled" && req.Thinking.BudgetTokens >= req.MaxTokens {
+			return &ValidationError{
+				Field: "thinking.budget_tokens",
+				Message: fmt.Sprintf("thinking.budget_tokens (%d) must be less than max_tokens (%d)",
+					req.Thinking.BudgetTokens, req.MaxTokens),
+			}
+		}
+	}
+
+	// Validate tools
+	for i, tool := range req.Tools {
+		if tool.Name == "" {
+			return &ValidationError{
+				Field:   fmt.Sprintf("tools[%d].name", i),
+				Message: "tool name is required",
+			}
+		}
+		if tool.InputSchema == nil {
+			return &ValidationError{
+				Field:   fmt.Sprintf("tools[%d].input_schema", i),
+				Message: "input_schema is required",
 			}
 		}
 	}
@@ -164,6 +453,38 @@ func validateMessagesRequest(req *MessagesRequest) error {
 	return nil
 }
 
+// normalizeSystem checks a Messages API system prompt, which may be a string
+// or []AnthropicContentBlock of text blocks. An empty string is returned as nil
+// so it is omitted from the request.
+func normalizeSystem(system interface{}, field string) (interface{}, error) {
+	switch v := system.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return v, nil
+	case []AnthropicContentBlock:
+		for i, block := range v {
+			if block.Type != "text" {
+				return nil, &ValidationError{
+					Field:   fmt.Sprintf("%s[%d].type", field, i),
+					Message: fmt.Sprintf("system blocks must have type text, got %q", block.Type),
+				}
+			}
+		}
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v, nil
+	}
+	return nil, &ValidationError{
+		Field:   field,
+		Message: fmt.Sprintf("system must be a string or text content blocks, got %T", system),
+	}
+}
+
 // validateConfig validates the client configuration.
 func validateConfig(cfg *Config) error {
 	if cfg.BaseURL == "" {
@@ -183,9 +504,45 @@ func validateConfig(cfg *Config) error {
 	// Warn about http (not https) but don't fail
 	// This is just basic validation, not security enforcement
 
+	// ForceHTTP1 can only reconfigure standard transports
+	if cfg.ForceHTTP1 && cfg.HTTPClient != nil && cfg.HTTPClient.Transport != nil {
+		if _, ok := cfg.HTTPClient.Transport.(*http.Transport); !ok {
+			return errors.New("ForceHTTP1 requires HTTPClient.Transport to be nil or an *http.Transport")
+		}
+	}
+
+	switch cfg.VirtualModelMode {
+	case "", VirtualModelModeField, VirtualModelModeHeader, VirtualModelModeModel:
+	default:
+		return fmt.Errorf("VirtualModelMode must be %q, %q or %q, got %q",
+			VirtualModelModeField, VirtualModelModeHeader, VirtualModelModeModel, cfg.VirtualModelMode)
+	}
+
+	// Reject default headers that would clash with SDK-managed headers
+	for name := range cfg.DefaultHeaders {
+		if isReservedHeader(name) {
+			return fmt.Errorf("DefaultHeaders must not set reserved header %s", http.CanonicalHeaderKey(name))
+		}
+	}
+
 	return nil
 }
 
+// reservedHeaders are set by the SDK on every request and cannot be
+// overridden through Config.DefaultHeaders.
+var reservedHeaders = []string{"Authorization", "User-Agent", "X-Request-Id", "Content-Type"}
+
+// isReservedHeader reports whether name is an SDK-managed header.
+func isReservedHeader(name string) bool {
+	canonical := http.CanonicalHeaderKey(name)
+	for _, h := range reservedHeaders {
+		if canonical == h {
+			return true
+		}
+	}
+	return false
+}
+
 // validateModelID validates a model ID format.
 func validateModelID(modelID string) error {
 	if modelID == "" {
@@ -211,6 +568,13 @@ func validateEmbeddingsRequest(req *EmbeddingsRequest) error {
 		return &ValidationError{Field: "input", Message: "input is required"}
 	}
 
+	// Coerce input to a string or []string
+	input, err := normalizeTextInput(req.Input)
+	if err != nil {
+		return err
+	}
+	req.Input = input
+
 	// Validate input type
 	switch v := req.Input.(type) {
 	case string:
@@ -257,6 +621,84 @@ func validateEmbeddingsRequest(req *EmbeddingsRequest) error {
 	return nil
 }
 
+// validateCompletionRequest validates a CompletionRequest.
+func validateCompletionRequest(req *CompletionRequest) error {
+	if req.Model == "" {
+		return &ValidationError{Field: "model", Message: "model is required"}
+	}
+	if req.Prompt == nil {
+		return &ValidationError{Field: "prompt", Message: "prompt is required"}
+	}
+
+	// Coerce prompt to a string or []string
+	prompt, err := normalizeTextInput(req.Prompt)
+	if err != nil {
+		if ve, ok := err.(*ValidationError); ok {
+			return &ValidationError{
+				Field:   "prompt",
+				Message: strings.Replace(ve.Message, "input", "prompt", 1),
+			}
+		}
+		return err
+	}
+	if texts, ok := prompt.([]string); ok && len(texts) == 0 {
+		return &ValidationError{Field: "prompt", Message: "prompt array cannot be empty"}
+	}
+	req.Prompt = prompt
+
+	if req.Temperature != nil {
+		if *req.Temperature < 0 || *req.Temperature > 2 {
+			return &ValidationError{
+				Field:   "temperature",
+				Message: "temperature must be between 0 and 2",
+			}
+		}
+	}
+	if req.TopP != nil {
+		if *req.TopP < 0 || *req.TopP > 1 {
+			return &ValidationError{
+				Field:   "top_p",
+				Message: "top_p must be between 0 and 1",
+			}
+		}
+	}
+	if req.MaxTokens != nil && *req.MaxTokens < 1 {
+		return &ValidationError{
+			Field:   "max_tokens",
+			Message: "max_tokens must be at least 1",
+		}
+	}
+	if req.N != nil && *req.N < 1 {
+		return &ValidationError{Field: "n", Message: "n must be at least 1"}
+	}
+	if req.Logprobs != nil {
+		if *req.Logprobs < 0 || *req.Logprobs > 5 {
+			return &ValidationError{
+				Field:   "logprobs",
+				Message: "logprobs must be between 0 and 5",
+			}
+		}
+	}
+	if req.PresencePenalty != nil {
+		if *req.PresencePenalty < -2 || *req.PresencePenalty > 2 {
+			return &ValidationError{
+				Field:   "presence_penalty",
+				Message: "presence_penalty must be between -2 and 2",
+			}
+		}
+	}
+	if req.FrequencyPenalty != nil {
+		if *req.FrequencyPenalty < -2 || *req.FrequencyPenalty > 2 {
+			return &ValidationError{
+				Field:   "frequency_penalty",
+				Message: "frequency_penalty must be between -2 and 2",
+			}
+		}
+	}
+
+	return nil
+}
+
 // validateAudioTranscriptionRequest validates an AudioTranscriptionRequest.
 func validateAudioTranscriptionRequest(req *AudioTranscriptionRequest) error {
 	if req.File == nil {
@@ -303,6 +745,12 @@ func validateAudioSpeechRequest(req *AudioSpeechRequest) error {
 	if req.Input == "" {
 		return &ValidationError{Field: "input", Message: "input is required"}
 	}
+	if n := utf8.RuneCountInString(req.Input); n > MaxSpeechInputChars {
+		return &ValidationError{
+			Field:   "input",
+			Message: fmt.Sprintf("input is %d characters, exceeding the limit of %d", n, MaxSpeechInputChars),
+		}
+	}
 	if req.Voice == "" {
 		return &ValidationError{Field: "voice", Message: "voice is required"}
 	}
@@ -349,9 +797,168 @@ func validateModerationRequest(req *ModerationRequest) error {
 	if req.Input == nil {
 		return &ValidationError{Field: "input", Message: "input is required"}
 	}
+
+	// Multimodal input is sent as-is once its parts are checked
+	if parts, ok := req.Input.([]ModerationInput); ok {
+		return validateModerationInputs(parts)
+	}
+
+	// Coerce input to a string or []string
+	input, err := normalizeTextInput(req.Input)
+	if err != nil {
+		return err
+	}
+	req.Input = input
+
 	return nil
 }
 
+// validateModerationInputs checks the parts of a multimodal moderation input.
+func validateModerationInputs(parts []ModerationInput) error {
+	if len(parts) == 0 {
+		return &ValidationError{Field: "input", Message: "at least one input part is required"}
+	}
+	for i, part := range parts {
+		switch part.Type {
+		case "text":
+			if part.Text == "" {
+				return &ValidationError{
+					Field:   fmt.Sprintf("input[%d].text", i),
+					Message: "text is required",
+				}
+			}
+		case "image_url":
+			if part.ImageURL == nil || part.ImageURL.URL == "" {
+				return &ValidationError{
+					Field:   fmt.Sprintf("input[%d].image_url.url", i),
+					Message: "image URL is required",
+				}
+			}
+		default:
+			return &ValidationError{
+				Field:   fmt.Sprintf("input[%d].type", i),
+				Message: "type must be one of: text, image_url",
+			}
+		}
+	}
+	return nil
+}
+
+// NormalizeMetadata returns a copy of metadata with every value converted to
+// a string, as gateways that store request metadata require. Strings are kept
+// as-is; other values, including numbers, booleans, and nested objects, are
+// JSON-encoded. It returns nil for nil metadata, and a *ValidationError if a
+// value cannot be encoded.
+//
+// Chat, ChatStream, Messages, MessagesStream, and CreateMessagesBatch
+// normalize request metadata automatically unless Config.StrictMetadata is
+// set.
+//
+// Example:
+//
+//	meta, err := zaguansdk.NormalizeMetadata(map[string]interface{}{
+//		"user":    "u-123",
+//		"attempt": 2,
+//	})
+//	// meta == map[string]string{"user": "u-123", "attempt": "2"}
+func NormalizeMetadata(metadata map[string]interface{}) (map[string]string, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+
+	normalized := make(map[string]string, len(metadata))
+	for _, key := range sortedKeys(metadata) {
+		switch v := metadata[key].(type) {
+		case string:
+			normalized[key] = v
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, &ValidationError{
+					Field:   "metadata." + key,
+					Message: fmt.Sprintf("value cannot be encoded as JSON: %v", err),
+				}
+			}
+			normalized[key] = string(data)
+		}
+	}
+	return normalized, nil
+}
+
+// prepareMetadata returns request metadata ready to send. Values are
+// normalized to strings, or, with Config.StrictMetadata, non-string values
+// are rejected. field is the path of the metadata in the request, used in
+// errors.
+func (c *Client) prepareMetadata(metadata map[string]interface{}, field string) (map[string]interface{}, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+
+	if c.strictMetadata {
+		for _, key := range sortedKeys(metadata) {
+			if _, ok := metadata[key].(string); !ok {
+				return nil, &ValidationError{
+					Field:   field + "." + key,
+					Message: fmt.Sprintf("metadata values must be strings, got %T", metadata[key]),
+				}
+			}
+		}
+		return metadata, nil
+	}
+
+	normalized, err := NormalizeMetadata(metadata)
+	if err != nil {
+		if ve, ok := err.(*ValidationError); ok {
+			ve.Field = field + strings.TrimPrefix(ve.Field, "metadata")
+		}
+		return nil, err
+	}
+	result := make(map[string]interface{}, len(normalized))
+	for key, value := range normalized {
+		result[key] = value
+	}
+	return result, nil
+}
+
+// sortedKeys returns the keys of m in sorted order, so that validation
+// reports the same key on every run.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalizeTextInput coerces an untyped text input into its canonical form:
+// a string or []string. A []interface{} whose elements are all strings (as
+// produced by decoding JSON) is converted to []string. Any other type is
+// rejected with a *ValidationError.
+func normalizeTextInput(input interface{}) (interface{}, error) {
+	switch v := input.(type) {
+	case string, []string:
+		return v, nil
+	case []interface{}:
+		texts := make([]string, len(v))
+		for i, item := range v {
+			text, ok := item.(string)
+			if !ok {
+				return nil, &ValidationError{
+					Field:   "input",
+					Message: fmt.Sprintf("input[%d] must be a string, got %T", i, item),
+				}
+			}
+			texts[i] = text
+		}
+		return texts, nil
+	}
+	return nil, &ValidationError{
+		Field:   "input",
+		Message: fmt.Sprintf("input must be a string or array of strings, got %T", input),
+	}
+}
+
 // validateBatchRequest validates a BatchRequest.
 func validateBatchRequest(req *BatchRequest) error {
 	if req.InputFileID == "" {
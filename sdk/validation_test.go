@@ -1,7 +1,14 @@
 package zaguansdk
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/ZaguanLabs/zaguan-sdk-go/sdk/internal/testutil"
 )
 
 func TestValidateChatRequest(t *testing.T) {
@@ -111,6 +118,56 @@ func TestValidateChatRequest(t *testing.T) {
 			wantErr: true,
 			errMsg:  "max_tokens must be at least 1",
 		},
+		{
+			name: "invalid n",
+			req: ChatRequest{
+				Model: "openai/gpt-4o",
+				Messages: []Message{
+					{Role: "user", Content: "Hello"},
+				},
+				N: ptr(0),
+			},
+			wantErr: true,
+			errMsg:  "n must be at least 1",
+		},
+		{
+			name: "valid top_logprobs",
+			req: ChatRequest{
+				Model: "openai/gpt-4o",
+				Messages: []Message{
+					{Role: "user", Content: "Hello"},
+				},
+				Logprobs:    ptr(true),
+				TopLogprobs: ptr(20),
+			},
+			wantErr: false,
+		},
+		{
+			name: "top_logprobs out of range",
+			req: ChatRequest{
+				Model: "openai/gpt-4o",
+				Messages: []Message{
+					{Role: "user", Content: "Hello"},
+				},
+				Logprobs:    ptr(true),
+				TopLogprobs: ptr(21),
+			},
+			wantErr: true,
+			errMsg:  "top_logprobs must be between 0 and 20",
+		},
+		{
+			name: "top_logprobs without logprobs",
+			req: ChatRequest{
+				Model: "openai/gpt-4o",
+				Messages: []Message{
+					{Role: "user", Content: "Hello"},
+				},
+				Logprobs:    ptr(false),
+				TopLogprobs: ptr(3),
+			},
+			wantErr: true,
+			errMsg:  "top_logprobs requires logprobs to be true",
+		},
 		{
 			name: "invalid presence_penalty",
 			req: ChatRequest{
@@ -158,6 +215,69 @@ func TestValidateChatRequest(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "audio modality without audio config",
+			req: ChatRequest{
+				Model:      "openai/gpt-4o-audio-preview",
+				Messages:   []Message{{Role: "user", Content: "Hello"}},
+				Modalities: []string{"text", "audio"},
+			},
+			wantErr: true,
+			errMsg:  "audio is required when modalities include audio",
+		},
+		{
+			name: "audio config without audio modality",
+			req: ChatRequest{
+				Model:    "openai/gpt-4o-audio-preview",
+				Messages: []Message{{Role: "user", Content: "Hello"}},
+				Audio:    &AudioConfig{Voice: "alloy", Format: "wav"},
+			},
+			wantErr: true,
+			errMsg:  "modalities must include audio when audio is set",
+		},
+		{
+			name: "audio modality without voice",
+			req: ChatRequest{
+				Model:      "openai/gpt-4o-audio-preview",
+				Messages:   []Message{{Role: "user", Content: "Hello"}},
+				Modalities: []string{"text", "audio"},
+				Audio:      &AudioConfig{Format: "wav"},
+			},
+			wantErr: true,
+			errMsg:  "voice is required",
+		},
+		{
+			name: "audio modality with unknown voice",
+			req: ChatRequest{
+				Model:      "openai/gpt-4o-audio-preview",
+				Messages:   []Message{{Role: "user", Content: "Hello"}},
+				Modalities: []string{"text", "audio"},
+				Audio:      &AudioConfig{Voice: "robot", Format: "wav"},
+			},
+			wantErr: true,
+			errMsg:  "unsupported voice",
+		},
+		{
+			name: "audio modality with invalid format",
+			req: ChatRequest{
+				Model:      "openai/gpt-4o-audio-preview",
+				Messages:   []Message{{Role: "user", Content: "Hello"}},
+				Modalities: []string{"text", "audio"},
+				Audio:      &AudioConfig{Voice: "alloy", Format: "ogg"},
+			},
+			wantErr: true,
+			errMsg:  "format must be one of",
+		},
+		{
+			name: "valid audio output",
+			req: ChatRequest{
+				Model:      "openai/gpt-4o-audio-preview",
+				Messages:   []Message{{Role: "user", Content: "Hello"}},
+				Modalities: []string{"text", "audio"},
+				Audio:      &AudioConfig{Voice: "alloy", Format: "wav"},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -215,6 +335,19 @@ func TestValidateMessagesRequest(t *testing.T) {
 			wantErr: true,
 			errMsg:  "at least one message is required",
 		},
+		{
+			name: "tool missing input_schema",
+			req: MessagesRequest{
+				Model:     "anthropic/claude-3-5-sonnet-20241022",
+				MaxTokens: 1024,
+				Messages: []AnthropicMessage{
+					{Role: "user", Content: "Hello"},
+				},
+				Tools: []AnthropicTool{{Name: "get_weather"}},
+			},
+			wantErr: true,
+			errMsg:  "input_schema is required",
+		},
 		{
 			name: "missing max_tokens",
 			req: MessagesRequest{
@@ -268,7 +401,38 @@ func TestValidateMessagesRequest(t *testing.T) {
 				},
 			},
 			wantErr: true,
-			errMsg:  "thinking.budget_tokens must be between 1000 and 10000",
+			errMsg:  "thinking.budget_tokens must be at least 1000",
+		},
+		{
+			name: "thinking budget not below max_tokens",
+			req: MessagesRequest{
+				Model:     "anthropic/claude-3-7-sonnet-20250219",
+				MaxTokens: 4096,
+				Messages: []AnthropicMessage{
+					{Role: "user", Content: "Hello"},
+				},
+				Thinking: &AnthropicThinkingConfig{
+					Type:         "enabled",
+					BudgetTokens: 4096,
+				},
+			},
+			wantErr: true,
+			errMsg:  "thinking.budget_tokens (4096) must be less than max_tokens (4096)",
+		},
+		{
+			name: "large thinking budget",
+			req: MessagesRequest{
+				Model:     "anthropic/claude-3-7-sonnet-20250219",
+				MaxTokens: 64000,
+				Messages: []AnthropicMessage{
+					{Role: "user", Content: "Hello"},
+				},
+				Thinking: &AnthropicThinkingConfig{
+					Type:         "enabled",
+					BudgetTokens: 32000,
+				},
+			},
+			wantErr: false,
 		},
 	}
 
@@ -336,6 +500,55 @@ func TestValidateConfig(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "custom default headers",
+			cfg: Config{
+				BaseURL:        "https://api.example.com",
+				APIKey:         "test-key",
+				DefaultHeaders: http.Header{"X-Trace-Id": []string{"abc"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "force HTTP/1.1 with custom round tripper",
+			cfg: Config{
+				BaseURL:    "https://api.example.com",
+				APIKey:     "test-key",
+				HTTPClient: &http.Client{Transport: http.NewFileTransport(http.Dir("."))},
+				ForceHTTP1: true,
+			},
+			wantErr: true,
+			errMsg:  "ForceHTTP1 requires",
+		},
+		{
+			name: "reserved default header",
+			cfg: Config{
+				BaseURL:        "https://api.example.com",
+				APIKey:         "test-key",
+				DefaultHeaders: http.Header{"authorization": []string{"Bearer other"}},
+			},
+			wantErr: true,
+			errMsg:  "reserved header Authorization",
+		},
+		{
+			name: "virtual model header mode",
+			cfg: Config{
+				BaseURL:          "https://api.example.com",
+				APIKey:           "test-key",
+				VirtualModelMode: VirtualModelModeHeader,
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown virtual model mode",
+			cfg: Config{
+				BaseURL:          "https://api.example.com",
+				APIKey:           "test-key",
+				VirtualModelMode: "query",
+			},
+			wantErr: true,
+			errMsg:  "VirtualModelMode must be",
+		},
 	}
 
 	for _, tt := range tests {
@@ -354,6 +567,167 @@ func TestValidateConfig(t *testing.T) {
 	}
 }
 
+func TestTool_ValidateSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		tool    Tool
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid schema",
+			tool: Tool{
+				Type: "function",
+				Function: FunctionDefinition{
+					Name: "get_weather",
+					Parameters: map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"location": map[string]interface{}{"type": "string"},
+							"days": map[string]interface{}{
+								"type":  "array",
+								"items": map[string]interface{}{"type": "integer"},
+							},
+						},
+						"required": []string{"location"},
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "raw JSON schema",
+			tool: Tool{
+				Type: "function",
+				Function: FunctionDefinition{
+					Name:       "lookup",
+					Parameters: json.RawMessage(`{"type":"object","properties":{"id":{"type":"string"}},"required":["id"]}`),
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "no parameters",
+			tool: Tool{
+				Type:     "function",
+				Function: FunctionDefinition{Name: "ping"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "missing name",
+			tool: Tool{
+				Type:     "function",
+				Function: FunctionDefinition{},
+			},
+			wantErr: true,
+			errMsg:  "function name is required",
+		},
+		{
+			name: "not an object",
+			tool: Tool{
+				Type: "function",
+				Function: FunctionDefinition{
+					Name:       "bad",
+					Parameters: []string{"location"},
+				},
+			},
+			wantErr: true,
+			errMsg:  "parameters must be a JSON Schema object",
+		},
+		{
+			name: "missing type",
+			tool: Tool{
+				Type: "function",
+				Function: FunctionDefinition{
+					Name:       "bad",
+					Parameters: map[string]interface{}{"properties": map[string]interface{}{}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "schema type is required",
+		},
+		{
+			name: "properties not an object",
+			tool: Tool{
+				Type: "function",
+				Function: FunctionDefinition{
+					Name: "bad",
+					Parameters: map[string]interface{}{
+						"type":       "object",
+						"properties": []string{"location"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "properties must be an object",
+		},
+		{
+			name: "unresolvable required",
+			tool: Tool{
+				Type: "function",
+				Function: FunctionDefinition{
+					Name: "bad",
+					Parameters: map[string]interface{}{
+						"type": "object",
+						"properties": map[string]interface{}{
+							"location": map[string]interface{}{"type": "string"},
+						},
+						"required": []string{"locaton"},
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  `required property "locaton" is not defined in properties`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.tool.ValidateSchema()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSchema() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil && tt.errMsg != "" {
+				if !contains(err.Error(), tt.errMsg) {
+					t.Errorf("ValidateSchema() error = %v, want error containing %q", err, tt.errMsg)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateChatRequest_ToolSchema(t *testing.T) {
+	req := ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "Hello"},
+		},
+		Tools: []Tool{
+			{
+				Type: "function",
+				Function: FunctionDefinition{
+					Name:       "get_weather",
+					Parameters: map[string]interface{}{"properties": map[string]interface{}{}},
+				},
+			},
+		},
+	}
+
+	err := validateChatRequest(&req)
+	if err == nil {
+		t.Fatal("validateChatRequest() should reject invalid tool schema")
+	}
+	ve, ok := err.(*ValidationError)
+	if !ok {
+		t.Fatalf("error type = %T, want *ValidationError", err)
+	}
+	if ve.Field != "tools[0].function.parameters.type" {
+		t.Errorf("Field = %q, want tools[0].function.parameters.type", ve.Field)
+	}
+}
+
 // Helper functions
 
 func ptr[T any](v T) *T {
@@ -373,3 +747,236 @@ func findSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestNormalizeTextInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   interface{}
+		want    interface{}
+		wantErr string
+	}{
+		{name: "string", input: "hello", want: "hello"},
+		{name: "string slice", input: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "interface slice of strings", input: []interface{}{"a", "b"}, want: []string{"a", "b"}},
+		{name: "interface slice with number", input: []interface{}{"a", 1}, wantErr: "input[1] must be a string, got int"},
+		{name: "int", input: 42, wantErr: "input must be a string or array of strings, got int"},
+		{name: "int slice", input: []int{1, 2}, wantErr: "got []int"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizeTextInput(tt.input)
+			if tt.wantErr != "" {
+				valErr, ok := err.(*ValidationError)
+				if !ok {
+					t.Fatalf("normalizeTextInput() error = %v, want *ValidationError", err)
+				}
+				if valErr.Field != "input" || !strings.Contains(valErr.Message, tt.wantErr) {
+					t.Errorf("normalizeTextInput() error = %v, want message containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("normalizeTextInput() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeTextInput() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		metadata  map[string]interface{}
+		want      map[string]string
+		wantField string
+	}{
+		{name: "nil", metadata: nil, want: nil},
+		{
+			name: "mixed values",
+			metadata: map[string]interface{}{
+				"user":    "u-123",
+				"attempt": 2,
+				"beta":    true,
+				"tags":    []string{"a", "b"},
+				"ctx":     map[string]interface{}{"k": "v"},
+			},
+			want: map[string]string{
+				"user":    "u-123",
+				"attempt": "2",
+				"beta":    "true",
+				"tags":    `["a","b"]`,
+				"ctx":     `{"k":"v"}`,
+			},
+		},
+		{
+			name:      "unencodable value",
+			metadata:  map[string]interface{}{"fn": func() {}},
+			wantField: "metadata.fn",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeMetadata(tt.metadata)
+			if tt.wantField != "" {
+				valErr, ok := err.(*ValidationError)
+				if !ok || valErr.Field != tt.wantField {
+					t.Errorf("NormalizeMetadata() error = %v, want field %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeMetadata() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Metadata(t *testing.T) {
+	var gotMetadata map[string]interface{}
+	mockServer := testutil.NewMockServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Metadata map[string]interface{} `json:"metadata"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotMetadata = body.Metadata
+		testutil.ChatCompletionHandler(testutil.ChatCompletionFixture())(w, r)
+	}))
+	defer mockServer.Close()
+
+	req := ChatRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+		Metadata: map[string]interface{}{"user": "u-123", "attempt": 2},
+	}
+
+	// Non-string values are sent as strings
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+	if _, err := client.Chat(context.Background(), req, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	want := map[string]interface{}{"user": "u-123", "attempt": "2"}
+	if !reflect.DeepEqual(gotMetadata, want) {
+		t.Errorf("metadata = %v, want %v", gotMetadata, want)
+	}
+	if req.Metadata["attempt"] != 2 {
+		t.Errorf("Chat() modified the caller's metadata: %v", req.Metadata)
+	}
+
+	// Strict mode rejects them
+	strict := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key", StrictMetadata: true})
+	_, err := strict.Chat(context.Background(), req, nil)
+	valErr, ok := err.(*ValidationError)
+	if !ok || valErr.Field != "metadata.attempt" {
+		t.Errorf("Chat() error = %v, want a metadata.attempt ValidationError", err)
+	}
+
+	// Batch items are reported by index
+	_, err = strict.CreateMessagesBatch(context.Background(), MessagesBatchRequest{
+		Requests: []MessagesBatchItem{{
+			CustomID: "req-1",
+			Params: MessagesRequest{
+				Model:     "anthropic/claude-3-5-sonnet",
+				MaxTokens: 100,
+				Messages:  []AnthropicMessage{{Role: "user", Content: "Hello"}},
+				Metadata:  map[string]interface{}{"n": 1},
+			},
+		}},
+	}, nil)
+	valErr, ok = err.(*ValidationError)
+	if !ok || valErr.Field != "requests[0].params.metadata.n" {
+		t.Errorf("CreateMessagesBatch() error = %v, want a requests[0].params.metadata.n ValidationError", err)
+	}
+}
+
+func TestValidateToolMessages(t *testing.T) {
+	call := ToolCall{ID: "call_1", Type: "function", Function: FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`}}
+
+	tests := []struct {
+		name      string
+		messages  []Message
+		wantField string
+	}{
+		{
+			name: "paired tool call",
+			messages: []Message{
+				{Role: "user", Content: "Weather in Paris?"},
+				{Role: "assistant", ToolCalls: []ToolCall{call}},
+				{Role: "tool", ToolCallID: "call_1", Content: "18C"},
+			},
+		},
+		{
+			name: "missing tool_call_id",
+			messages: []Message{
+				{Role: "user", Content: "Weather in Paris?"},
+				{Role: "assistant", ToolCalls: []ToolCall{call}},
+				{Role: "tool", Content: "18C"},
+			},
+			wantField: "messages[2].tool_call_id",
+		},
+		{
+			name: "orphaned tool message",
+			messages: []Message{
+				{Role: "user", Content: "Weather in Paris?"},
+				{Role: "tool", ToolCallID: "call_1", Content: "18C"},
+			},
+			wantField: "messages[1].tool_call_id",
+		},
+		{
+			name: "unknown tool_call_id",
+			messages: []Message{
+				{Role: "user", Content: "Weather in Paris?"},
+				{Role: "assistant", ToolCalls: []ToolCall{call}},
+				{Role: "tool", ToolCallID: "call_2", Content: "18C"},
+			},
+			wantField: "messages[2].tool_call_id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateToolMessages(tt.messages)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("validateToolMessages() error = %v, want nil", err)
+				}
+				return
+			}
+			valErr, ok := err.(*ValidationError)
+			if !ok || valErr.Field != tt.wantField {
+				t.Errorf("validateToolMessages() error = %v, want a %s ValidationError", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestChat_StrictToolMessages(t *testing.T) {
+	mockServer := testutil.NewMockServer(testutil.ChatCompletionHandler(testutil.ChatCompletionFixture()))
+	defer mockServer.Close()
+
+	req := ChatRequest{
+		Model: "openai/gpt-4o",
+		Messages: []Message{
+			{Role: "user", Content: "Weather in Paris?"},
+			{Role: "tool", ToolCallID: "call_1", Content: "18C"},
+		},
+	}
+
+	// Off by default: the request is sent as-is
+	client := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key"})
+	if _, err := client.Chat(context.Background(), req, nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	strict := NewClient(Config{BaseURL: mockServer.URL(), APIKey: "test-key", StrictToolMessages: true})
+	_, err := strict.Chat(context.Background(), req, nil)
+	if valErr, ok := err.(*ValidationError); !ok || valErr.Field != "messages[1].tool_call_id" {
+		t.Errorf("Chat() error = %v, want a messages[1].tool_call_id ValidationError", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Package zaguantest provides helpers for testing code that uses the Zaguan
+// SDK.
+//
+// This file implements FakeClock, a zaguansdk.Clock that tests control.
+package zaguantest
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a Clock whose time only moves when Advance or Set is called.
+//
+// It is safe for concurrent use.
+//
+// Example:
+//
+//	clock := zaguantest.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+//	client := zaguansdk.NewClient(zaguansdk.Config{
+//		BaseURL: server.URL,
+//		APIKey:  "test-key",
+//		Clock:   clock,
+//	})
+//	// ...
+//	clock.Advance(10 * time.Second)
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+// fakeWaiter is a pending After call on a FakeClock.
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock creates a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the fake current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives the fake time once the clock has
+// been advanced by at least d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing any timers that expire.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(c.now.Add(d))
+}
+
+// Set moves the clock to now, firing any timers that expire.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(now)
+}
+
+// Waiters returns the number of pending After calls. Tests can poll it to
+// know when code under test is waiting on the clock.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// setLocked sets the time and fires expired timers. c.mu must be held.
+func (c *FakeClock) setLocked(now time.Time) {
+	c.now = now
+
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !now.Before(w.deadline) {
+			w.ch <- now
+		} else {
+			pending = append(pending, w)
+		}
+	}
+	c.waiters = pending
+}
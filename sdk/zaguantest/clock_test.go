@@ -0,0 +1,53 @@
+package zaguantest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	if !clock.Now().Equal(start) {
+		t.Errorf("Now() = %v, want %v", clock.Now(), start)
+	}
+
+	short := clock.After(time.Second)
+	long := clock.After(time.Minute)
+	if clock.Waiters() != 2 {
+		t.Errorf("Waiters() = %d, want 2", clock.Waiters())
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case got := <-short:
+		if !got.Equal(start.Add(30 * time.Second)) {
+			t.Errorf("short timer fired at %v", got)
+		}
+	default:
+		t.Error("short timer should fire after Advance")
+	}
+	select {
+	case <-long:
+		t.Error("long timer should not fire yet")
+	default:
+	}
+
+	clock.Set(start.Add(time.Hour))
+	select {
+	case <-long:
+	default:
+		t.Error("long timer should fire after Set")
+	}
+	if clock.Waiters() != 0 {
+		t.Errorf("Waiters() = %d, want 0", clock.Waiters())
+	}
+
+	// Non-positive durations fire immediately
+	select {
+	case <-clock.After(0):
+	default:
+		t.Error("After(0) should fire immediately")
+	}
+}